@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	notifierclient "github.com/PDeXchange/pac/internal/pkg/notifier/client"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/db"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+// digester mails the digest of the events batched for every user once a day at the time of the day set through the
+// backends mailing the digest, the events are claimed with a lease so that the replicas don't mail the same digest
+func digester(db db.DB, backends notifierclient.Notifiers, at time.Duration) {
+	l := log.GetLogger()
+	owner := getLeaseOwner()
+	for {
+		next := nextDigestTime(time.Now(), at)
+		l.Info("Next digest scheduled", zap.Time("at", next))
+		time.Sleep(time.Until(next))
+		sendDigests(db, backends, owner)
+	}
+}
+
+// nextDigestTime returns the time the digest is mailed next, at the duration since the midnight UTC
+func nextDigestTime(now time.Time, at time.Duration) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).Add(at)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// parseDigestTime parses the time of the day in HH:MM format to the duration since the midnight
+func parseDigestTime(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of the day %s, must be in HH:MM format", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// sendDigests mails the digest to every user having events batched for it, the events the digest failed for are
+// mailed in the next one until they are dead-lettered after the max attempts
+func sendDigests(db db.DB, backends notifierclient.Notifiers, owner string) {
+	l := log.GetLogger()
+	userIDs, err := db.GetDigestUserIDs()
+	if err != nil {
+		l.Error("Error getting digest users", zap.Error(err))
+		return
+	}
+	l.Info("Sending digests", zap.Int("users", len(userIDs)))
+	for _, userID := range userIDs {
+		events, err := db.ClaimDigestEvents(userID, owner, time.Now(), leaseDuration)
+		if err != nil {
+			l.Error("Error claiming digest events", zap.String("user id", userID), zap.Error(err))
+			continue
+		}
+		// claimed by another notifier
+		if len(events) == 0 {
+			continue
+		}
+		err = sendDigest(backends, userID, events)
+		now := time.Now()
+		if err != nil {
+			l.Error("Error mailing digest", zap.String("user id", userID), zap.Int("events", len(events)), zap.Error(err))
+		}
+		for i := range events {
+			if err != nil && !isDigestDelivered(backends, &events[i]) {
+				events[i].SetDigestFailed(err, now, maxDeliveryAttempts)
+			} else {
+				events[i].SetDelivered(now)
+			}
+			if err := db.UpdateEventDelivery(events[i].ID.Hex(), owner, now, events[i].Delivery); err != nil {
+				l.Error("Error updating event", zap.String("id", events[i].ID.Hex()), zap.Error(err))
+			}
+		}
+	}
+}
+
+// sendDigest mails every backend the digest of the events not mailed through it yet, the backends mailed are recorded
+// on the events so that the next digest doesn't mail them again through the same backend
+func sendDigest(backends notifierclient.Notifiers, userID string, events []models.Event) error {
+	if len(backends) == 0 {
+		return errors.New("none of the notifiers mails the digest")
+	}
+	email, err := lookupEmail(&events[0])
+	if err != nil {
+		return fmt.Errorf("error retrieving user information: %w", err)
+	}
+	var errs []error
+	for _, backend := range backends {
+		var pending []models.Event
+		for _, event := range events {
+			if !slices.Contains(event.Delivery.DigestDeliveredTo, backend.Name) {
+				pending = append(pending, event)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		digest := models.NewEventDigest(userID, email, pending, time.Now())
+		if err := backend.Notifier.(notifierclient.DigestNotifier).NotifyDigest(*digest); err != nil {
+			errs = append(errs, fmt.Errorf("error mailing digest through %s: %w", backend.Name, err))
+			continue
+		}
+		for i := range events {
+			if !slices.Contains(events[i].Delivery.DigestDeliveredTo, backend.Name) {
+				events[i].Delivery.DigestDeliveredTo = append(events[i].Delivery.DigestDeliveredTo, backend.Name)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isDigestDelivered returns true if the digest including the event is mailed through all the backends
+func isDigestDelivered(backends notifierclient.Notifiers, event *models.Event) bool {
+	for _, backend := range backends {
+		if !slices.Contains(event.Delivery.DigestDeliveredTo, backend.Name) {
+			return false
+		}
+	}
+	return len(backends) > 0
+}
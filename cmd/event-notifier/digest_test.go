@@ -0,0 +1,224 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	notifierclient "github.com/PDeXchange/pac/internal/pkg/notifier/client"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+func TestNextDigestTime(t *testing.T) {
+	testcases := []struct {
+		name string
+		now  time.Time
+		at   time.Duration
+		want time.Time
+	}{
+		{
+			name: "later today",
+			now:  time.Date(2026, 1, 2, 6, 0, 0, 0, time.UTC),
+			at:   8 * time.Hour,
+			want: time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "tomorrow once passed",
+			now:  time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC),
+			at:   8 * time.Hour,
+			want: time.Date(2026, 1, 3, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "tomorrow at the time itself",
+			now:  time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC),
+			at:   8 * time.Hour,
+			want: time.Date(2026, 1, 3, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "next month",
+			now:  time.Date(2026, 1, 31, 23, 30, 0, 0, time.UTC),
+			at:   30 * time.Minute,
+			want: time.Date(2026, 2, 1, 0, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "in UTC regardless of the zone",
+			now:  time.Date(2026, 1, 2, 6, 0, 0, 0, time.FixedZone("IST", 5*3600+1800)),
+			at:   8 * time.Hour,
+			want: time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, nextDigestTime(tc.now, tc.at))
+		})
+	}
+}
+
+func TestParseDigestTime(t *testing.T) {
+	testcases := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "08:00", want: 8 * time.Hour},
+		{value: "23:59", want: 23*time.Hour + 59*time.Minute},
+		{value: "00:00", want: 0},
+		{value: "24:00", wantErr: true},
+		{value: "8am", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseDigestTime(tc.value)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestSendDigests(t *testing.T) {
+	mockDB, tearDown := setUp(t)
+	defer tearDown()
+
+	events := func(userID string, customize func(*models.Event)) []models.Event {
+		return []models.Event{
+			*newTestEvent(func(e *models.Event) {
+				e.UserID = userID
+				e.Delivery.State = models.EventDeliveryDigest
+				if customize != nil {
+					customize(e)
+				}
+			}),
+			*newTestEvent(func(e *models.Event) {
+				e.UserID = userID
+				e.Type = models.EventGroupJoinRequest
+				e.Delivery.State = models.EventDeliveryDigest
+				if customize != nil {
+					customize(e)
+				}
+			}),
+		}
+	}
+	testcases := []struct {
+		name     string
+		mockFunc func()
+		failing  map[string]bool
+		// wantDigests are the emails the digests are mailed to per backend
+		wantDigests map[string][]string
+		// wantStates are the states the events are updated to, in order
+		wantStates          []models.EventDeliveryState
+		wantDigestDelivered []string
+	}{
+		{
+			name: "digests mailed",
+			mockFunc: func() {
+				mockDB.EXPECT().GetDigestUserIDs().Return([]string{"12345", "67890"}, nil).Times(1)
+				mockDB.EXPECT().ClaimDigestEvents("12345", "test-notifier", gomock.Any(), leaseDuration).Return(events("12345", nil), nil).Times(1)
+				mockDB.EXPECT().ClaimDigestEvents("67890", "test-notifier", gomock.Any(), leaseDuration).Return(events("67890", nil), nil).Times(1)
+			},
+			wantDigests: map[string][]string{
+				"mail": {"12345@example.com", "67890@example.com"},
+				"smtp": {"12345@example.com", "67890@example.com"},
+			},
+			wantStates: []models.EventDeliveryState{models.EventDeliveryDelivered, models.EventDeliveryDelivered,
+				models.EventDeliveryDelivered, models.EventDeliveryDelivered},
+			wantDigestDelivered: []string{"mail", "smtp"},
+		},
+		{
+			name: "events claimed by another notifier skipped",
+			mockFunc: func() {
+				mockDB.EXPECT().GetDigestUserIDs().Return([]string{"12345", "67890"}, nil).Times(1)
+				mockDB.EXPECT().ClaimDigestEvents("12345", "test-notifier", gomock.Any(), leaseDuration).Return([]models.Event{}, nil).Times(1)
+				mockDB.EXPECT().ClaimDigestEvents("67890", "test-notifier", gomock.Any(), leaseDuration).Return(nil, errors.New("db unavailable")).Times(1)
+			},
+		},
+		{
+			name: "failed digest kept for the next one",
+			mockFunc: func() {
+				mockDB.EXPECT().GetDigestUserIDs().Return([]string{"12345"}, nil).Times(1)
+				mockDB.EXPECT().ClaimDigestEvents("12345", "test-notifier", gomock.Any(), leaseDuration).Return(events("12345", nil), nil).Times(1)
+			},
+			failing:             map[string]bool{"smtp": true},
+			wantDigests:         map[string][]string{"mail": {"12345@example.com"}, "smtp": {"12345@example.com"}},
+			wantStates:          []models.EventDeliveryState{models.EventDeliveryDigest, models.EventDeliveryDigest},
+			wantDigestDelivered: []string{"mail"},
+		},
+		{
+			name: "only the failed backend mailed in the next digest",
+			mockFunc: func() {
+				mockDB.EXPECT().GetDigestUserIDs().Return([]string{"12345"}, nil).Times(1)
+				mockDB.EXPECT().ClaimDigestEvents("12345", "test-notifier", gomock.Any(), leaseDuration).Return(events("12345", func(e *models.Event) {
+					e.Delivery.Attempts = 1
+					e.Delivery.DigestDeliveredTo = []string{"mail"}
+				}), nil).Times(1)
+			},
+			wantDigests:         map[string][]string{"smtp": {"12345@example.com"}},
+			wantStates:          []models.EventDeliveryState{models.EventDeliveryDelivered, models.EventDeliveryDelivered},
+			wantDigestDelivered: []string{"mail", "smtp"},
+		},
+		{
+			name: "dead-lettered at max attempts",
+			mockFunc: func() {
+				mockDB.EXPECT().GetDigestUserIDs().Return([]string{"12345"}, nil).Times(1)
+				mockDB.EXPECT().ClaimDigestEvents("12345", "test-notifier", gomock.Any(), leaseDuration).Return(events("12345", func(e *models.Event) {
+					e.Delivery.Attempts = 2
+				}), nil).Times(1)
+			},
+			failing:     map[string]bool{"mail": true, "smtp": true},
+			wantDigests: map[string][]string{"mail": {"12345@example.com"}, "smtp": {"12345@example.com"}},
+			wantStates:  []models.EventDeliveryState{models.EventDeliveryDeadLetter, models.EventDeliveryDeadLetter},
+		},
+		{
+			name: "user email not found",
+			mockFunc: func() {
+				mockDB.EXPECT().GetDigestUserIDs().Return([]string{"unknown"}, nil).Times(1)
+				mockDB.EXPECT().ClaimDigestEvents("unknown", "test-notifier", gomock.Any(), leaseDuration).Return(events("unknown", nil), nil).Times(1)
+			},
+			wantStates: []models.EventDeliveryState{models.EventDeliveryDigest, models.EventDeliveryDigest},
+		},
+		{
+			name: "digest users not fetched",
+			mockFunc: func() {
+				mockDB.EXPECT().GetDigestUserIDs().Return(nil, errors.New("db unavailable")).Times(1)
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			var states []models.EventDeliveryState
+			mockDB.EXPECT().UpdateEventDelivery(gomock.Any(), "test-notifier", gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_, _ string, _ time.Time, delivery models.EventDelivery) error {
+					states = append(states, delivery.State)
+					assert.Equal(t, tc.wantDigestDelivered, delivery.DigestDeliveredTo)
+					return nil
+				}).Times(len(tc.wantStates))
+			backends := map[string]*fakeNotifier{}
+			var notifiers notifierclient.Notifiers
+			for _, name := range []string{"mail", "smtp"} {
+				backends[name] = &fakeNotifier{}
+				if tc.failing[name] {
+					backends[name].err = errors.New("unavailable")
+				}
+				notifiers = append(notifiers, notifierclient.Backend{Name: name, Notifier: backends[name]})
+			}
+
+			sendDigests(mockDB, notifiers, "test-notifier")
+			digests := map[string][]string{}
+			for name, backend := range backends {
+				for _, digest := range backend.digests {
+					digests[name] = append(digests[name], digest.UserEmail)
+					assert.Len(t, digest.Groups, 2)
+				}
+			}
+			if tc.wantDigests == nil {
+				tc.wantDigests = map[string][]string{}
+			}
+			assert.Equal(t, tc.wantDigests, digests)
+			assert.Equal(t, tc.wantStates, states)
+		})
+	}
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
+	flag "github.com/spf13/pflag"
 	"go.uber.org/zap"
 
+	"github.com/PDeXchange/pac/internal/pkg/notifier/client"
 	"github.com/PDeXchange/pac/internal/pkg/notifier/client/mail"
+	"github.com/PDeXchange/pac/internal/pkg/notifier/client/smtp"
+	"github.com/PDeXchange/pac/internal/pkg/notifier/client/webhook"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/db/mongodb"
 	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
 )
 
 var (
@@ -16,8 +23,58 @@ var (
 	hostname               = os.Getenv("KEYCLOAK_HOSTNAME")
 	serviceAccount         = os.Getenv("KEYCLOAK_SERVICE_ACCOUNT")
 	serviceAccountPassword = os.Getenv("KEYCLOAK_SERVICE_ACCOUNT_PASSWORD")
+
+	notifiers           []string
+	maxDeliveryAttempts int
+	retryBackoff        time.Duration
+	pollInterval        time.Duration
+	leaseDuration       time.Duration
+	digestAt            string
+	templatesDir        string
 )
 
+// notifierBackends are the backends the events can be notified through, each is configured by its env variables
+var notifierBackends = map[string]func() []client.Backend{
+	"mail":    singleBackend("mail", mail.New),
+	"smtp":    singleBackend("smtp", smtp.New),
+	"webhook": webhook.NewBackends,
+}
+
+// singleBackend returns the constructor of the backend named after the notifier
+func singleBackend(name string, newNotifier func() client.Notifier) func() []client.Backend {
+	return func() []client.Backend {
+		return []client.Backend{{Name: name, Notifier: newNotifier()}}
+	}
+}
+
+func initFlags() {
+	flag.StringSliceVar(&notifiers, "notifiers", []string{"mail"}, "comma separated list of the backends to notify the events through, valid values are mail, smtp, webhook")
+	flag.IntVar(&maxDeliveryAttempts, "max-delivery-attempts", 5, "number of attempts to notify an event before it is dead-lettered")
+	flag.DurationVar(&retryBackoff, "retry-backoff", time.Minute, "wait before retrying a failed notification, doubles with every attempt")
+	flag.DurationVar(&pollInterval, "poll-interval", 5*time.Second, "interval to check for the events due for notification when there are none")
+	flag.DurationVar(&leaseDuration, "lease-duration", 5*time.Minute,
+		"duration an event is claimed for by a notifier, must be longer than a notification takes")
+	flag.StringVar(&digestAt, "digest-at", "08:00", "time of the day in HH:MM UTC to mail the daily digest of the events at")
+	flag.StringVar(&templatesDir, "templates-dir", "", "directory with the mail templates overriding the built-in ones, e.g. a mounted ConfigMap")
+	flag.Parse()
+}
+
+// newNotifier returns the notifier fanning out the events to the backends, the webhook fans out to a backend per endpoint
+func newNotifier(backends []string) (client.Notifiers, error) {
+	var n client.Notifiers
+	for _, backend := range backends {
+		newBackends, ok := notifierBackends[backend]
+		if !ok {
+			return nil, fmt.Errorf("invalid notifier %s, valid values are mail, smtp, webhook", backend)
+		}
+		n = append(n, newBackends()...)
+	}
+	if len(n) == 0 {
+		return nil, errors.New("at least one notifier is required")
+	}
+	return n, nil
+}
+
 func validateEnvVars() error {
 	globalVars := map[string]string{
 		"KEYCLOAK_REALM":                    realm,
@@ -34,11 +91,28 @@ func validateEnvVars() error {
 }
 
 func main() {
+	initFlags()
 	l := log.GetLogger()
 	l.Info("Starting event notifier")
 	if err := validateEnvVars(); err != nil {
 		l.Fatal("Env variable not set or empty", zap.Error(err))
 	}
+	if maxDeliveryAttempts < 1 || retryBackoff <= 0 || pollInterval <= 0 || leaseDuration <= 0 {
+		l.Fatal("max-delivery-attempts, retry-backoff, poll-interval and lease-duration must be positive")
+	}
+	notifierClient, err := newNotifier(notifiers)
+	if err != nil {
+		l.Fatal("Error creating notifiers", zap.Strings("notifiers", notifiers), zap.Error(err))
+	}
+	if templatesDir != "" {
+		if err := models.LoadEventTemplates(templatesDir); err != nil {
+			l.Fatal("Error loading templates", zap.String("dir", templatesDir), zap.Error(err))
+		}
+	}
+	digestTime, err := parseDigestTime(digestAt)
+	if err != nil {
+		l.Fatal("Invalid digest-at", zap.Error(err))
+	}
 	db := mongodb.New()
 	if err := db.Connect(); err != nil {
 		l.Fatal("Error connecting to MongoDB", zap.Error(err))
@@ -49,6 +123,10 @@ func main() {
 		}
 	}
 	defer disconnect()
-	mailClient := mail.New()
-	notifier(db, mailClient)
+	if digestBackends := notifierClient.DigestBackends(); len(digestBackends) > 0 {
+		go digester(db, digestBackends, digestTime)
+	} else {
+		l.Warn("None of the notifiers mails the digest, the events batched for the digest are notified right away")
+	}
+	notifier(db, notifierClient)
 }
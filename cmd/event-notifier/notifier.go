@@ -3,46 +3,140 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/Nerzal/gocloak/v13"
-	mailclient "github.com/PDeXchange/pac/internal/pkg/notifier/client"
+	notifierclient "github.com/PDeXchange/pac/internal/pkg/notifier/client"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/db"
 	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
 )
 
-func notifier(db db.DB, mailClient mailclient.Notifier) {
+// lookupEmail returns the email of the user the event is for from Keycloak
+var lookupEmail = getEmailForEvent
+
+// notifier delivers the events claimed one by one, the claims are leased so that several notifiers can run at once
+func notifier(db db.DB, notifierClient notifierclient.Notifiers) {
 	l := log.GetLogger()
-	eventCh := make(chan *models.Event)
-	go func() {
-		err := db.WatchEvents(eventCh)
+	owner := getLeaseOwner()
+	l.Info("Claiming events", zap.String("owner", owner))
+	for {
+		event, err := db.ClaimEvent(owner, time.Now(), leaseDuration)
 		if err != nil {
-			l.Fatal("error watching events", zap.Error(err))
+			l.Error("Error claiming event", zap.Error(err))
+			time.Sleep(pollInterval)
+			continue
 		}
-	}()
-
-	for event := range eventCh {
-		var err error
-		l.Info("Received an event:", zap.Any("Document", event))
-		// skip if already notified
-		if event.Notified {
-			l.Info("Already notified")
+		if event == nil {
+			time.Sleep(pollInterval)
 			continue
 		}
-		l.Info("Notifying")
-		if event.UserEmail, err = getEmailForEvent(event); err != nil {
-			l.Error("Error retrieving user information", zap.Error(err))
+		l.Info("Notifying", zap.String("id", event.ID.Hex()), zap.String("type", string(event.Type)),
+			zap.Int("attempts", event.Delivery.Attempts))
+		deliverEvent(db, notifierClient, event)
+	}
+}
+
+// getLeaseOwner returns the identity of the notifier the events are claimed by
+func getLeaseOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "event-notifier"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// deliverEvent notifies the event and records the delivery, the failed delivery is retried with exponential backoff
+// until the event is dead-lettered after the max attempts. The delivery isn't recorded if the lease on the event is
+// over.
+func deliverEvent(db db.DB, notifierClient notifierclient.Notifiers, event *models.Event) {
+	l := log.GetLogger()
+	// the lease is released on recording the delivery
+	owner := event.Delivery.LeaseOwner
+	channel, err := getNotificationChannel(db, event)
+	// the events are notified right away if none of the notifiers mails the digest
+	if channel == models.NotificationChannelDigest && len(notifierClient.DigestBackends()) == 0 {
+		channel = models.NotificationChannelEmail
+	}
+	if err == nil {
+		err = routeEvent(notifierClient, event, channel)
+	}
+	now := time.Now()
+	if err != nil {
+		event.SetDeliveryFailed(err, now, maxDeliveryAttempts, retryBackoff)
+		l.Error("Error notifying", zap.String("id", event.ID.Hex()), zap.Int("attempts", event.Delivery.Attempts),
+			zap.String("state", string(event.Delivery.State)), zap.Error(err))
+	}
+	if err := db.UpdateEventDelivery(event.ID.Hex(), owner, now, event.Delivery); err != nil {
+		if errors.Is(err, utils.ErrResourceModified) {
+			l.Warn("Lease of the event is over, the delivery is left to the notifier claimed it", zap.String("id", event.ID.Hex()))
+			return
 		}
-		if err = mailClient.Notify(*event); err != nil {
-			l.Error("Error notifying", zap.Error(err))
+		l.Error("Error updating event", zap.Error(err))
+	}
+}
+
+// getNotificationChannel returns the channel the user chose for the type of the event, the events not notifying the
+// user are muted
+func getNotificationChannel(db db.DB, event *models.Event) (models.NotificationChannel, error) {
+	if !event.Notify {
+		return models.NotificationChannelMuted, nil
+	}
+	preferences, err := db.GetNotificationPreferences(event.UserID)
+	if err != nil && !errors.Is(err, utils.ErrResourceNotFound) {
+		return "", fmt.Errorf("error getting notification preferences: %w", err)
+	}
+	return preferences.GetChannel(event.Type), nil
+}
+
+// routeEvent notifies the event through the channel and sets the delivery state, the admin is notified right away
+// regardless of the channel chosen by the user
+func routeEvent(notifierClient notifierclient.Notifiers, event *models.Event, channel models.NotificationChannel) error {
+	switch channel {
+	case models.NotificationChannelEmail:
+		if err := notify(notifierClient, event); err != nil {
+			return err
 		}
-		if err = db.MarkEventAsNotified(event.ID.Hex()); err != nil {
-			l.Error("Error updating event", zap.Error(err))
+		event.SetDelivered(time.Now())
+	default:
+		if event.NotifyAdmin {
+			adminEvent := *event
+			adminEvent.Notify = false
+			if err := notifyPending(notifierClient, event, adminEvent); err != nil {
+				return err
+			}
+		}
+		if channel == models.NotificationChannelDigest {
+			event.SetDeliveryDigest()
+		} else {
+			event.SetDeliverySkipped()
 		}
 	}
+	return nil
+}
+
+// notify notifies the event to the user, the admin is notified too if set on the event
+func notify(notifierClient notifierclient.Notifiers, event *models.Event) error {
+	email, err := lookupEmail(event)
+	if err != nil {
+		return fmt.Errorf("error retrieving user information: %w", err)
+	}
+	event.UserEmail = email
+	return notifyPending(notifierClient, event, *event)
+}
+
+// notifyPending notifies through the backends the event is not delivered through yet, the backends notified are
+// recorded on the event so that the retry of the failed delivery doesn't notify them again
+func notifyPending(notifierClient notifierclient.Notifiers, event *models.Event, notified models.Event) error {
+	delivered, err := notifierClient.NotifyPending(notified, event.Delivery.DeliveredTo)
+	event.Delivery.DeliveredTo = append(event.Delivery.DeliveredTo, delivered...)
+	return err
 }
 
 func getEmailForEvent(event *models.Event) (string, error) {
@@ -51,7 +145,7 @@ func getEmailForEvent(event *models.Event) (string, error) {
 	token, err := kc.LoginAdmin(context.Background(), serviceAccount, serviceAccountPassword, realm)
 	if err != nil {
 		l.Error("failed to get access token", zap.Error(err))
-		return "", errors.New("ailed to get access token")
+		return "", errors.New("failed to get access token")
 	}
 
 	// Build Context
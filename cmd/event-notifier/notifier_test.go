@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	notifierclient "github.com/PDeXchange/pac/internal/pkg/notifier/client"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/db"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+// fakeNotifier records the events and the digests notified, the notifications fail with err if set
+type fakeNotifier struct {
+	err     error
+	events  []models.Event
+	digests []models.EventDigest
+}
+
+func (f *fakeNotifier) Notify(event models.Event) error {
+	f.events = append(f.events, event)
+	return f.err
+}
+
+func (f *fakeNotifier) NotifyDigest(digest models.EventDigest) error {
+	f.digests = append(f.digests, digest)
+	return f.err
+}
+
+func setUp(t *testing.T) (*db.MockDB, func()) {
+	ctrl := gomock.NewController(t)
+	maxDeliveryAttempts = 3
+	retryBackoff = time.Minute
+	leaseDuration = time.Minute
+	lookupEmail = func(event *models.Event) (string, error) {
+		if event.UserID == "unknown" {
+			return "", errors.New("user not found")
+		}
+		return event.UserID + "@example.com", nil
+	}
+	return db.NewMockDB(ctrl), func() {
+		ctrl.Finish()
+		lookupEmail = getEmailForEvent
+	}
+}
+
+func newTestEvent(customize func(*models.Event)) *models.Event {
+	leasedUntil := time.Now().Add(time.Minute)
+	event := &models.Event{
+		ID:          primitive.NewObjectID(),
+		Type:        models.EventTypeRequestApproved,
+		UserID:      "12345",
+		Notify:      true,
+		NotifyAdmin: true,
+		Log:         models.EventLog{Level: models.EventLogLevelINFO, Message: "Request has been approved"},
+		Delivery: models.EventDelivery{
+			State:       models.EventDeliveryPending,
+			LeaseOwner:  "test-notifier",
+			LeasedUntil: &leasedUntil,
+		},
+	}
+	if customize != nil {
+		customize(event)
+	}
+	return event
+}
+
+func TestDeliverEvent(t *testing.T) {
+	mockDB, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name            string
+		event           *models.Event
+		mockFunc        func()
+		failing         map[string]bool
+		wantState       models.EventDeliveryState
+		wantAttempts    int
+		wantDeliveredTo []string
+		// wantNotified is the number of the events notified per backend, and whether they are for the user
+		wantNotified map[string]int
+		wantUser     bool
+	}{
+		{
+			name:  "mailed to the user",
+			event: newTestEvent(nil),
+			mockFunc: func() {
+				mockDB.EXPECT().GetNotificationPreferences("12345").Return(nil, utils.ErrResourceNotFound).Times(1)
+			},
+			wantState:       models.EventDeliveryDelivered,
+			wantDeliveredTo: []string{"mail", "webhook"},
+			wantNotified:    map[string]int{"mail": 1, "webhook": 1},
+			wantUser:        true,
+		},
+		{
+			name:  "batched for the digest, the admin notified right away",
+			event: newTestEvent(nil),
+			mockFunc: func() {
+				mockDB.EXPECT().GetNotificationPreferences("12345").Return(&models.NotificationPreferences{
+					Channels: map[models.EventType]models.NotificationChannel{models.EventTypeRequestApproved: models.NotificationChannelDigest},
+				}, nil).Times(1)
+			},
+			wantState:       models.EventDeliveryDigest,
+			wantDeliveredTo: []string{"mail", "webhook"},
+			wantNotified:    map[string]int{"mail": 1, "webhook": 1},
+		},
+		{
+			name: "skipped for the muted type",
+			event: newTestEvent(func(e *models.Event) {
+				e.NotifyAdmin = false
+			}),
+			mockFunc: func() {
+				mockDB.EXPECT().GetNotificationPreferences("12345").Return(&models.NotificationPreferences{
+					Channels: map[models.EventType]models.NotificationChannel{models.EventTypeRequestApproved: models.NotificationChannelMuted},
+				}, nil).Times(1)
+			},
+			wantState:    models.EventDeliverySkipped,
+			wantNotified: map[string]int{"mail": 0, "webhook": 0},
+		},
+		{
+			name: "admin only event skipped for the user",
+			event: newTestEvent(func(e *models.Event) {
+				e.Notify = false
+			}),
+			mockFunc:        func() {},
+			wantState:       models.EventDeliverySkipped,
+			wantDeliveredTo: []string{"mail", "webhook"},
+			wantNotified:    map[string]int{"mail": 1, "webhook": 1},
+		},
+		{
+			name:  "failed backend retried",
+			event: newTestEvent(nil),
+			mockFunc: func() {
+				mockDB.EXPECT().GetNotificationPreferences("12345").Return(nil, nil).Times(1)
+			},
+			failing:         map[string]bool{"webhook": true},
+			wantState:       models.EventDeliveryRetrying,
+			wantAttempts:    1,
+			wantDeliveredTo: []string{"mail"},
+			wantNotified:    map[string]int{"mail": 1, "webhook": 1},
+			wantUser:        true,
+		},
+		{
+			name: "only the failed backend notified on retry",
+			event: newTestEvent(func(e *models.Event) {
+				e.Delivery.State = models.EventDeliveryRetrying
+				e.Delivery.Attempts = 1
+				e.Delivery.DeliveredTo = []string{"mail"}
+			}),
+			mockFunc: func() {
+				mockDB.EXPECT().GetNotificationPreferences("12345").Return(nil, nil).Times(1)
+			},
+			wantState:       models.EventDeliveryDelivered,
+			wantAttempts:    1,
+			wantDeliveredTo: []string{"mail", "webhook"},
+			wantNotified:    map[string]int{"mail": 0, "webhook": 1},
+			wantUser:        true,
+		},
+		{
+			name: "dead-lettered at max attempts",
+			event: newTestEvent(func(e *models.Event) {
+				e.Delivery.State = models.EventDeliveryRetrying
+				e.Delivery.Attempts = 2
+			}),
+			mockFunc: func() {
+				mockDB.EXPECT().GetNotificationPreferences("12345").Return(nil, nil).Times(1)
+			},
+			failing:      map[string]bool{"mail": true, "webhook": true},
+			wantState:    models.EventDeliveryDeadLetter,
+			wantAttempts: 3,
+			wantNotified: map[string]int{"mail": 1, "webhook": 1},
+			wantUser:     true,
+		},
+		{
+			name: "user email not found",
+			event: newTestEvent(func(e *models.Event) {
+				e.UserID = "unknown"
+			}),
+			mockFunc: func() {
+				mockDB.EXPECT().GetNotificationPreferences("unknown").Return(nil, nil).Times(1)
+			},
+			wantState:    models.EventDeliveryRetrying,
+			wantAttempts: 1,
+			wantNotified: map[string]int{"mail": 0, "webhook": 0},
+		},
+		{
+			name:  "preferences not fetched",
+			event: newTestEvent(nil),
+			mockFunc: func() {
+				mockDB.EXPECT().GetNotificationPreferences("12345").Return(nil, errors.New("db unavailable")).Times(1)
+			},
+			wantState:    models.EventDeliveryRetrying,
+			wantAttempts: 1,
+			wantNotified: map[string]int{"mail": 0, "webhook": 0},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			backends := map[string]*fakeNotifier{}
+			var notifiers notifierclient.Notifiers
+			for _, name := range []string{"mail", "webhook"} {
+				backends[name] = &fakeNotifier{}
+				if tc.failing[name] {
+					backends[name].err = errors.New("unavailable")
+				}
+				notifiers = append(notifiers, notifierclient.Backend{Name: name, Notifier: backends[name]})
+			}
+			mockDB.EXPECT().UpdateEventDelivery(tc.event.ID.Hex(), "test-notifier", gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_, _ string, _ time.Time, delivery models.EventDelivery) error {
+					assert.Equal(t, tc.wantState, delivery.State)
+					assert.Equal(t, tc.wantAttempts, delivery.Attempts)
+					assert.Equal(t, tc.wantDeliveredTo, delivery.DeliveredTo)
+					assert.Empty(t, delivery.LeaseOwner)
+					return nil
+				}).Times(1)
+
+			deliverEvent(mockDB, notifiers, tc.event)
+			for name, notified := range tc.wantNotified {
+				assert.Len(t, backends[name].events, notified, name)
+				for _, event := range backends[name].events {
+					assert.Equal(t, tc.wantUser, event.Notify, name)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliverEventLeaseOver(t *testing.T) {
+	mockDB, tearDown := setUp(t)
+	defer tearDown()
+
+	event := newTestEvent(nil)
+	notifier := &fakeNotifier{}
+	mockDB.EXPECT().GetNotificationPreferences("12345").Return(nil, nil).Times(1)
+	mockDB.EXPECT().UpdateEventDelivery(event.ID.Hex(), "test-notifier", gomock.Any(), gomock.Any()).
+		Return(utils.ErrResourceModified).Times(1)
+	deliverEvent(mockDB, notifierclient.Notifiers{{Name: "mail", Notifier: notifier}}, event)
+	assert.Len(t, notifier.events, 1)
+}
+
+func TestDeliverEventWithoutDigestNotifier(t *testing.T) {
+	mockDB, tearDown := setUp(t)
+	defer tearDown()
+
+	event := newTestEvent(nil)
+	// the webhook doesn't mail the digest
+	webhook := &fakeNotifier{}
+	notifiers := notifierclient.Notifiers{{Name: "webhook", Notifier: struct{ notifierclient.Notifier }{webhook}}}
+	mockDB.EXPECT().GetNotificationPreferences("12345").Return(&models.NotificationPreferences{
+		Channels: map[models.EventType]models.NotificationChannel{models.EventTypeRequestApproved: models.NotificationChannelDigest},
+	}, nil).Times(1)
+	mockDB.EXPECT().UpdateEventDelivery(event.ID.Hex(), "test-notifier", gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_, _ string, _ time.Time, delivery models.EventDelivery) error {
+			assert.Equal(t, models.EventDeliveryDelivered, delivery.State)
+			return nil
+		}).Times(1)
+
+	deliverEvent(mockDB, notifiers, event)
+	if assert.Len(t, webhook.events, 1) {
+		assert.True(t, webhook.events[0].Notify)
+	}
+}
@@ -18,6 +18,12 @@ import (
 
 var (
 	servicePort = "8000"
+	// approvalPolicies is the path of the JSON file with the approval chains required for the sensitive requests
+	approvalPolicies string
+	// staleRequestAges is the age after which the pending requests of every type are expired
+	staleRequestAges map[string]string
+	// groupExitPolicy is the policy applied to the group exits leaving the services of the user over the quota
+	groupExitPolicy string
 )
 
 func initFlags() {
@@ -26,6 +32,19 @@ func initFlags() {
 	flag.DurationVar(&models.ExpiryNotificationDuration, "expiry-notification-duration", 48*time.Hour,
 		`set duration for notification for about-to-expire services,
 		e.g. 45s, 2m, 1h30m, 20h, default: 48h which means that user will start receiving expiry notifications 48 hrs before service expiry, once a day`)
+	flag.IntSliceVar(&models.QuotaThresholds, "quota-thresholds", []int{80, 100},
+		"comma separated list of quota utilization percentages, users are notified once on crossing each of them")
+	flag.StringVar(&approvalPolicies, "approval-policies", "", "path of the JSON file with the approval policies for the requests")
+	flag.StringToStringVar(&staleRequestAges, "stale-request-ages", map[string]string{"GROUP": "720h"},
+		"comma separated list of request type and the age after which its pending requests are expired, e.g. GROUP=720h,QUOTA_INCREASE=168h")
+	flag.DurationVar(&models.StaleRequestReminder, "stale-request-reminder", 48*time.Hour,
+		"duration before the expiry of a pending request the approvers are reminded at")
+	flag.StringVar(&groupExitPolicy, "group-exit-over-quota", string(models.GroupExitPolicyBlock),
+		"policy for the group exits leaving the services of the user over the quota, block: exit is not approved until the services are deleted, expire: services are expired after the notice")
+	flag.DurationVar(&models.GroupExitExpiryNotice, "group-exit-expiry-notice", 72*time.Hour,
+		"notice given to the user before the services exceeding the quota after the group exit are expired")
+	flag.DurationVar(&models.EventRetention, "event-retention", 90*24*time.Hour,
+		"duration the events are kept for before they are removed, the events are kept forever if it is 0")
 	flag.Parse()
 }
 
@@ -34,6 +53,21 @@ func main() {
 	logger.Info("Starting PAC server...")
 	initFlags()
 
+	if approvalPolicies != "" {
+		logger.Info("Loading approval policies", zap.String("path", approvalPolicies))
+		if err := services.LoadApprovalPolicies(approvalPolicies); err != nil {
+			panic(err)
+		}
+	}
+
+	if err := services.LoadStaleRequestAges(staleRequestAges); err != nil {
+		panic(err)
+	}
+
+	if err := services.LoadGroupExitPolicy(groupExitPolicy); err != nil {
+		panic(err)
+	}
+
 	logger.Info("Attempting to connect to MongoDB...")
 	db := mongodb.New()
 	if err := db.Connect(); err != nil {
@@ -54,6 +88,12 @@ func main() {
 	logger.Info("Starting service expiry notifier")
 	go services.ExpiryNotification()
 
+	logger.Info("Starting quota threshold notifier")
+	go services.QuotaThresholdNotification()
+
+	logger.Info("Starting stale request expiry")
+	go services.StaleRequestExpiry()
+
 	var appRouter = router.CreateRouter()
 	logger.Info("PAC server is up and running", zap.String("port", servicePort))
 	logger.Fatal("Error encountered while routing", zap.Error(appRouter.Run(":"+servicePort)))
@@ -1,7 +1,63 @@
 package client
 
-import "github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
 
 type Notifier interface {
 	Notify(event models.Event) error
 }
+
+// DigestNotifier is implemented by the notifiers mailing the digest of the events to the user
+type DigestNotifier interface {
+	NotifyDigest(digest models.EventDigest) error
+}
+
+// Backend is a notifier named after the backend it notifies through, the deliveries of the events are tracked by the
+// name
+type Backend struct {
+	Name string
+	Notifier
+}
+
+// Notifiers fans out the events to all the backends
+type Notifiers []Backend
+
+// Notify notifies the event through all the backends, the failures of any of them are returned together
+func (n Notifiers) Notify(event models.Event) error {
+	_, err := n.NotifyPending(event, nil)
+	return err
+}
+
+// NotifyPending notifies the event through the backends it is not delivered through yet and returns the backends it
+// is delivered through now, the failures of the others are returned together so that only they are retried
+func (n Notifiers) NotifyPending(event models.Event, delivered []string) ([]string, error) {
+	var notified []string
+	var errs []error
+	for _, backend := range n {
+		if slices.Contains(delivered, backend.Name) {
+			continue
+		}
+		if err := backend.Notify(event); err != nil {
+			errs = append(errs, fmt.Errorf("error notifying through %s: %w", backend.Name, err))
+			continue
+		}
+		notified = append(notified, backend.Name)
+	}
+	return notified, errors.Join(errs...)
+}
+
+// DigestBackends returns the backends mailing the digest, i.e. the ones implementing DigestNotifier
+func (n Notifiers) DigestBackends() Notifiers {
+	var backends Notifiers
+	for _, backend := range n {
+		if _, ok := backend.Notifier.(DigestNotifier); ok {
+			backends = append(backends, backend)
+		}
+	}
+	return backends
+}
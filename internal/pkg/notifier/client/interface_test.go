@@ -0,0 +1,69 @@
+package client
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+type fakeNotifier struct {
+	err      error
+	notified int
+}
+
+func (f *fakeNotifier) Notify(_ models.Event) error {
+	f.notified++
+	return f.err
+}
+
+func TestNotifyPending(t *testing.T) {
+	testcases := []struct {
+		name          string
+		failing       map[string]bool
+		delivered     []string
+		wantDelivered []string
+		wantNotified  map[string]int
+		wantErr       bool
+	}{
+		{
+			name:          "delivered through all the backends",
+			wantDelivered: []string{"mail", "webhook"},
+			wantNotified:  map[string]int{"mail": 1, "webhook": 1},
+		},
+		{
+			name:          "failed backend reported",
+			failing:       map[string]bool{"webhook": true},
+			wantDelivered: []string{"mail"},
+			wantNotified:  map[string]int{"mail": 1, "webhook": 1},
+			wantErr:       true,
+		},
+		{
+			name:          "delivered backends not notified again",
+			delivered:     []string{"mail"},
+			wantDelivered: []string{"webhook"},
+			wantNotified:  map[string]int{"mail": 0, "webhook": 1},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			backends := map[string]*fakeNotifier{}
+			var n Notifiers
+			for _, name := range []string{"mail", "webhook"} {
+				backends[name] = &fakeNotifier{}
+				if tc.failing[name] {
+					backends[name].err = errors.New("unavailable")
+				}
+				n = append(n, Backend{Name: name, Notifier: backends[name]})
+			}
+			delivered, err := n.NotifyPending(models.Event{}, tc.delivered)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.wantDelivered, delivered)
+			for name, notified := range tc.wantNotified {
+				assert.Equal(t, notified, backends[name].notified, name)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package mail
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -15,6 +16,7 @@ import (
 )
 
 var _ client.Notifier = &Mail{}
+var _ client.DigestNotifier = &Mail{}
 
 var l = log.GetLogger()
 
@@ -32,31 +34,81 @@ func (m *Mail) Notify(event models.Event) error {
 		return err
 	}
 
-	content := mail.NewContent("text", plainTextContent)
-	m1.AddContent(content)
+	htmlContent, err := event.ComposeMailHTMLBody()
+	if err != nil {
+		return err
+	}
+	subject, err := event.ComposeMailSubject()
+	if err != nil {
+		return err
+	}
+
+	// the plain text content must precede the HTML one
+	m1.AddContent(mail.NewContent("text/plain", plainTextContent), mail.NewContent("text/html", htmlContent))
 
 	personalization := mail.NewPersonalization()
 	to := mail.NewEmail("", event.UserEmail)
 	// TODO: Add BCC to all the admins or to the group alias when we have it
 	bcc := mail.NewEmail("IBM® Power® Access Cloud", "PowerACL@ibm.com")
-	personalization.AddTos(to)
-	if event.NotifyAdmin {
-		personalization.AddBCCs(bcc)
+	// the admin is mailed directly if the user isn't notified
+	if event.UserEmail == "" {
+		if !event.NotifyAdmin {
+			return errors.New("no recipient to mail the event to")
+		}
+		personalization.AddTos(bcc)
+	} else {
+		personalization.AddTos(to)
+		if event.NotifyAdmin {
+			personalization.AddBCCs(bcc)
+		}
 	}
-	personalization.Subject = fmt.Sprintf("IBM® Power® Access Cloud - %s", event.Type)
+	personalization.Subject = subject
 
 	m1.AddPersonalizations(personalization)
+	return m.send(m1)
+}
 
+// NotifyDigest mails the digest to the user
+func (m *Mail) NotifyDigest(digest models.EventDigest) error {
+	if digest.UserEmail == "" {
+		return errors.New("no recipient to mail the digest to")
+	}
+	m1 := mail.NewV3Mail()
+	m1.SetFrom(m.from)
+
+	plainTextContent, err := digest.ComposeMailBody()
+	if err != nil {
+		return err
+	}
+	htmlContent, err := digest.ComposeMailHTMLBody()
+	if err != nil {
+		return err
+	}
+	subject, err := digest.ComposeMailSubject()
+	if err != nil {
+		return err
+	}
+	m1.AddContent(mail.NewContent("text/plain", plainTextContent), mail.NewContent("text/html", htmlContent))
+
+	personalization := mail.NewPersonalization()
+	personalization.AddTos(mail.NewEmail("", digest.UserEmail))
+	personalization.Subject = subject
+	m1.AddPersonalizations(personalization)
+	return m.send(m1)
+}
+
+func (m *Mail) send(m1 *mail.SGMailV3) error {
 	req := m.request
 	req.Body = mail.GetRequestBody(m1)
 	response, err := sendgrid.API(req)
-
 	if err != nil {
 		l.Error("Error sending mail", zap.Error(err))
+		return fmt.Errorf("error sending mail: %w", err)
 	}
 
 	if response.StatusCode != 202 {
 		l.Error("Error sending mail, response code is not 202", zap.Int("code", response.StatusCode))
+		return fmt.Errorf("error sending mail, response code is %d: %s", response.StatusCode, response.Body)
 	}
 
 	return nil
@@ -0,0 +1,274 @@
+package smtp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	gosmtp "net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/PDeXchange/pac/internal/pkg/notifier/client"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+// TLSMode is how the connection to the SMTP server is secured
+type TLSMode string
+
+const (
+	// TLSModeStartTLS upgrades the plain connection with STARTTLS, the server must support it
+	TLSModeStartTLS TLSMode = "starttls"
+	// TLSModeImplicit connects over TLS from the start, usually on port 465
+	TLSModeImplicit TLSMode = "implicit"
+	// TLSModeNone doesn't secure the connection, meant for the relays on the local network only
+	TLSModeNone TLSMode = "none"
+
+	defaultTimeout = 30 * time.Second
+)
+
+var defaultPorts = map[TLSMode]string{
+	TLSModeStartTLS: "587",
+	TLSModeImplicit: "465",
+	TLSModeNone:     "25",
+}
+
+var _ client.Notifier = &SMTP{}
+var _ client.DigestNotifier = &SMTP{}
+
+var l = log.GetLogger()
+
+type SMTP struct {
+	host    string
+	port    string
+	tlsMode TLSMode
+	// tlsConfig is used for both STARTTLS and implicit TLS
+	tlsConfig *tls.Config
+	// auth is nil if the server doesn't require the authentication
+	auth gosmtp.Auth
+	from netmail.Address
+	// admin is blind copied on the events notifying the admin, if set
+	admin string
+}
+
+// Notify mails the event to the user with a multipart plain text and HTML body
+func (s *SMTP) Notify(event models.Event) error {
+	var to []string
+	if event.UserEmail != "" {
+		to = append(to, event.UserEmail)
+	}
+	var bcc []string
+	if event.NotifyAdmin && s.admin != "" {
+		bcc = append(bcc, s.admin)
+	}
+	if len(to)+len(bcc) == 0 {
+		return errors.New("no recipient to mail the event to")
+	}
+	subject, err := event.ComposeMailSubject()
+	if err != nil {
+		return fmt.Errorf("error composing mail subject: %w", err)
+	}
+	return s.mail(subject, &event, to, bcc)
+}
+
+// NotifyDigest mails the digest to the user with a multipart plain text and HTML body
+func (s *SMTP) NotifyDigest(digest models.EventDigest) error {
+	if digest.UserEmail == "" {
+		return errors.New("no recipient to mail the digest to")
+	}
+	subject, err := digest.ComposeMailSubject()
+	if err != nil {
+		return fmt.Errorf("error composing mail subject: %w", err)
+	}
+	return s.mail(subject, &digest, []string{digest.UserEmail}, nil)
+}
+
+// mailBody is the content mailed, either an event or a digest
+type mailBody interface {
+	ComposeMailBody() (string, error)
+	ComposeMailHTMLBody() (string, error)
+}
+
+func (s *SMTP) mail(subject string, body mailBody, to, bcc []string) error {
+	msg, err := s.composeMessage(subject, body, to)
+	if err != nil {
+		return fmt.Errorf("error composing mail: %w", err)
+	}
+	if err := s.send(append(to, bcc...), msg); err != nil {
+		l.Error("Error sending mail", zap.String("host", s.host), zap.Error(err))
+		return fmt.Errorf("error sending mail: %w", err)
+	}
+	return nil
+}
+
+// composeMessage composes the message with the plain text and the HTML alternatives of the body, the blind copied
+// recipients are not disclosed in the headers
+func (s *SMTP) composeMessage(subject string, content mailBody, to []string) ([]byte, error) {
+	plainBody, err := content.ComposeMailBody()
+	if err != nil {
+		return nil, err
+	}
+	htmlBody, err := content.ComposeMailHTMLBody()
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", plainBody},
+		{"text/html; charset=UTF-8", htmlBody},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(w)
+		if _, err := qw.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := []struct{ key, value string }{
+		{"From", s.from.String()},
+		{"Subject", mime.QEncoding.Encode("UTF-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary())},
+	}
+	if len(to) > 0 {
+		headers = append(headers, struct{ key, value string }{"To", to[0]})
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&msg, "%s: %s\r\n", header.key, header.value)
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+	return msg.Bytes(), nil
+}
+
+// send sends the message to the recipients over a connection secured as per the TLS mode
+func (s *SMTP) send(recipients []string, msg []byte) error {
+	addr := net.JoinHostPort(s.host, s.port)
+	dialer := &net.Dialer{Timeout: defaultTimeout}
+	var conn net.Conn
+	var err error
+	if s.tlsMode == TLSModeImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, s.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %w", addr, err)
+	}
+	c, err := gosmtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() {
+		if err := c.Close(); err != nil {
+			l.Debug("Error closing the SMTP connection", zap.Error(err))
+		}
+	}()
+
+	if s.tlsMode == TLSModeStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("server doesn't support STARTTLS")
+		}
+		if err := c.StartTLS(s.tlsConfig); err != nil {
+			return fmt.Errorf("error starting TLS: %w", err)
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return fmt.Errorf("error authenticating: %w", err)
+		}
+	}
+	if err := c.Mail(s.from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := c.Rcpt(recipient); err != nil {
+			return fmt.Errorf("error adding recipient %s: %w", recipient, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// New returns the notifier mailing through the SMTP server configured by the env variables:
+//   - SMTP_HOST and SMTP_PORT of the server, the port defaults as per the TLS mode
+//   - SMTP_TLS_MODE, one of starttls, implicit and none, defaults to starttls
+//   - SMTP_USERNAME and SMTP_PASSWORD to authenticate with, if the server requires
+//   - SMTP_FROM address the mails are sent from, e.g. "IBM® Power® Access Cloud <PowerACL@ibm.com>"
+//   - SMTP_ADMIN_EMAIL blind copied on the events notifying the admin
+func New() client.Notifier {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		l.Fatal("SMTP_HOST not set")
+	}
+	tlsMode := TLSMode(os.Getenv("SMTP_TLS_MODE"))
+	if tlsMode == "" {
+		tlsMode = TLSModeStartTLS
+	}
+	port, ok := defaultPorts[tlsMode]
+	if !ok {
+		l.Fatal("invalid SMTP_TLS_MODE, valid values are starttls, implicit, none", zap.String("mode", string(tlsMode)))
+	}
+	if p := os.Getenv("SMTP_PORT"); p != "" {
+		if _, err := strconv.ParseUint(p, 10, 16); err != nil {
+			l.Fatal("invalid SMTP_PORT", zap.String("port", p), zap.Error(err))
+		}
+		port = p
+	}
+	from, err := netmail.ParseAddress(os.Getenv("SMTP_FROM"))
+	if err != nil {
+		l.Fatal("SMTP_FROM not set or invalid", zap.Error(err))
+	}
+	var auth gosmtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = gosmtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return &SMTP{
+		host:      host,
+		port:      port,
+		tlsMode:   tlsMode,
+		tlsConfig: &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12},
+		auth:      auth,
+		from:      *from,
+		admin:     os.Getenv("SMTP_ADMIN_EMAIL"),
+	}
+}
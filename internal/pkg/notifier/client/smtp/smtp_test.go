@@ -0,0 +1,257 @@
+package smtp
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	gosmtp "net/smtp"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+// smtpServer is an in-process stand-in of the SMTP server, recording the mail received
+type smtpServer struct {
+	listener net.Listener
+	// tlsConfig is offered over STARTTLS unless the connection is implicitly secured
+	tlsConfig *tls.Config
+	startTLS  bool
+
+	mu         sync.Mutex
+	auth       string
+	from       string
+	recipients []string
+	data       string
+	secured    bool
+}
+
+func newSMTPServer(t *testing.T, tlsConfig *tls.Config, mode TLSMode) *smtpServer {
+	var listener net.Listener
+	var err error
+	if mode == TLSModeImplicit {
+		listener, err = tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	} else {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &smtpServer{listener: listener, tlsConfig: tlsConfig, startTLS: mode == TLSModeStartTLS}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, mode == TLSModeImplicit)
+		}
+	}()
+	t.Cleanup(func() { _ = listener.Close() })
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn, secured bool) {
+	defer func() { _ = conn.Close() }()
+	text := textproto.NewConn(conn)
+	_ = text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		s.mu.Lock()
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			if s.startTLS && !secured {
+				_ = text.PrintfLine("250-localhost\r\n250-STARTTLS\r\n250 AUTH PLAIN")
+			} else {
+				_ = text.PrintfLine("250-localhost\r\n250 AUTH PLAIN")
+			}
+		case "STARTTLS":
+			_ = text.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				s.mu.Unlock()
+				return
+			}
+			conn, secured = tlsConn, true
+			text = textproto.NewConn(conn)
+		case "AUTH":
+			credentials, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(arg, "PLAIN "))
+			s.auth = string(credentials)
+			_ = text.PrintfLine("235 Authenticated")
+		case "MAIL":
+			s.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			s.secured = secured
+			_ = text.PrintfLine("250 OK")
+		case "RCPT":
+			s.recipients = append(s.recipients, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			_ = text.PrintfLine("250 OK")
+		case "DATA":
+			_ = text.PrintfLine("354 Go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				s.mu.Unlock()
+				return
+			}
+			s.data = string(data)
+			_ = text.PrintfLine("250 OK")
+		case "QUIT":
+			_ = text.PrintfLine("221 Bye")
+			s.mu.Unlock()
+			return
+		default:
+			_ = text.PrintfLine("502 Command not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestNotify(t *testing.T) {
+	// the test certificate of httptest is valid for 127.0.0.1
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	serverTLSConfig := &tls.Config{Certificates: tlsServer.TLS.Certificates}
+	clientTLSConfig := &tls.Config{
+		ServerName: "127.0.0.1",
+		RootCAs:    tlsServer.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+	}
+	tlsServer.Close()
+
+	testcases := []struct {
+		name       string
+		serverMode TLSMode
+		clientMode TLSMode
+		event      models.Event
+		recipients []string
+		secured    bool
+		wantErr    bool
+	}{
+		{
+			name:       "mailed over STARTTLS",
+			serverMode: TLSModeStartTLS,
+			clientMode: TLSModeStartTLS,
+			event:      models.Event{Type: models.EventTypeRequestApproved, UserEmail: "user@example.com"},
+			recipients: []string{"user@example.com"},
+			secured:    true,
+		},
+		{
+			name:       "mailed over implicit TLS with admin blind copied",
+			serverMode: TLSModeImplicit,
+			clientMode: TLSModeImplicit,
+			event:      models.Event{Type: models.EventTypeRequestApproved, UserEmail: "user@example.com", NotifyAdmin: true},
+			recipients: []string{"user@example.com", "admin@example.com"},
+			secured:    true,
+		},
+		{
+			name:       "mailed without TLS",
+			serverMode: TLSModeNone,
+			clientMode: TLSModeNone,
+			event:      models.Event{Type: models.EventTypeRequestApproved, UserEmail: "user@example.com"},
+			recipients: []string{"user@example.com"},
+		},
+		{
+			name:       "server not supporting STARTTLS",
+			serverMode: TLSModeNone,
+			clientMode: TLSModeStartTLS,
+			event:      models.Event{Type: models.EventTypeRequestApproved, UserEmail: "user@example.com"},
+			wantErr:    true,
+		},
+		{
+			name:       "no recipient",
+			serverMode: TLSModeStartTLS,
+			clientMode: TLSModeStartTLS,
+			event:      models.Event{Type: models.EventTypeRequestApproved},
+			wantErr:    true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newSMTPServer(t, serverTLSConfig, tc.serverMode)
+			_, port, _ := net.SplitHostPort(server.listener.Addr().String())
+			s := &SMTP{
+				host:      "127.0.0.1",
+				port:      port,
+				tlsMode:   tc.clientMode,
+				tlsConfig: clientTLSConfig,
+				auth:      gosmtp.PlainAuth("", "user", "password", "127.0.0.1"),
+				from:      netmail.Address{Name: "IBM® Power® Access Cloud", Address: "pac@example.com"},
+				admin:     "admin@example.com",
+			}
+			tc.event.CreatedAt = time.Now()
+			tc.event.Log = models.EventLog{Level: models.EventLogLevelINFO, Message: "Request has been approved"}
+
+			err := s.Notify(tc.event)
+			assert.Equal(t, tc.wantErr, err != nil)
+			if tc.wantErr {
+				return
+			}
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			assert.Equal(t, "\x00user\x00password", server.auth)
+			assert.Equal(t, "pac@example.com", server.from)
+			assert.Equal(t, tc.recipients, server.recipients)
+			assert.Equal(t, tc.secured, server.secured)
+
+			msg, err := netmail.ReadMessage(strings.NewReader(server.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, "user@example.com", msg.Header.Get("To"))
+			assert.Empty(t, msg.Header.Get("Bcc"))
+			assert.Contains(t, msg.Header.Get("Content-Type"), "multipart/alternative")
+			assert.Contains(t, server.data, "Content-Type: text/plain; charset=UTF-8")
+			assert.Contains(t, server.data, "Content-Type: text/html; charset=UTF-8")
+			assert.Contains(t, server.data, "Request has been approved")
+		})
+	}
+}
+
+func TestNotifyDigest(t *testing.T) {
+	server := newSMTPServer(t, nil, TLSModeNone)
+	_, port, _ := net.SplitHostPort(server.listener.Addr().String())
+	s := &SMTP{
+		host:    "127.0.0.1",
+		port:    port,
+		tlsMode: TLSModeNone,
+		from:    netmail.Address{Name: "IBM® Power® Access Cloud", Address: "pac@example.com"},
+		admin:   "admin@example.com",
+	}
+	now := time.Now()
+	events := []models.Event{
+		{Type: models.EventQuotaThreshold, CreatedAt: now, Log: models.EventLog{Message: "Quota usage is above 80%"}},
+		{Type: models.EventTypeRequestApproved, CreatedAt: now, Log: models.EventLog{Message: "Request has been approved"}},
+		{Type: models.EventQuotaThreshold, CreatedAt: now, Log: models.EventLog{Message: "Quota usage is above 90%"}},
+	}
+	digest := models.NewEventDigest("12345", "user@example.com", events, now)
+
+	err := s.NotifyDigest(*digest)
+	assert.NoError(t, err)
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, []string{"user@example.com"}, server.recipients)
+	msg, err := netmail.ReadMessage(strings.NewReader(server.data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "IBM® Power® Access Cloud - Daily digest", subject)
+	// grouped by type in the order of the event types
+	approved := strings.Index(server.data, string(models.EventTypeRequestApproved)+" (1)")
+	threshold := strings.Index(server.data, string(models.EventQuotaThreshold)+" (2)")
+	assert.True(t, approved >= 0 && threshold > approved)
+	assert.Contains(t, server.data, "Quota usage is above 90%")
+
+	assert.Error(t, s.NotifyDigest(models.EventDigest{UserID: "12345"}))
+}
@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/PDeXchange/pac/internal/pkg/notifier/client"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the timestamp and the body, e.g. sha256=<hex>
+	SignatureHeader = "X-PAC-Signature"
+	// TimestampHeader carries the unix time the payload is signed at, receivers should reject the stale payloads
+	TimestampHeader = "X-PAC-Timestamp"
+
+	defaultTimeout = 10 * time.Second
+)
+
+var _ client.Notifier = &Webhook{}
+
+var l = log.GetLogger()
+
+// Payload is the JSON body posted to the endpoints, Text lets the incoming webhooks of the chat ops render the event
+type Payload struct {
+	ID          string               `json:"id"`
+	Type        models.EventType     `json:"type"`
+	CreatedAt   time.Time            `json:"created_at"`
+	Originator  string               `json:"originator"`
+	UserID      string               `json:"user_id"`
+	NotifyAdmin bool                 `json:"notify_admin"`
+	Level       models.EventLogLevel `json:"level"`
+	Message     string               `json:"message"`
+	Text        string               `json:"text"`
+	// Resource and Metadata are the structured details of the event, if set
+	Resource *models.EventResource `json:"resource,omitempty"`
+	Metadata map[string]string     `json:"metadata,omitempty"`
+}
+
+// Webhook posts the events to a single endpoint, every endpoint is a backend of its own so that the deliveries are
+// tracked per endpoint
+type Webhook struct {
+	endpoint   string
+	secret     []byte
+	httpClient *http.Client
+}
+
+// Notify posts the event to the endpoint once. The failed delivery is retried by the event notifier with backoff, hence
+// not retried here so as not to hold up the other events.
+func (w *Webhook) Notify(event models.Event) error {
+	body, err := json.Marshal(Payload{
+		ID:          event.ID.Hex(),
+		Type:        event.Type,
+		CreatedAt:   event.CreatedAt,
+		Originator:  event.Originator,
+		UserID:      event.UserID,
+		NotifyAdmin: event.NotifyAdmin,
+		Level:       event.Log.Level,
+		Message:     event.Log.Message,
+		Text:        fmt.Sprintf("[%s] %s", event.Type, event.Log.Message),
+		Resource:    event.Resource,
+		Metadata:    event.Metadata,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling the webhook payload: %w", err)
+	}
+	if err := w.post(body); err != nil {
+		l.Error("Error delivering webhook", zap.String("endpoint", w.endpoint), zap.Error(err))
+		return fmt.Errorf("error delivering to %s: %w", w.endpoint, err)
+	}
+	return nil
+}
+
+// post posts the signed body to the endpoint
+func (w *Webhook) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, timestamp, body))
+	}
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			l.Debug("Error closing the response body", zap.Error(err))
+		}
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and the body joined by a dot, receivers compute the same
+// to verify the payload
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewBackends returns a backend named webhook:<endpoint> for each of the comma separated endpoints of WEBHOOK_URLS, the
+// payloads are signed with WEBHOOK_SECRET
+func NewBackends() []client.Backend {
+	var endpoints []string
+	for _, endpoint := range strings.Split(os.Getenv("WEBHOOK_URLS"), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) == 0 {
+		l.Fatal("WEBHOOK_URLS not set")
+	}
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if secret == "" {
+		l.Fatal("WEBHOOK_SECRET not set")
+	}
+	httpClient := &http.Client{Timeout: defaultTimeout}
+	var backends []client.Backend
+	for _, endpoint := range endpoints {
+		backends = append(backends, client.Backend{
+			Name:     "webhook:" + endpoint,
+			Notifier: &Webhook{endpoint: endpoint, secret: []byte(secret), httpClient: httpClient},
+		})
+	}
+	return backends
+}
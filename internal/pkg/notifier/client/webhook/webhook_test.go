@@ -0,0 +1,85 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+func TestNotify(t *testing.T) {
+	testcases := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{
+			name:       "delivered",
+			statusCode: http.StatusOK,
+		},
+		{
+			name:       "server error not retried inline",
+			statusCode: http.StatusInternalServerError,
+			wantErr:    true,
+		},
+		{
+			name:       "client error",
+			statusCode: http.StatusBadRequest,
+			wantErr:    true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				timestamp := r.Header.Get(TimestampHeader)
+				assert.NotEmpty(t, timestamp)
+				assert.Equal(t, "sha256="+Sign([]byte("secret"), timestamp, body), r.Header.Get(SignatureHeader))
+
+				var payload Payload
+				assert.NoError(t, json.Unmarshal(body, &payload))
+				assert.Equal(t, models.EventTypeRequestApproved, payload.Type)
+				assert.Equal(t, "Request has been approved", payload.Message)
+				assert.Equal(t, &models.EventResource{Kind: models.EventResourceRequest, ID: "12345"}, payload.Resource)
+				assert.Equal(t, "12345", payload.Metadata[models.EventMetadataRequestID])
+
+				w.WriteHeader(tc.statusCode)
+				attempts++
+			}))
+			defer server.Close()
+
+			w := &Webhook{
+				endpoint:   server.URL,
+				secret:     []byte("secret"),
+				httpClient: server.Client(),
+			}
+			err := w.Notify(models.Event{
+				Type:     models.EventTypeRequestApproved,
+				Log:      models.EventLog{Level: models.EventLogLevelINFO, Message: "Request has been approved"},
+				Resource: &models.EventResource{Kind: models.EventResourceRequest, ID: "12345"},
+				Metadata: map[string]string{models.EventMetadataRequestID: "12345"},
+			})
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, 1, attempts)
+		})
+	}
+}
+
+func TestNewBackends(t *testing.T) {
+	t.Setenv("WEBHOOK_URLS", "https://chat.example.com/hook, https://ops.example.com/hook,")
+	t.Setenv("WEBHOOK_SECRET", "secret")
+
+	var names []string
+	for _, backend := range NewBackends() {
+		names = append(names, backend.Name)
+	}
+	// every endpoint is tracked on its own so that only the failed ones are retried
+	assert.Equal(t, []string{"webhook:https://chat.example.com/hook", "webhook:https://ops.example.com/hook"}, names)
+}
@@ -14,6 +14,7 @@ type Keycloak interface {
 	AddUserToGroup(userID, groupID string) error
 	DeleteUserFromGroup(userID, groupID string) error
 	GetUserGroups(userID string) ([]*gocloak.Group, error)
+	GetGroupMembers(groupID string) ([]*gocloak.User, error)
 	DeleteUser(userID string) error
 	IsRole(name string) bool
 	GetUserID() string
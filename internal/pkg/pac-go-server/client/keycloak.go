@@ -3,10 +3,15 @@ package client
 import (
 	"context"
 	"errors"
+	"fmt"
+	"os"
 
 	"github.com/Nerzal/gocloak/v13"
 )
 
+// groupMembersPageSize is the number of the members fetched from keycloak at a time
+const groupMembersPageSize = 100
+
 var (
 	ErrorGroupNotFound = errors.New("group not found")
 	ErrorUserNotFound  = errors.New("user not found")
@@ -36,6 +41,19 @@ var NewKeyCloakClient = func(config KeyCloakConfig, ctx context.Context) Keycloa
 	}
 }
 
+// NewServiceAccountClient returns the client calling keycloak as the service account of the server, set by
+// KEYCLOAK_SERVICE_ACCOUNT and KEYCLOAK_SERVICE_ACCOUNT_PASSWORD. It is used for the admin APIs called on behalf of the
+// users who aren't allowed to call them, e.g. listing the members of a group.
+var NewServiceAccountClient = func(ctx context.Context) (Keycloak, error) {
+	hostname, realm := os.Getenv("KEYCLOAK_HOSTNAME"), os.Getenv("KEYCLOAK_REALM")
+	client := gocloak.NewClient(hostname)
+	token, err := client.LoginAdmin(ctx, os.Getenv("KEYCLOAK_SERVICE_ACCOUNT"), os.Getenv("KEYCLOAK_SERVICE_ACCOUNT_PASSWORD"), realm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to login as the service account: %w", err)
+	}
+	return NewKeyCloakClient(KeyCloakConfig{Hostname: hostname, AccessToken: token.AccessToken, Realm: realm}, ctx), nil
+}
+
 func (k *KeyCloakClient) GetClient() *gocloak.GoCloak {
 	return k.client
 }
@@ -70,6 +88,25 @@ func (k *KeyCloakClient) GetUserGroups(userID string) ([]*gocloak.Group, error)
 	return k.client.GetUserGroups(k.ctx, k.config.AccessToken, k.config.Realm, userID, gocloak.GetGroupsParams{})
 }
 
+// GetGroupMembers for listing all the members of a group from keycloak, the members are fetched page by page since
+// keycloak returns a page of 100 members by default
+func (k *KeyCloakClient) GetGroupMembers(groupID string) ([]*gocloak.User, error) {
+	var members []*gocloak.User
+	for first := 0; ; first += groupMembersPageSize {
+		page, err := k.client.GetGroupMembers(k.ctx, k.config.AccessToken, k.config.Realm, groupID, gocloak.GetGroupsParams{
+			First: gocloak.IntP(first),
+			Max:   gocloak.IntP(groupMembersPageSize),
+		})
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, page...)
+		if len(page) < groupMembersPageSize {
+			return members, nil
+		}
+	}
+}
+
 func (k *KeyCloakClient) DeleteUser(userID string) error {
 	return k.client.DeleteUser(k.ctx, k.config.AccessToken, k.config.Realm, userID)
 }
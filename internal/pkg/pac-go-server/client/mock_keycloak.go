@@ -90,6 +90,21 @@ func (mr *MockKeycloakMockRecorder) GetClient() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClient", reflect.TypeOf((*MockKeycloak)(nil).GetClient))
 }
 
+// GetGroupMembers mocks base method.
+func (m *MockKeycloak) GetGroupMembers(arg0 string) ([]*gocloak.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupMembers", arg0)
+	ret0, _ := ret[0].([]*gocloak.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupMembers indicates an expected call of GetGroupMembers.
+func (mr *MockKeycloakMockRecorder) GetGroupMembers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupMembers", reflect.TypeOf((*MockKeycloak)(nil).GetGroupMembers), arg0)
+}
+
 // GetGroups mocks base method.
 func (m *MockKeycloak) GetGroups() ([]*gocloak.Group, error) {
 	m.ctrl.T.Helper()
@@ -1,6 +1,8 @@
 package db
 
 import (
+	"time"
+
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
 )
 
@@ -13,10 +15,18 @@ type DB interface {
 	NewRequest(request *models.Request) (string, error)
 	GetRequestByGroupIDAndUserID(groupID, userID string) ([]models.Request, error)
 	GetRequestByID(string) (*models.Request, error)
-	DeleteRequest(string) error
-	UpdateRequestState(id string, state models.RequestStateType) error
-	UpdateRequestStateWithComment(id string, state models.RequestStateType, comment string) error
+	UpdateRequestState(id string, transition models.RequestTransition) error
+	UpdateRequestApproval(id string, approval *models.Approval, previousApprovals int) error
+	UpdateRequestDecision(id string, transition models.RequestTransition, decision *models.RuleDecision) error
+	RevertRequestDecision(id string, transition models.RequestTransition) error
 	GetRequestByServiceName(string) ([]models.Request, error)
+	GetRequests(filter models.RequestFilter) ([]models.Request, int64, error)
+	GetPendingRequestsBefore(requestType models.RequestType, createdBefore time.Time) ([]models.Request, error)
+	UpdateRequestReminded(id string, remindedAt time.Time) error
+
+	// Implementations for the comment threads of the requests.
+	NewRequestComment(*models.RequestComment) (string, error)
+	GetRequestComments(requestID string) ([]models.RequestComment, error)
 
 	GetKeyByID(id string) (*models.Key, error)
 	GetKeyByUserID(userid string) ([]models.Key, error)
@@ -29,12 +39,66 @@ type DB interface {
 	DeleteQuota(string) error
 	GetQuotaForGroupID(string) (*models.Quota, error)
 	GetGroupsQuota([]string) ([]models.Quota, error)
+	GetQuotas() ([]models.Quota, error)
+
+	// Implementations for the time-bound quota granted to a user.
+	NewUserQuota(*models.UserQuota) error
+	GetActiveUserQuotas(string) ([]models.UserQuota, error)
+	GetAllActiveUserQuotas() ([]models.UserQuota, error)
+
+	// Implementations for the alerts on quota utilization.
+	GetQuotaAlert(models.QuotaAlertSubject, string) (*models.QuotaAlert, error)
+	UpdateQuotaAlert(*models.QuotaAlert) error
+
+	// Implementations for quota reserved by the user services.
+	GetQuotaLedger(string) (*models.QuotaLedger, error)
+	ReserveQuota(string, models.QuotaReservation, models.Capacity) error
+	AddQuotaReservation(string, models.QuotaReservation) error
+	ReleaseQuota(string) error
+
+	// Implementations for quota reserved by the services of the members of the pooled groups.
+	GetGroupQuotaLedger(string) (*models.QuotaLedger, error)
+	ReserveGroupQuota(string, models.QuotaReservation, models.Capacity) error
+	AddGroupQuotaReservation(string, models.QuotaReservation) error
+	ReleaseGroupQuota(groupID, serviceName string) error
+
+	// Implementations for the usage ledger of the user services.
+	NewServiceUsage(*models.ServiceUsage) error
+	GetServiceUsage(userIDs []string, start, end time.Time) ([]models.ServiceUsage, error)
+	UpdateServiceUsageExpiry(serviceName string, expiry time.Time) error
+	EndServiceUsage(serviceName string, endedAt time.Time) (*models.ServiceUsage, error)
+	DeleteServiceUsage(serviceName string) error
+
+	// Implementations for the usage history of the user services.
+	NewUsageRecord(*models.UsageRecord) error
+	GetUsageRecords(userIDs []string, start, end time.Time) ([]models.UsageRecord, error)
+
+	// Implementations for the rules auto-deciding the requests.
+	NewRule(*models.Rule) (string, error)
+	GetRules() ([]models.Rule, error)
+	GetRuleByID(string) (*models.Rule, error)
+	UpdateRule(*models.Rule) error
+	DeleteRule(string) error
+
+	// Implementations for the owners administering the groups.
+	GetGroupOwnership(string) (*models.GroupOwnership, error)
+	GetOwnedGroups(string) ([]models.GroupOwnership, error)
+	UpdateGroupOwnership(*models.GroupOwnership) error
+	DeleteGroupOwnership(string) error
 
 	NewEvent(*models.Event) error
-	GetEventsByUserID(string, int64, int64) ([]models.Event, int64, error)
+	GetEvents(models.EventFilter) ([]models.Event, int64, error)
 	GetEventsByType(models.EventType, uint) ([]models.Event, int64, error)
-	WatchEvents(chan<- *models.Event) error
-	MarkEventAsNotified(string) error
+	GetEventByID(string) (*models.Event, error)
+	GetEventsByDeliveryState(models.EventDeliveryState, int64, int64) ([]models.Event, int64, error)
+	ClaimEvent(string, time.Time, time.Duration) (*models.Event, error)
+	UpdateEventDelivery(string, string, time.Time, models.EventDelivery) error
+	ReplayEventDelivery(string, time.Time, models.EventDelivery) error
+	GetDigestUserIDs() ([]string, error)
+	ClaimDigestEvents(string, string, time.Time, time.Duration) ([]models.Event, error)
+
+	GetNotificationPreferences(string) (*models.NotificationPreferences, error)
+	UpdateNotificationPreferences(*models.NotificationPreferences) error
 
 	AcceptTermsAndConditions(*models.TermsAndConditions) error
 	GetTermsAndConditionsByUserID(string) (*models.TermsAndConditions, error)
@@ -6,6 +6,7 @@ package db
 
 import (
 	reflect "reflect"
+	time "time"
 
 	models "github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
 	gomock "github.com/golang/mock/gomock"
@@ -48,6 +49,64 @@ func (mr *MockDBMockRecorder) AcceptTermsAndConditions(arg0 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptTermsAndConditions", reflect.TypeOf((*MockDB)(nil).AcceptTermsAndConditions), arg0)
 }
 
+// AddGroupQuotaReservation mocks base method.
+func (m *MockDB) AddGroupQuotaReservation(arg0 string, arg1 models.QuotaReservation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddGroupQuotaReservation", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddGroupQuotaReservation indicates an expected call of AddGroupQuotaReservation.
+func (mr *MockDBMockRecorder) AddGroupQuotaReservation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddGroupQuotaReservation", reflect.TypeOf((*MockDB)(nil).AddGroupQuotaReservation), arg0, arg1)
+}
+
+// AddQuotaReservation mocks base method.
+func (m *MockDB) AddQuotaReservation(arg0 string, arg1 models.QuotaReservation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddQuotaReservation", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddQuotaReservation indicates an expected call of AddQuotaReservation.
+func (mr *MockDBMockRecorder) AddQuotaReservation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddQuotaReservation", reflect.TypeOf((*MockDB)(nil).AddQuotaReservation), arg0, arg1)
+}
+
+// ClaimDigestEvents mocks base method.
+func (m *MockDB) ClaimDigestEvents(arg0, arg1 string, arg2 time.Time, arg3 time.Duration) ([]models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDigestEvents", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDigestEvents indicates an expected call of ClaimDigestEvents.
+func (mr *MockDBMockRecorder) ClaimDigestEvents(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDigestEvents", reflect.TypeOf((*MockDB)(nil).ClaimDigestEvents), arg0, arg1, arg2, arg3)
+}
+
+// ClaimEvent mocks base method.
+func (m *MockDB) ClaimEvent(arg0 string, arg1 time.Time, arg2 time.Duration) (*models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimEvent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimEvent indicates an expected call of ClaimEvent.
+func (mr *MockDBMockRecorder) ClaimEvent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimEvent", reflect.TypeOf((*MockDB)(nil).ClaimEvent), arg0, arg1, arg2)
+}
+
 // Connect mocks base method.
 func (m *MockDB) Connect() error {
 	m.ctrl.T.Helper()
@@ -76,6 +135,20 @@ func (mr *MockDBMockRecorder) CreateKey(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateKey", reflect.TypeOf((*MockDB)(nil).CreateKey), arg0)
 }
 
+// DeleteGroupOwnership mocks base method.
+func (m *MockDB) DeleteGroupOwnership(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGroupOwnership", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGroupOwnership indicates an expected call of DeleteGroupOwnership.
+func (mr *MockDBMockRecorder) DeleteGroupOwnership(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGroupOwnership", reflect.TypeOf((*MockDB)(nil).DeleteGroupOwnership), arg0)
+}
+
 // DeleteKey mocks base method.
 func (m *MockDB) DeleteKey(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -104,18 +177,32 @@ func (mr *MockDBMockRecorder) DeleteQuota(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQuota", reflect.TypeOf((*MockDB)(nil).DeleteQuota), arg0)
 }
 
-// DeleteRequest mocks base method.
-func (m *MockDB) DeleteRequest(arg0 string) error {
+// DeleteRule mocks base method.
+func (m *MockDB) DeleteRule(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRule", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRule indicates an expected call of DeleteRule.
+func (mr *MockDBMockRecorder) DeleteRule(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRule", reflect.TypeOf((*MockDB)(nil).DeleteRule), arg0)
+}
+
+// DeleteServiceUsage mocks base method.
+func (m *MockDB) DeleteServiceUsage(arg0 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteRequest", arg0)
+	ret := m.ctrl.Call(m, "DeleteServiceUsage", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteRequest indicates an expected call of DeleteRequest.
-func (mr *MockDBMockRecorder) DeleteRequest(arg0 interface{}) *gomock.Call {
+// DeleteServiceUsage indicates an expected call of DeleteServiceUsage.
+func (mr *MockDBMockRecorder) DeleteServiceUsage(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRequest", reflect.TypeOf((*MockDB)(nil).DeleteRequest), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteServiceUsage", reflect.TypeOf((*MockDB)(nil).DeleteServiceUsage), arg0)
 }
 
 // DeleteTermsAndConditionsByUserID mocks base method.
@@ -146,6 +233,113 @@ func (mr *MockDBMockRecorder) Disconnect() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disconnect", reflect.TypeOf((*MockDB)(nil).Disconnect))
 }
 
+// EndServiceUsage mocks base method.
+func (m *MockDB) EndServiceUsage(arg0 string, arg1 time.Time) (*models.ServiceUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EndServiceUsage", arg0, arg1)
+	ret0, _ := ret[0].(*models.ServiceUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EndServiceUsage indicates an expected call of EndServiceUsage.
+func (mr *MockDBMockRecorder) EndServiceUsage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EndServiceUsage", reflect.TypeOf((*MockDB)(nil).EndServiceUsage), arg0, arg1)
+}
+
+// GetActiveUserQuotas mocks base method.
+func (m *MockDB) GetActiveUserQuotas(arg0 string) ([]models.UserQuota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveUserQuotas", arg0)
+	ret0, _ := ret[0].([]models.UserQuota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveUserQuotas indicates an expected call of GetActiveUserQuotas.
+func (mr *MockDBMockRecorder) GetActiveUserQuotas(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveUserQuotas", reflect.TypeOf((*MockDB)(nil).GetActiveUserQuotas), arg0)
+}
+
+// GetAllActiveUserQuotas mocks base method.
+func (m *MockDB) GetAllActiveUserQuotas() ([]models.UserQuota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllActiveUserQuotas")
+	ret0, _ := ret[0].([]models.UserQuota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllActiveUserQuotas indicates an expected call of GetAllActiveUserQuotas.
+func (mr *MockDBMockRecorder) GetAllActiveUserQuotas() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllActiveUserQuotas", reflect.TypeOf((*MockDB)(nil).GetAllActiveUserQuotas))
+}
+
+// GetDigestUserIDs mocks base method.
+func (m *MockDB) GetDigestUserIDs() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDigestUserIDs")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDigestUserIDs indicates an expected call of GetDigestUserIDs.
+func (mr *MockDBMockRecorder) GetDigestUserIDs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDigestUserIDs", reflect.TypeOf((*MockDB)(nil).GetDigestUserIDs))
+}
+
+// GetEventByID mocks base method.
+func (m *MockDB) GetEventByID(arg0 string) (*models.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEventByID", arg0)
+	ret0, _ := ret[0].(*models.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEventByID indicates an expected call of GetEventByID.
+func (mr *MockDBMockRecorder) GetEventByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventByID", reflect.TypeOf((*MockDB)(nil).GetEventByID), arg0)
+}
+
+// GetEvents mocks base method.
+func (m *MockDB) GetEvents(arg0 models.EventFilter) ([]models.Event, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEvents", arg0)
+	ret0, _ := ret[0].([]models.Event)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetEvents indicates an expected call of GetEvents.
+func (mr *MockDBMockRecorder) GetEvents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEvents", reflect.TypeOf((*MockDB)(nil).GetEvents), arg0)
+}
+
+// GetEventsByDeliveryState mocks base method.
+func (m *MockDB) GetEventsByDeliveryState(arg0 models.EventDeliveryState, arg1, arg2 int64) ([]models.Event, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEventsByDeliveryState", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Event)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetEventsByDeliveryState indicates an expected call of GetEventsByDeliveryState.
+func (mr *MockDBMockRecorder) GetEventsByDeliveryState(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsByDeliveryState", reflect.TypeOf((*MockDB)(nil).GetEventsByDeliveryState), arg0, arg1, arg2)
+}
+
 // GetEventsByType mocks base method.
 func (m *MockDB) GetEventsByType(arg0 models.EventType, arg1 uint) ([]models.Event, int64, error) {
 	m.ctrl.T.Helper()
@@ -162,20 +356,34 @@ func (mr *MockDBMockRecorder) GetEventsByType(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsByType", reflect.TypeOf((*MockDB)(nil).GetEventsByType), arg0, arg1)
 }
 
-// GetEventsByUserID mocks base method.
-func (m *MockDB) GetEventsByUserID(arg0 string, arg1, arg2 int64) ([]models.Event, int64, error) {
+// GetGroupOwnership mocks base method.
+func (m *MockDB) GetGroupOwnership(arg0 string) (*models.GroupOwnership, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEventsByUserID", arg0, arg1, arg2)
-	ret0, _ := ret[0].([]models.Event)
-	ret1, _ := ret[1].(int64)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "GetGroupOwnership", arg0)
+	ret0, _ := ret[0].(*models.GroupOwnership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// GetEventsByUserID indicates an expected call of GetEventsByUserID.
-func (mr *MockDBMockRecorder) GetEventsByUserID(arg0, arg1, arg2 interface{}) *gomock.Call {
+// GetGroupOwnership indicates an expected call of GetGroupOwnership.
+func (mr *MockDBMockRecorder) GetGroupOwnership(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEventsByUserID", reflect.TypeOf((*MockDB)(nil).GetEventsByUserID), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupOwnership", reflect.TypeOf((*MockDB)(nil).GetGroupOwnership), arg0)
+}
+
+// GetGroupQuotaLedger mocks base method.
+func (m *MockDB) GetGroupQuotaLedger(arg0 string) (*models.QuotaLedger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGroupQuotaLedger", arg0)
+	ret0, _ := ret[0].(*models.QuotaLedger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGroupQuotaLedger indicates an expected call of GetGroupQuotaLedger.
+func (mr *MockDBMockRecorder) GetGroupQuotaLedger(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGroupQuotaLedger", reflect.TypeOf((*MockDB)(nil).GetGroupQuotaLedger), arg0)
 }
 
 // GetGroupsQuota mocks base method.
@@ -223,6 +431,66 @@ func (mr *MockDBMockRecorder) GetKeyByUserID(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeyByUserID", reflect.TypeOf((*MockDB)(nil).GetKeyByUserID), arg0)
 }
 
+// GetNotificationPreferences mocks base method.
+func (m *MockDB) GetNotificationPreferences(arg0 string) (*models.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationPreferences", arg0)
+	ret0, _ := ret[0].(*models.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationPreferences indicates an expected call of GetNotificationPreferences.
+func (mr *MockDBMockRecorder) GetNotificationPreferences(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationPreferences", reflect.TypeOf((*MockDB)(nil).GetNotificationPreferences), arg0)
+}
+
+// GetOwnedGroups mocks base method.
+func (m *MockDB) GetOwnedGroups(arg0 string) ([]models.GroupOwnership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOwnedGroups", arg0)
+	ret0, _ := ret[0].([]models.GroupOwnership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOwnedGroups indicates an expected call of GetOwnedGroups.
+func (mr *MockDBMockRecorder) GetOwnedGroups(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOwnedGroups", reflect.TypeOf((*MockDB)(nil).GetOwnedGroups), arg0)
+}
+
+// GetPendingRequestsBefore mocks base method.
+func (m *MockDB) GetPendingRequestsBefore(arg0 models.RequestType, arg1 time.Time) ([]models.Request, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingRequestsBefore", arg0, arg1)
+	ret0, _ := ret[0].([]models.Request)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingRequestsBefore indicates an expected call of GetPendingRequestsBefore.
+func (mr *MockDBMockRecorder) GetPendingRequestsBefore(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingRequestsBefore", reflect.TypeOf((*MockDB)(nil).GetPendingRequestsBefore), arg0, arg1)
+}
+
+// GetQuotaAlert mocks base method.
+func (m *MockDB) GetQuotaAlert(arg0 models.QuotaAlertSubject, arg1 string) (*models.QuotaAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuotaAlert", arg0, arg1)
+	ret0, _ := ret[0].(*models.QuotaAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuotaAlert indicates an expected call of GetQuotaAlert.
+func (mr *MockDBMockRecorder) GetQuotaAlert(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuotaAlert", reflect.TypeOf((*MockDB)(nil).GetQuotaAlert), arg0, arg1)
+}
+
 // GetQuotaForGroupID mocks base method.
 func (m *MockDB) GetQuotaForGroupID(arg0 string) (*models.Quota, error) {
 	m.ctrl.T.Helper()
@@ -238,6 +506,36 @@ func (mr *MockDBMockRecorder) GetQuotaForGroupID(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuotaForGroupID", reflect.TypeOf((*MockDB)(nil).GetQuotaForGroupID), arg0)
 }
 
+// GetQuotaLedger mocks base method.
+func (m *MockDB) GetQuotaLedger(arg0 string) (*models.QuotaLedger, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuotaLedger", arg0)
+	ret0, _ := ret[0].(*models.QuotaLedger)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuotaLedger indicates an expected call of GetQuotaLedger.
+func (mr *MockDBMockRecorder) GetQuotaLedger(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuotaLedger", reflect.TypeOf((*MockDB)(nil).GetQuotaLedger), arg0)
+}
+
+// GetQuotas mocks base method.
+func (m *MockDB) GetQuotas() ([]models.Quota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuotas")
+	ret0, _ := ret[0].([]models.Quota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuotas indicates an expected call of GetQuotas.
+func (mr *MockDBMockRecorder) GetQuotas() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuotas", reflect.TypeOf((*MockDB)(nil).GetQuotas))
+}
+
 // GetRequestByGroupIDAndUserID mocks base method.
 func (m *MockDB) GetRequestByGroupIDAndUserID(arg0, arg1 string) ([]models.Request, error) {
 	m.ctrl.T.Helper()
@@ -283,6 +581,37 @@ func (mr *MockDBMockRecorder) GetRequestByServiceName(arg0 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestByServiceName", reflect.TypeOf((*MockDB)(nil).GetRequestByServiceName), arg0)
 }
 
+// GetRequestComments mocks base method.
+func (m *MockDB) GetRequestComments(arg0 string) ([]models.RequestComment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRequestComments", arg0)
+	ret0, _ := ret[0].([]models.RequestComment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRequestComments indicates an expected call of GetRequestComments.
+func (mr *MockDBMockRecorder) GetRequestComments(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestComments", reflect.TypeOf((*MockDB)(nil).GetRequestComments), arg0)
+}
+
+// GetRequests mocks base method.
+func (m *MockDB) GetRequests(arg0 models.RequestFilter) ([]models.Request, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRequests", arg0)
+	ret0, _ := ret[0].([]models.Request)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRequests indicates an expected call of GetRequests.
+func (mr *MockDBMockRecorder) GetRequests(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequests", reflect.TypeOf((*MockDB)(nil).GetRequests), arg0)
+}
+
 // GetRequestsByUserID mocks base method.
 func (m *MockDB) GetRequestsByUserID(arg0, arg1 string) ([]models.Request, error) {
 	m.ctrl.T.Helper()
@@ -298,6 +627,51 @@ func (mr *MockDBMockRecorder) GetRequestsByUserID(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestsByUserID", reflect.TypeOf((*MockDB)(nil).GetRequestsByUserID), arg0, arg1)
 }
 
+// GetRuleByID mocks base method.
+func (m *MockDB) GetRuleByID(arg0 string) (*models.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRuleByID", arg0)
+	ret0, _ := ret[0].(*models.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRuleByID indicates an expected call of GetRuleByID.
+func (mr *MockDBMockRecorder) GetRuleByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRuleByID", reflect.TypeOf((*MockDB)(nil).GetRuleByID), arg0)
+}
+
+// GetRules mocks base method.
+func (m *MockDB) GetRules() ([]models.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRules")
+	ret0, _ := ret[0].([]models.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRules indicates an expected call of GetRules.
+func (mr *MockDBMockRecorder) GetRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRules", reflect.TypeOf((*MockDB)(nil).GetRules))
+}
+
+// GetServiceUsage mocks base method.
+func (m *MockDB) GetServiceUsage(arg0 []string, arg1, arg2 time.Time) ([]models.ServiceUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceUsage", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.ServiceUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceUsage indicates an expected call of GetServiceUsage.
+func (mr *MockDBMockRecorder) GetServiceUsage(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceUsage", reflect.TypeOf((*MockDB)(nil).GetServiceUsage), arg0, arg1, arg2)
+}
+
 // GetTermsAndConditionsByUserID mocks base method.
 func (m *MockDB) GetTermsAndConditionsByUserID(arg0 string) (*models.TermsAndConditions, error) {
 	m.ctrl.T.Helper()
@@ -313,18 +687,19 @@ func (mr *MockDBMockRecorder) GetTermsAndConditionsByUserID(arg0 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTermsAndConditionsByUserID", reflect.TypeOf((*MockDB)(nil).GetTermsAndConditionsByUserID), arg0)
 }
 
-// MarkEventAsNotified mocks base method.
-func (m *MockDB) MarkEventAsNotified(arg0 string) error {
+// GetUsageRecords mocks base method.
+func (m *MockDB) GetUsageRecords(arg0 []string, arg1, arg2 time.Time) ([]models.UsageRecord, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "MarkEventAsNotified", arg0)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetUsageRecords", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.UsageRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// MarkEventAsNotified indicates an expected call of MarkEventAsNotified.
-func (mr *MockDBMockRecorder) MarkEventAsNotified(arg0 interface{}) *gomock.Call {
+// GetUsageRecords indicates an expected call of GetUsageRecords.
+func (mr *MockDBMockRecorder) GetUsageRecords(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEventAsNotified", reflect.TypeOf((*MockDB)(nil).MarkEventAsNotified), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsageRecords", reflect.TypeOf((*MockDB)(nil).GetUsageRecords), arg0, arg1, arg2)
 }
 
 // NewEvent mocks base method.
@@ -370,6 +745,204 @@ func (mr *MockDBMockRecorder) NewRequest(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewRequest", reflect.TypeOf((*MockDB)(nil).NewRequest), arg0)
 }
 
+// NewRequestComment mocks base method.
+func (m *MockDB) NewRequestComment(arg0 *models.RequestComment) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewRequestComment", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewRequestComment indicates an expected call of NewRequestComment.
+func (mr *MockDBMockRecorder) NewRequestComment(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewRequestComment", reflect.TypeOf((*MockDB)(nil).NewRequestComment), arg0)
+}
+
+// NewRule mocks base method.
+func (m *MockDB) NewRule(arg0 *models.Rule) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewRule", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewRule indicates an expected call of NewRule.
+func (mr *MockDBMockRecorder) NewRule(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewRule", reflect.TypeOf((*MockDB)(nil).NewRule), arg0)
+}
+
+// NewServiceUsage mocks base method.
+func (m *MockDB) NewServiceUsage(arg0 *models.ServiceUsage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewServiceUsage", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NewServiceUsage indicates an expected call of NewServiceUsage.
+func (mr *MockDBMockRecorder) NewServiceUsage(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewServiceUsage", reflect.TypeOf((*MockDB)(nil).NewServiceUsage), arg0)
+}
+
+// NewUsageRecord mocks base method.
+func (m *MockDB) NewUsageRecord(arg0 *models.UsageRecord) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewUsageRecord", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NewUsageRecord indicates an expected call of NewUsageRecord.
+func (mr *MockDBMockRecorder) NewUsageRecord(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewUsageRecord", reflect.TypeOf((*MockDB)(nil).NewUsageRecord), arg0)
+}
+
+// NewUserQuota mocks base method.
+func (m *MockDB) NewUserQuota(arg0 *models.UserQuota) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewUserQuota", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// NewUserQuota indicates an expected call of NewUserQuota.
+func (mr *MockDBMockRecorder) NewUserQuota(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewUserQuota", reflect.TypeOf((*MockDB)(nil).NewUserQuota), arg0)
+}
+
+// ReleaseGroupQuota mocks base method.
+func (m *MockDB) ReleaseGroupQuota(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseGroupQuota", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseGroupQuota indicates an expected call of ReleaseGroupQuota.
+func (mr *MockDBMockRecorder) ReleaseGroupQuota(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseGroupQuota", reflect.TypeOf((*MockDB)(nil).ReleaseGroupQuota), arg0, arg1)
+}
+
+// ReleaseQuota mocks base method.
+func (m *MockDB) ReleaseQuota(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseQuota", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseQuota indicates an expected call of ReleaseQuota.
+func (mr *MockDBMockRecorder) ReleaseQuota(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseQuota", reflect.TypeOf((*MockDB)(nil).ReleaseQuota), arg0)
+}
+
+// ReplayEventDelivery mocks base method.
+func (m *MockDB) ReplayEventDelivery(arg0 string, arg1 time.Time, arg2 models.EventDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayEventDelivery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplayEventDelivery indicates an expected call of ReplayEventDelivery.
+func (mr *MockDBMockRecorder) ReplayEventDelivery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayEventDelivery", reflect.TypeOf((*MockDB)(nil).ReplayEventDelivery), arg0, arg1, arg2)
+}
+
+// ReserveGroupQuota mocks base method.
+func (m *MockDB) ReserveGroupQuota(arg0 string, arg1 models.QuotaReservation, arg2 models.Capacity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveGroupQuota", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReserveGroupQuota indicates an expected call of ReserveGroupQuota.
+func (mr *MockDBMockRecorder) ReserveGroupQuota(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveGroupQuota", reflect.TypeOf((*MockDB)(nil).ReserveGroupQuota), arg0, arg1, arg2)
+}
+
+// ReserveQuota mocks base method.
+func (m *MockDB) ReserveQuota(arg0 string, arg1 models.QuotaReservation, arg2 models.Capacity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReserveQuota", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReserveQuota indicates an expected call of ReserveQuota.
+func (mr *MockDBMockRecorder) ReserveQuota(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReserveQuota", reflect.TypeOf((*MockDB)(nil).ReserveQuota), arg0, arg1, arg2)
+}
+
+// RevertRequestDecision mocks base method.
+func (m *MockDB) RevertRequestDecision(arg0 string, arg1 models.RequestTransition) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertRequestDecision", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevertRequestDecision indicates an expected call of RevertRequestDecision.
+func (mr *MockDBMockRecorder) RevertRequestDecision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertRequestDecision", reflect.TypeOf((*MockDB)(nil).RevertRequestDecision), arg0, arg1)
+}
+
+// UpdateEventDelivery mocks base method.
+func (m *MockDB) UpdateEventDelivery(arg0, arg1 string, arg2 time.Time, arg3 models.EventDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEventDelivery", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEventDelivery indicates an expected call of UpdateEventDelivery.
+func (mr *MockDBMockRecorder) UpdateEventDelivery(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEventDelivery", reflect.TypeOf((*MockDB)(nil).UpdateEventDelivery), arg0, arg1, arg2, arg3)
+}
+
+// UpdateGroupOwnership mocks base method.
+func (m *MockDB) UpdateGroupOwnership(arg0 *models.GroupOwnership) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateGroupOwnership", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateGroupOwnership indicates an expected call of UpdateGroupOwnership.
+func (mr *MockDBMockRecorder) UpdateGroupOwnership(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateGroupOwnership", reflect.TypeOf((*MockDB)(nil).UpdateGroupOwnership), arg0)
+}
+
+// UpdateNotificationPreferences mocks base method.
+func (m *MockDB) UpdateNotificationPreferences(arg0 *models.NotificationPreferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateNotificationPreferences", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateNotificationPreferences indicates an expected call of UpdateNotificationPreferences.
+func (mr *MockDBMockRecorder) UpdateNotificationPreferences(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateNotificationPreferences", reflect.TypeOf((*MockDB)(nil).UpdateNotificationPreferences), arg0)
+}
+
 // UpdateQuota mocks base method.
 func (m *MockDB) UpdateQuota(arg0 *models.Quota) error {
 	m.ctrl.T.Helper()
@@ -384,8 +957,64 @@ func (mr *MockDBMockRecorder) UpdateQuota(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuota", reflect.TypeOf((*MockDB)(nil).UpdateQuota), arg0)
 }
 
+// UpdateQuotaAlert mocks base method.
+func (m *MockDB) UpdateQuotaAlert(arg0 *models.QuotaAlert) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateQuotaAlert", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateQuotaAlert indicates an expected call of UpdateQuotaAlert.
+func (mr *MockDBMockRecorder) UpdateQuotaAlert(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuotaAlert", reflect.TypeOf((*MockDB)(nil).UpdateQuotaAlert), arg0)
+}
+
+// UpdateRequestApproval mocks base method.
+func (m *MockDB) UpdateRequestApproval(arg0 string, arg1 *models.Approval, arg2 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRequestApproval", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRequestApproval indicates an expected call of UpdateRequestApproval.
+func (mr *MockDBMockRecorder) UpdateRequestApproval(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRequestApproval", reflect.TypeOf((*MockDB)(nil).UpdateRequestApproval), arg0, arg1, arg2)
+}
+
+// UpdateRequestDecision mocks base method.
+func (m *MockDB) UpdateRequestDecision(arg0 string, arg1 models.RequestTransition, arg2 *models.RuleDecision) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRequestDecision", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRequestDecision indicates an expected call of UpdateRequestDecision.
+func (mr *MockDBMockRecorder) UpdateRequestDecision(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRequestDecision", reflect.TypeOf((*MockDB)(nil).UpdateRequestDecision), arg0, arg1, arg2)
+}
+
+// UpdateRequestReminded mocks base method.
+func (m *MockDB) UpdateRequestReminded(arg0 string, arg1 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRequestReminded", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRequestReminded indicates an expected call of UpdateRequestReminded.
+func (mr *MockDBMockRecorder) UpdateRequestReminded(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRequestReminded", reflect.TypeOf((*MockDB)(nil).UpdateRequestReminded), arg0, arg1)
+}
+
 // UpdateRequestState mocks base method.
-func (m *MockDB) UpdateRequestState(arg0 string, arg1 models.RequestStateType) error {
+func (m *MockDB) UpdateRequestState(arg0 string, arg1 models.RequestTransition) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "UpdateRequestState", arg0, arg1)
 	ret0, _ := ret[0].(error)
@@ -398,30 +1027,30 @@ func (mr *MockDBMockRecorder) UpdateRequestState(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRequestState", reflect.TypeOf((*MockDB)(nil).UpdateRequestState), arg0, arg1)
 }
 
-// UpdateRequestStateWithComment mocks base method.
-func (m *MockDB) UpdateRequestStateWithComment(arg0 string, arg1 models.RequestStateType, arg2 string) error {
+// UpdateRule mocks base method.
+func (m *MockDB) UpdateRule(arg0 *models.Rule) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateRequestStateWithComment", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "UpdateRule", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateRequestStateWithComment indicates an expected call of UpdateRequestStateWithComment.
-func (mr *MockDBMockRecorder) UpdateRequestStateWithComment(arg0, arg1, arg2 interface{}) *gomock.Call {
+// UpdateRule indicates an expected call of UpdateRule.
+func (mr *MockDBMockRecorder) UpdateRule(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRequestStateWithComment", reflect.TypeOf((*MockDB)(nil).UpdateRequestStateWithComment), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRule", reflect.TypeOf((*MockDB)(nil).UpdateRule), arg0)
 }
 
-// WatchEvents mocks base method.
-func (m *MockDB) WatchEvents(arg0 chan<- *models.Event) error {
+// UpdateServiceUsageExpiry mocks base method.
+func (m *MockDB) UpdateServiceUsageExpiry(arg0 string, arg1 time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WatchEvents", arg0)
+	ret := m.ctrl.Call(m, "UpdateServiceUsageExpiry", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// WatchEvents indicates an expected call of WatchEvents.
-func (mr *MockDBMockRecorder) WatchEvents(arg0 interface{}) *gomock.Call {
+// UpdateServiceUsageExpiry indicates an expected call of UpdateServiceUsageExpiry.
+func (mr *MockDBMockRecorder) UpdateServiceUsageExpiry(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchEvents", reflect.TypeOf((*MockDB)(nil).WatchEvents), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateServiceUsageExpiry", reflect.TypeOf((*MockDB)(nil).UpdateServiceUsageExpiry), arg0, arg1)
 }
@@ -0,0 +1,42 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+func (db *MongoDB) NewRequestComment(comment *models.RequestComment) (string, error) {
+	collection := db.Database.Collection("request_comments")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	result, err := collection.InsertOne(ctx, comment)
+	if err != nil {
+		return "", fmt.Errorf("error inserting request comment: %w", err)
+	}
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// GetRequestComments returns the comments on the request in the order they were made
+func (db *MongoDB) GetRequestComments(requestID string) ([]models.RequestComment, error) {
+	comments := []models.RequestComment{}
+	collection := db.Database.Collection("request_comments")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := collection.Find(ctx, bson.M{"request_id": requestID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting request comments: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &comments); err != nil {
+		return nil, fmt.Errorf("error fetching request comments: %w", err)
+	}
+	return comments, nil
+}
@@ -7,10 +7,12 @@ import (
 	"os"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/db"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
 )
 
 var (
@@ -57,9 +59,107 @@ func (db *MongoDB) Connect() error {
 	log.Println("Connected to MongoDB")
 	db.Database = client.Database(PacDB)
 
+	if err := db.createIndexes(); err != nil {
+		return fmt.Errorf("error creating indexes: %w", err)
+	}
+
 	return nil
 }
 
+// createIndexes creates the indexes required by the collections, creating an existing index is a no-op
+func (db *MongoDB) createIndexes() error {
+	indexes := map[string][]mongo.IndexModel{
+		"quota_ledger": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "reservations.service_name", Value: 1}}},
+		},
+		"group_quota_ledger": {
+			{Keys: bson.D{{Key: "group_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "reservations.service_name", Value: 1}}},
+		},
+		// expired user quota are removed by the TTL index
+		"user_quota": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"quota_alerts": {
+			{Keys: bson.D{{Key: "subject_type", Value: 1}, {Key: "subject_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		// requests are listed by the requester, the group or the service, the pending requests are also looked up by
+		// age for expiry
+		"requests": {
+			{Keys: bson.D{{Key: "state", Value: 1}, {Key: "type", Value: 1}, {Key: "created_at", Value: 1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+			{Keys: bson.D{{Key: "group.group_id", Value: 1}, {Key: "created_at", Value: 1}}},
+			{Keys: bson.D{{Key: "service.name", Value: 1}}},
+			{Keys: bson.D{{Key: "created_at", Value: 1}}},
+		},
+		"request_comments": {
+			{Keys: bson.D{{Key: "request_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+		"group_owners": {
+			{Keys: bson.D{{Key: "group_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "owners", Value: 1}}},
+		},
+		// events are claimed for delivery by the state oldest first, the failed deliveries are listed by the state for
+		// replay and the expired events are removed by the TTL index
+		"events": {
+			{Keys: bson.D{{Key: "delivery.state", Value: 1}, {Key: "created_at", Value: 1}}},
+			{Keys: bson.D{{Key: "delivery.state", Value: 1}, {Key: "delivery.last_attempt_at", Value: 1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}}},
+			{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: 1}}},
+			{Keys: bson.D{{Key: "resource.kind", Value: 1}, {Key: "resource.id", Value: 1}}},
+			{Keys: bson.D{{Key: "resource.kind", Value: 1}, {Key: "resource.name", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"notification_preferences": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"usage": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "started_at", Value: 1}}},
+			{Keys: bson.D{{Key: "service_name", Value: 1}}},
+		},
+		"usage_history": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: 1}}},
+			{Keys: bson.D{{Key: "timestamp", Value: 1}}},
+			// a service expires only once, guards against recording the expiry on every check
+			{Keys: bson.D{{Key: "service_name", Value: 1}, {Key: "transition", Value: 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "transition", Value: models.UsageTransitionExpired}})},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	for collection, idx := range indexes {
+		capped, err := db.isCapped(ctx, collection)
+		if err != nil {
+			return err
+		}
+		// the TTL indexes aren't supported on the capped collections and the size of the capped documents can't change on
+		// update, e.g. the events collection created by the earlier versions has to be converted first
+		if capped {
+			return fmt.Errorf("collection %s is capped, convert it to a regular collection", collection)
+		}
+		if _, err := db.Database.Collection(collection).Indexes().CreateMany(ctx, idx); err != nil {
+			return fmt.Errorf("error creating indexes for %s: %w", collection, err)
+		}
+	}
+	return nil
+}
+
+// isCapped returns true if the collection exists and is capped
+func (db *MongoDB) isCapped(ctx context.Context, collection string) (bool, error) {
+	specs, err := db.Database.ListCollectionSpecifications(ctx, bson.D{{Key: "name", Value: collection}})
+	if err != nil {
+		return false, fmt.Errorf("error listing the collection %s: %w", collection, err)
+	}
+	for _, spec := range specs {
+		if capped, ok := spec.Options.Lookup("capped").BooleanOK(); ok && capped {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func (db *MongoDB) Disconnect() error {
 	return db.client.Disconnect(context.Background())
 }
@@ -0,0 +1,27 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCreateIndexesCappedCollection(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("capped collection not converted", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "pac.$cmd.listCollections", mtest.FirstBatch, bson.D{
+			{Key: "name", Value: "events"},
+			{Key: "type", Value: "collection"},
+			{Key: "options", Value: bson.D{{Key: "capped", Value: true}, {Key: "size", Value: 1024}}},
+		}))
+		db := &MongoDB{Database: mt.Client.Database("pac")}
+		assert.ErrorContains(mt, db.createIndexes(), "is capped")
+		// no index is created on the capped collection
+		for _, event := range mt.GetAllStartedEvents() {
+			assert.Equal(mt, "listCollections", event.CommandName)
+		}
+	})
+}
@@ -2,11 +2,12 @@ package mongodb
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -25,38 +26,72 @@ func (db *MongoDB) NewEvent(e *models.Event) error {
 	return nil
 }
 
-// GetEventsByUserID returns all events by user id
-func (db *MongoDB) GetEventsByUserID(id string, startIndex, perPage int64) ([]models.Event, int64, error) {
+// GetEvents returns the events matching the filter along with the total count of them
+func (db *MongoDB) GetEvents(filter models.EventFilter) ([]models.Event, int64, error) {
 	events := []models.Event{}
-	var totalCount int64
-
-	filter := bson.D{{}}
-	if id != "" {
-		filter = bson.D{{Key: "user_id", Value: id}}
+	query := bson.D{}
+	if filter.UserID != "" {
+		query = append(query, bson.E{Key: "user_id", Value: filter.UserID})
+	}
+	if filter.Type != "" {
+		query = append(query, bson.E{Key: "type", Value: filter.Type})
+	}
+	if filter.Level != "" {
+		query = append(query, bson.E{Key: "log.level", Value: filter.Level})
+	}
+	if filter.Originator != "" {
+		query = append(query, bson.E{Key: "originator", Value: filter.Originator})
+	}
+	if filter.ResourceKind != "" {
+		query = append(query, bson.E{Key: "resource.kind", Value: filter.ResourceKind})
+	}
+	if filter.ResourceID != "" {
+		query = append(query, bson.E{Key: "resource.id", Value: filter.ResourceID})
+	}
+	if filter.ResourceName != "" {
+		query = append(query, bson.E{Key: "resource.name", Value: filter.ResourceName})
+	}
+	createdAt := bson.D{}
+	if !filter.CreatedAfter.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$gte", Value: filter.CreatedAfter})
+	}
+	if !filter.CreatedBefore.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$lt", Value: filter.CreatedBefore})
+	}
+	if len(createdAt) > 0 {
+		query = append(query, bson.E{Key: "created_at", Value: createdAt})
 	}
 
-	findOptions := options.Find()
-	findOptions.SetSkip(startIndex)
-	findOptions.SetLimit(perPage)
+	order := 1
+	if filter.Descending {
+		order = -1
+	}
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: sortBy, Value: order}, {Key: "_id", Value: order}}).
+		SetSkip(filter.StartIndex)
+	if filter.PerPage > 0 {
+		findOptions.SetLimit(filter.PerPage)
+	}
 
 	collection := db.Database.Collection("events")
 	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
 	defer cancel()
-	cur, err := collection.Find(ctx, filter, findOptions)
+	cur, err := collection.Find(ctx, query, findOptions)
 	if err != nil {
-		return nil, totalCount, fmt.Errorf("error getting events: %w", err)
+		return nil, 0, fmt.Errorf("error getting events: %w", err)
 	}
 	defer cur.Close(ctx)
 
-	if err = cur.All(context.Background(), &events); err != nil {
-		return nil, totalCount, fmt.Errorf("error fetching events: %w", err)
+	if err = cur.All(ctx, &events); err != nil {
+		return nil, 0, fmt.Errorf("error fetching events: %w", err)
 	}
-	// Get the total number of events from the database
-	totalCount, err = collection.CountDocuments(context.Background(), bson.D{})
+	totalCount, err := collection.CountDocuments(ctx, query)
 	if err != nil {
-		return nil, totalCount, fmt.Errorf("error getting total count of events: %w", err)
+		return nil, 0, fmt.Errorf("error getting total count of events: %w", err)
 	}
-
 	return events, totalCount, nil
 }
 
@@ -90,68 +125,191 @@ func (db *MongoDB) GetEventsByType(eventType models.EventType, duration uint) ([
 	return events, totalCount, nil
 }
 
-// WatchEvents watches the events collection for changes
-func (db *MongoDB) WatchEvents(eventCh chan<- *models.Event) error {
+// GetEventByID returns the event by id, utils.ErrResourceNotFound is returned if the event doesn't exist
+func (db *MongoDB) GetEventByID(id string) (*models.Event, error) {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
 	collection := db.Database.Collection("events")
-	cursorOptions := options.Find().SetCursorType(options.TailableAwait).SetMaxAwaitTime(2 * time.Second)
-
-	for {
-		// Create a new cursor for each iteration to handle possible cursor timeouts
-		cursor, err := collection.Find(context.Background(), bson.D{}, cursorOptions)
-		if err != nil {
-			return fmt.Errorf("error creating cursor: %w", err)
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	var event models.Event
+	if err := collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&event); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, utils.ErrResourceNotFound
 		}
+		return nil, fmt.Errorf("error getting event: %w", err)
+	}
+	return &event, nil
+}
 
-		// Process the change events
-		for cursor.Next(context.Background()) {
-			var event models.Event
-			if err := cursor.Decode(&event); err != nil {
-				log.Println("Error decoding event:", err)
-				continue
-			}
+// GetEventsByDeliveryState returns the events in the delivery state, the events failed most recently first
+func (db *MongoDB) GetEventsByDeliveryState(state models.EventDeliveryState, startIndex, perPage int64) ([]models.Event, int64, error) {
+	events := []models.Event{}
+	filter := bson.D{{Key: "delivery.state", Value: state}}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "delivery.last_attempt_at", Value: -1}}).
+		SetSkip(startIndex).
+		SetLimit(perPage)
 
-			eventCh <- &event
-		}
+	collection := db.Database.Collection("events")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	cur, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting events: %w", err)
+	}
+	defer cur.Close(ctx)
+	if err = cur.All(ctx, &events); err != nil {
+		return nil, 0, fmt.Errorf("error fetching events: %w", err)
+	}
+	totalCount, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting total count of events: %w", err)
+	}
+	return events, totalCount, nil
+}
 
-		if err := cursor.Err(); err != nil {
-			return fmt.Errorf("error reading cursor: %w", err)
+// ClaimEvent claims the oldest event due for delivery, i.e. pending or due for retry, for the owner till the lease is
+// over so that the other notifiers don't deliver it meanwhile. nil is returned if no event is due.
+func (db *MongoDB) ClaimEvent(owner string, now time.Time, lease time.Duration) (*models.Event, error) {
+	// the filter is on the delivery state so that the events done with, i.e. delivered, skipped, batched for the digest
+	// or dead-lettered, aren't scanned
+	filter := bson.D{
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "delivery.state", Value: models.EventDeliveryPending}},
+			// the events created before the delivery is tracked have no state
+			bson.D{{Key: "delivery.state", Value: nil}, {Key: "notified", Value: false}},
+			bson.D{
+				{Key: "delivery.state", Value: models.EventDeliveryRetrying},
+				{Key: "delivery.next_retry_at", Value: bson.D{{Key: "$lte", Value: now}}},
+			},
+		}},
+		// matches the events never leased too
+		{Key: "delivery.leased_until", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gt", Value: now}}}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "delivery.lease_owner", Value: owner},
+		{Key: "delivery.leased_until", Value: now.Add(lease)},
+	}}}
+	findOptions := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	collection := db.Database.Collection("events")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	var event models.Event
+	if err := collection.FindOneAndUpdate(ctx, filter, update, findOptions).Decode(&event); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
 		}
+		return nil, fmt.Errorf("error claiming event: %w", err)
+	}
+	return &event, nil
+}
 
-		// Close the cursor
-		if err := cursor.Close(context.Background()); err != nil {
-			return fmt.Errorf("error closing cursor: %w", err)
+// GetDigestUserIDs returns the users having events to be mailed in the digest
+func (db *MongoDB) GetDigestUserIDs() ([]string, error) {
+	collection := db.Database.Collection("events")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	values, err := collection.Distinct(ctx, "user_id", bson.D{{Key: "delivery.state", Value: models.EventDeliveryDigest}})
+	if err != nil {
+		return nil, fmt.Errorf("error getting digest users: %w", err)
+	}
+	var userIDs []string
+	for _, value := range values {
+		if userID, ok := value.(string); ok {
+			userIDs = append(userIDs, userID)
 		}
+	}
+	return userIDs, nil
+}
+
+// ClaimDigestEvents claims the events of the user to be mailed in the digest for the owner till the lease is over, the
+// events leased by another notifier are left out. The claimed events are returned oldest first.
+func (db *MongoDB) ClaimDigestEvents(userID, owner string, now time.Time, lease time.Duration) ([]models.Event, error) {
+	leasedUntil := now.Add(lease)
+	filter := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "delivery.state", Value: models.EventDeliveryDigest},
+		{Key: "delivery.leased_until", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gt", Value: now}}}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "delivery.lease_owner", Value: owner},
+		{Key: "delivery.leased_until", Value: leasedUntil},
+	}}}
 
-		// Sleep for a while before checking for new changes
-		time.Sleep(5 * time.Second)
+	collection := db.Database.Collection("events")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	if _, err := collection.UpdateMany(ctx, filter, update); err != nil {
+		return nil, fmt.Errorf("error claiming digest events: %w", err)
+	}
+	claimed := bson.D{
+		{Key: "user_id", Value: userID},
+		{Key: "delivery.state", Value: models.EventDeliveryDigest},
+		{Key: "delivery.lease_owner", Value: owner},
+		{Key: "delivery.leased_until", Value: leasedUntil},
+	}
+	cur, err := collection.Find(ctx, claimed, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("error getting digest events: %w", err)
+	}
+	defer cur.Close(ctx)
+	events := []models.Event{}
+	if err = cur.All(ctx, &events); err != nil {
+		return nil, fmt.Errorf("error fetching digest events: %w", err)
 	}
+	return events, nil
 }
 
-// MarkEventAsNotified marks the event as notified
-func (db *MongoDB) MarkEventAsNotified(id string) error {
-	event := models.Event{}
-	if id == "" {
-		return fmt.Errorf("id cannot be empty")
+// UpdateEventDelivery records the delivery of the event claimed by the owner, the event is marked as notified once
+// delivered. utils.ErrResourceModified is returned if the lease of the owner is over, the event may be claimed by
+// another notifier then.
+func (db *MongoDB) UpdateEventDelivery(id, owner string, now time.Time, delivery models.EventDelivery) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
 	}
+	filter := bson.D{
+		{Key: "_id", Value: objectId},
+		{Key: "delivery.lease_owner", Value: owner},
+		{Key: "delivery.leased_until", Value: bson.D{{Key: "$gt", Value: now}}},
+	}
+	return db.updateEventDelivery(filter, delivery)
+}
+
+// ReplayEventDelivery records the replay of the failed delivery of the event. utils.ErrResourceModified is returned if
+// the delivery is not failed or the event is claimed by a notifier meanwhile.
+func (db *MongoDB) ReplayEventDelivery(id string, now time.Time, delivery models.EventDelivery) error {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return fmt.Errorf("invalid id: %w", err)
 	}
-	filter := bson.M{"_id": objectId}
+	filter := bson.D{
+		{Key: "_id", Value: objectId},
+		{Key: "delivery.state", Value: bson.D{{Key: "$in", Value: bson.A{models.EventDeliveryDeadLetter, models.EventDeliveryRetrying}}}},
+		{Key: "delivery.leased_until", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gt", Value: now}}}}},
+	}
+	return db.updateEventDelivery(filter, delivery)
+}
 
+func (db *MongoDB) updateEventDelivery(filter bson.D, delivery models.EventDelivery) error {
 	collection := db.Database.Collection("events")
 	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
 	defer cancel()
-	if err := collection.FindOne(ctx, filter).Decode(&event); err != nil && err != mongo.ErrNoDocuments {
-		return fmt.Errorf("error getting event: %w", err)
-	} else if err == mongo.ErrNoDocuments {
-		return fmt.Errorf("event not found with id: %s, err : %w", id, err)
-	}
-
-	if _, err := collection.UpdateOne(ctx, bson.M{"_id": objectId},
-		bson.D{{Key: "$set", Value: bson.D{{Key: "notified", Value: true}}}}); err != nil {
+	result, err := collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "delivery", Value: delivery},
+		{Key: "notified", Value: delivery.State == models.EventDeliveryDelivered},
+	}}})
+	if err != nil {
 		return fmt.Errorf("error while updating event: %w", err)
 	}
-
+	if result.MatchedCount == 0 {
+		return utils.ErrResourceModified
+	}
 	return nil
 }
@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+func (db *MongoDB) GetGroupOwnership(groupID string) (*models.GroupOwnership, error) {
+	var ownership models.GroupOwnership
+	collection := db.Database.Collection("group_owners")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	if err := collection.FindOne(ctx, bson.M{"group_id": groupID}).Decode(&ownership); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, utils.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("error getting group ownership: %w", err)
+	}
+	return &ownership, nil
+}
+
+// GetOwnedGroups returns the ownership of all the groups owned by the user
+func (db *MongoDB) GetOwnedGroups(userID string) ([]models.GroupOwnership, error) {
+	ownerships := []models.GroupOwnership{}
+	collection := db.Database.Collection("group_owners")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	cur, err := collection.Find(ctx, bson.M{"owners": userID})
+	if err != nil {
+		return nil, fmt.Errorf("error getting owned groups: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &ownerships); err != nil {
+		return nil, fmt.Errorf("error fetching owned groups: %w", err)
+	}
+	return ownerships, nil
+}
+
+func (db *MongoDB) UpdateGroupOwnership(ownership *models.GroupOwnership) error {
+	collection := db.Database.Collection("group_owners")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	if _, err := collection.ReplaceOne(ctx, bson.M{"group_id": ownership.GroupID}, ownership,
+		options.Replace().SetUpsert(true)); err != nil {
+		return fmt.Errorf("error updating group ownership: %w", err)
+	}
+	return nil
+}
+
+func (db *MongoDB) DeleteGroupOwnership(groupID string) error {
+	collection := db.Database.Collection("group_owners")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	result, err := collection.DeleteOne(ctx, bson.M{"group_id": groupID})
+	if err != nil {
+		return fmt.Errorf("error deleting group ownership: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return utils.ErrResourceNotFound
+	}
+	return nil
+}
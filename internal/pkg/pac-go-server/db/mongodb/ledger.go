@@ -0,0 +1,176 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+const (
+	// quotaLedgerCollection holds the ledgers of the users, keyed by user_id
+	quotaLedgerCollection = "quota_ledger"
+	// groupQuotaLedgerCollection holds the ledgers of the pooled groups, keyed by group_id
+	groupQuotaLedgerCollection = "group_quota_ledger"
+)
+
+// GetQuotaLedger returns the quota ledger of the user, an empty ledger is returned if user has not reserved anything yet
+func (db *MongoDB) GetQuotaLedger(userID string) (*models.QuotaLedger, error) {
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+	ledger := models.QuotaLedger{UserID: userID}
+	return &ledger, db.getQuotaLedger(quotaLedgerCollection, bson.D{{Key: "user_id", Value: userID}}, &ledger)
+}
+
+// GetGroupQuotaLedger returns the quota ledger of the pooled group, an empty ledger is returned if the members of the
+// group have not reserved anything yet
+func (db *MongoDB) GetGroupQuotaLedger(groupID string) (*models.QuotaLedger, error) {
+	if groupID == "" {
+		return nil, errors.New("group id is required")
+	}
+	ledger := models.QuotaLedger{GroupID: groupID}
+	return &ledger, db.getQuotaLedger(groupQuotaLedgerCollection, bson.D{{Key: "group_id", Value: groupID}}, &ledger)
+}
+
+func (db *MongoDB) getQuotaLedger(collectionName string, filter bson.D, ledger *models.QuotaLedger) error {
+	collection := db.Database.Collection(collectionName)
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	err := collection.FindOne(ctx, filter).Decode(ledger)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return fmt.Errorf("error getting quota ledger: %w", err)
+	}
+	return nil
+}
+
+// ReserveQuota atomically reserves the capacity for the service only if the total reserved capacity and the number of
+// services of the user stay within the limit, utils.ErrQuotaExceeded is returned otherwise.
+func (db *MongoDB) ReserveQuota(userID string, reservation models.QuotaReservation, limit models.Capacity) error {
+	return db.reserveQuota(quotaLedgerCollection, bson.E{Key: "user_id", Value: userID}, reservation, limit)
+}
+
+// ReserveGroupQuota atomically reserves the capacity for the service of a member of the pooled group only if the total
+// capacity reserved by the members stays within the limit, utils.ErrQuotaExceeded is returned otherwise.
+func (db *MongoDB) ReserveGroupQuota(groupID string, reservation models.QuotaReservation, limit models.Capacity) error {
+	return db.reserveQuota(groupQuotaLedgerCollection, bson.E{Key: "group_id", Value: groupID}, reservation, limit)
+}
+
+func (db *MongoDB) reserveQuota(collectionName string, owner bson.E, reservation models.QuotaReservation, limit models.Capacity) error {
+	if reservation.Capacity.CPU > limit.CPU || reservation.Capacity.Memory > limit.Memory {
+		return utils.ErrQuotaExceeded
+	}
+	filter := bson.D{
+		owner,
+		{Key: "cpu", Value: bson.D{{Key: "$lte", Value: limit.CPU - reservation.Capacity.CPU}}},
+		{Key: "memory", Value: bson.D{{Key: "$lte", Value: limit.Memory - reservation.Capacity.Memory}}},
+		{Key: "reservations.service_name", Value: bson.D{{Key: "$ne", Value: reservation.ServiceName}}},
+	}
+	if limit.Services > 0 {
+		// the ledger must not have more than limit-1 reservations to fit one more service
+		filter = append(filter, bson.E{Key: fmt.Sprintf("reservations.%d", limit.Services-1), Value: bson.D{{Key: "$exists", Value: false}}})
+	}
+	// when the filter does not match an existing ledger the upsert collides with the unique index on the owner, even
+	// once retried, which means that the reservation would exceed the limit.
+	err := db.addQuotaReservation(collectionName, filter, reservation)
+	if mongo.IsDuplicateKeyError(err) {
+		return utils.ErrQuotaExceeded
+	}
+	return err
+}
+
+// AddQuotaReservation records the capacity reserved by an already existing service of the user, irrespective of the limit
+func (db *MongoDB) AddQuotaReservation(userID string, reservation models.QuotaReservation) error {
+	return db.addExistingQuotaReservation(quotaLedgerCollection, bson.E{Key: "user_id", Value: userID}, reservation)
+}
+
+// AddGroupQuotaReservation records the capacity reserved by an already existing service of a member of the pooled
+// group, irrespective of the limit
+func (db *MongoDB) AddGroupQuotaReservation(groupID string, reservation models.QuotaReservation) error {
+	return db.addExistingQuotaReservation(groupQuotaLedgerCollection, bson.E{Key: "group_id", Value: groupID}, reservation)
+}
+
+func (db *MongoDB) addExistingQuotaReservation(collectionName string, owner bson.E, reservation models.QuotaReservation) error {
+	filter := bson.D{
+		owner,
+		{Key: "reservations.service_name", Value: bson.D{{Key: "$ne", Value: reservation.ServiceName}}},
+	}
+	err := db.addQuotaReservation(collectionName, filter, reservation)
+	// reservation for the service is already present
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (db *MongoDB) addQuotaReservation(collectionName string, filter bson.D, reservation models.QuotaReservation) error {
+	collection := db.Database.Collection(collectionName)
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	update := bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "cpu", Value: reservation.Capacity.CPU}, {Key: "memory", Value: reservation.Capacity.Memory}}},
+		{Key: "$push", Value: bson.D{{Key: "reservations", Value: reservation}}},
+	}
+	_, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// a parallel request may have created the ledger first, the retry matches the ledger unless the filter rules it out
+		_, err = collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	}
+	if err != nil {
+		return fmt.Errorf("error reserving quota: %w", err)
+	}
+	return nil
+}
+
+// ReleaseQuota releases the capacity reserved for the service in the ledger of the user and in the ledgers of the pooled
+// groups, releasing an already released service is a no-op
+func (db *MongoDB) ReleaseQuota(serviceName string) error {
+	if err := db.releaseQuota(quotaLedgerCollection, bson.D{}, serviceName); err != nil {
+		return err
+	}
+	return db.releaseQuota(groupQuotaLedgerCollection, bson.D{}, serviceName)
+}
+
+// ReleaseGroupQuota releases the capacity reserved for the service in the ledger of the pooled group only, e.g. once
+// the owner of the service has left the group
+func (db *MongoDB) ReleaseGroupQuota(groupID, serviceName string) error {
+	return db.releaseQuota(groupQuotaLedgerCollection, bson.D{{Key: "group_id", Value: groupID}}, serviceName)
+}
+
+func (db *MongoDB) releaseQuota(collectionName string, filter bson.D, serviceName string) error {
+	var ledgers []models.QuotaLedger
+	filter = append(filter, bson.E{Key: "reservations.service_name", Value: serviceName})
+
+	collection := db.Database.Collection(collectionName)
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("error getting quota ledger: %w", err)
+	}
+	if err := cursor.All(ctx, &ledgers); err != nil {
+		return fmt.Errorf("error getting quota ledger: %w", err)
+	}
+	for _, ledger := range ledgers {
+		reservation := ledger.GetReservation(serviceName)
+		if reservation == nil {
+			continue
+		}
+		// filter on the service name guards against releasing the same reservation twice
+		filter := bson.D{{Key: "_id", Value: ledger.ID}, {Key: "reservations.service_name", Value: serviceName}}
+		update := bson.D{
+			{Key: "$inc", Value: bson.D{{Key: "cpu", Value: -reservation.Capacity.CPU}, {Key: "memory", Value: -reservation.Capacity.Memory}}},
+			{Key: "$pull", Value: bson.D{{Key: "reservations", Value: bson.D{{Key: "service_name", Value: serviceName}}}}},
+		}
+		if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+			return fmt.Errorf("error releasing quota: %w", err)
+		}
+	}
+	return nil
+}
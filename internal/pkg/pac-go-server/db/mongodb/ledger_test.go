@@ -0,0 +1,137 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+var duplicateKeyResponse = mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"})
+
+func TestReserveQuota(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	reservation := models.QuotaReservation{
+		ServiceName: "test-service",
+		Capacity:    models.Capacity{CPU: 2, Memory: 4},
+		ReservedAt:  time.Now(),
+	}
+	testcases := []struct {
+		name      string
+		limit     models.Capacity
+		responses []bson.D
+		// wantUpdates is the number of the updates sent to the database
+		wantUpdates int
+		err         error
+	}{
+		{
+			name:        "quota reserved",
+			limit:       models.Capacity{CPU: 8, Memory: 8, Services: 3},
+			responses:   []bson.D{mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1})},
+			wantUpdates: 1,
+		},
+		{
+			name:  "service larger than the limit",
+			limit: models.Capacity{CPU: 1, Memory: 8},
+			err:   utils.ErrQuotaExceeded,
+		},
+		{
+			name:        "ledger over the limit",
+			limit:       models.Capacity{CPU: 8, Memory: 8},
+			responses:   []bson.D{duplicateKeyResponse, duplicateKeyResponse},
+			wantUpdates: 2,
+			err:         utils.ErrQuotaExceeded,
+		},
+		{
+			name:  "ledger created first by a parallel request",
+			limit: models.Capacity{CPU: 8, Memory: 8},
+			responses: []bson.D{duplicateKeyResponse,
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1})},
+			wantUpdates: 2,
+		},
+	}
+	for _, tc := range testcases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			mt.AddMockResponses(tc.responses...)
+			db := &MongoDB{Database: mt.Client.Database("pac")}
+			assert.ErrorIs(mt, db.ReserveQuota("test-user", reservation, tc.limit), tc.err)
+
+			events := mt.GetAllStartedEvents()
+			assert.Len(mt, events, tc.wantUpdates)
+			for _, event := range events {
+				update := event.Command.Lookup("updates").Array().Index(0).Value().Document()
+				assert.True(mt, update.Lookup("upsert").Boolean())
+				filter := update.Lookup("q").Document()
+				assert.Equal(mt, "test-user", filter.Lookup("user_id").StringValue())
+				// the reserved capacity must leave room for the service
+				assert.Equal(mt, tc.limit.CPU-reservation.Capacity.CPU, filter.Lookup("cpu", "$lte").Double())
+				assert.Equal(mt, int32(tc.limit.Memory-reservation.Capacity.Memory), filter.Lookup("memory", "$lte").Int32())
+				assert.Equal(mt, reservation.ServiceName, filter.Lookup("reservations.service_name", "$ne").StringValue())
+				_, err := filter.LookupErr("reservations.2", "$exists")
+				assert.Equal(mt, tc.limit.Services == 3, err == nil)
+				assert.Equal(mt, reservation.Capacity.CPU, update.Lookup("u", "$inc", "cpu").Double())
+			}
+		})
+	}
+}
+
+func TestReleaseQuota(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	ledgerID := primitive.NewObjectID()
+	ledger := bson.D{
+		{Key: "_id", Value: ledgerID},
+		{Key: "user_id", Value: "test-user"},
+		{Key: "cpu", Value: 4.0},
+		{Key: "memory", Value: 8},
+		{Key: "reservations", Value: bson.A{
+			bson.D{{Key: "service_name", Value: "test-service"}, {Key: "capacity", Value: bson.D{{Key: "cpu", Value: 2.0}, {Key: "memory", Value: 4}}}},
+			bson.D{{Key: "service_name", Value: "other-service"}, {Key: "capacity", Value: bson.D{{Key: "cpu", Value: 2.0}, {Key: "memory", Value: 4}}}},
+		}},
+	}
+
+	mt.Run("reservation released", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "pac.quota_ledger", mtest.FirstBatch, ledger),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateCursorResponse(0, "pac.group_quota_ledger", mtest.FirstBatch),
+		)
+		db := &MongoDB{Database: mt.Client.Database("pac")}
+		assert.NoError(mt, db.ReleaseQuota("test-service"))
+
+		var updates []bson.Raw
+		for _, event := range mt.GetAllStartedEvents() {
+			if event.CommandName == "update" {
+				updates = append(updates, event.Command.Lookup("updates").Array().Index(0).Value().Document())
+			}
+		}
+		if assert.Len(mt, updates, 1) {
+			// only the capacity of the service released is given back, and only once
+			filter := updates[0].Lookup("q").Document()
+			assert.Equal(mt, ledgerID, filter.Lookup("_id").ObjectID())
+			assert.Equal(mt, "test-service", filter.Lookup("reservations.service_name").StringValue())
+			assert.Equal(mt, -2.0, updates[0].Lookup("u", "$inc", "cpu").Double())
+			assert.Equal(mt, int32(-4), updates[0].Lookup("u", "$inc", "memory").Int32())
+			assert.Equal(mt, "test-service", updates[0].Lookup("u", "$pull", "reservations", "service_name").StringValue())
+		}
+	})
+
+	mt.Run("already released", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "pac.quota_ledger", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "pac.group_quota_ledger", mtest.FirstBatch),
+		)
+		db := &MongoDB{Database: mt.Client.Database("pac")}
+		assert.NoError(mt, db.ReleaseQuota("test-service"))
+		for _, event := range mt.GetAllStartedEvents() {
+			assert.Equal(mt, "find", event.CommandName)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+// GetNotificationPreferences returns the notification preferences of the user, utils.ErrResourceNotFound is returned if
+// the user has not set any
+func (db *MongoDB) GetNotificationPreferences(userID string) (*models.NotificationPreferences, error) {
+	collection := db.Database.Collection("notification_preferences")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	var preferences models.NotificationPreferences
+	if err := collection.FindOne(ctx, bson.D{{Key: "user_id", Value: userID}}).Decode(&preferences); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, utils.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("error getting notification preferences: %w", err)
+	}
+	return &preferences, nil
+}
+
+// UpdateNotificationPreferences replaces the notification preferences of the user, creating them if not set yet
+func (db *MongoDB) UpdateNotificationPreferences(preferences *models.NotificationPreferences) error {
+	collection := db.Database.Collection("notification_preferences")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	filter := bson.D{{Key: "user_id", Value: preferences.UserID}}
+	if _, err := collection.ReplaceOne(ctx, filter, preferences, options.Replace().SetUpsert(true)); err != nil {
+		return fmt.Errorf("error updating notification preferences: %w", err)
+	}
+	return nil
+}
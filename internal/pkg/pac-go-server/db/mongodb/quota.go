@@ -3,9 +3,11 @@ package mongodb
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 
 	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
@@ -48,7 +50,11 @@ func (db *MongoDB) UpdateQuota(quota *models.Quota) error {
 	collection := db.Database.Collection("quota")
 	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
 	defer cancel()
-	_, err := collection.UpdateOne(ctx, bson.M{"group_id": quota.GroupID}, bson.D{{Key: "$set", Value: bson.D{{Key: "capacity", Value: quota.Capacity}}}})
+	_, err := collection.UpdateOne(ctx, bson.M{"group_id": quota.GroupID}, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "capacity", Value: quota.Capacity},
+		{Key: "type", Value: quota.Type},
+		{Key: "member_capacity", Value: quota.MemberCapacity},
+	}}})
 	if err != nil {
 		return fmt.Errorf("error while adding an entry for quota: %w", err)
 	}
@@ -88,3 +94,100 @@ func (db *MongoDB) GetGroupsQuota(groups []string) ([]models.Quota, error) {
 	}
 	return quota, nil
 }
+
+// GetQuotas returns the quota of all the groups
+func (db *MongoDB) GetQuotas() ([]models.Quota, error) {
+	var quota []models.Quota
+	collection := db.Database.Collection("quota")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	cur, err := collection.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("error getting quota: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &quota); err != nil {
+		return nil, fmt.Errorf("error fetching quota: %w", err)
+	}
+	return quota, nil
+}
+
+func (db *MongoDB) NewUserQuota(quota *models.UserQuota) error {
+	collection := db.Database.Collection("user_quota")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	if _, err := collection.InsertOne(ctx, quota); err != nil {
+		return fmt.Errorf("error while adding an entry for user quota: %w", err)
+	}
+	return nil
+}
+
+// GetActiveUserQuotas returns the quota granted to the user which are in effect at the moment
+func (db *MongoDB) GetActiveUserQuotas(userID string) ([]models.UserQuota, error) {
+	return db.getActiveUserQuotas(bson.D{{Key: "user_id", Value: userID}})
+}
+
+// GetAllActiveUserQuotas returns the quota granted to all the users which are in effect at the moment
+func (db *MongoDB) GetAllActiveUserQuotas() ([]models.UserQuota, error) {
+	return db.getActiveUserQuotas(bson.D{})
+}
+
+func (db *MongoDB) getActiveUserQuotas(filter bson.D) ([]models.UserQuota, error) {
+	var quota []models.UserQuota
+	now := time.Now()
+	filter = append(filter,
+		bson.E{Key: "starts_at", Value: bson.D{{Key: "$lte", Value: now}}},
+		bson.E{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: now}}},
+	)
+
+	collection := db.Database.Collection("user_quota")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	cur, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user quota: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &quota); err != nil {
+		return nil, fmt.Errorf("error fetching user quota: %w", err)
+	}
+	return quota, nil
+}
+
+// GetQuotaAlert returns the alert of the user or the group, nil is returned if the quota was never evaluated
+func (db *MongoDB) GetQuotaAlert(subjectType models.QuotaAlertSubject, subjectID string) (*models.QuotaAlert, error) {
+	var alert models.QuotaAlert
+	filter := bson.D{{Key: "subject_type", Value: subjectType}, {Key: "subject_id", Value: subjectID}}
+
+	collection := db.Database.Collection("quota_alerts")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	err := collection.FindOne(ctx, filter).Decode(&alert)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting quota alert: %w", err)
+	}
+	return &alert, nil
+}
+
+// UpdateQuotaAlert creates or updates the alert of the user or the group
+func (db *MongoDB) UpdateQuotaAlert(alert *models.QuotaAlert) error {
+	collection := db.Database.Collection("quota_alerts")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	filter := bson.D{{Key: "subject_type", Value: alert.SubjectType}, {Key: "subject_id", Value: alert.SubjectID}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "group_ids", Value: alert.GroupIDs},
+		{Key: "member_ids", Value: alert.MemberIDs},
+		{Key: "threshold", Value: alert.Threshold},
+		{Key: "evaluated_at", Value: alert.EvaluatedAt},
+	}}}
+	if _, err := collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true)); err != nil {
+		return fmt.Errorf("error updating quota alert: %w", err)
+	}
+	return nil
+}
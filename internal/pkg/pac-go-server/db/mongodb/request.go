@@ -5,17 +5,25 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
 )
 
+// notDeleted excludes the deleted requests, which are kept only for the audit trail
+var notDeleted = bson.E{Key: "state", Value: bson.D{{Key: "$ne", Value: models.RequestStateDeleted}}}
+
+// GetRequestsByUserID returns the requests of the type made by the user, the requests of all the users and the types
+// are returned if not set. The deleted requests are left out.
 func (db *MongoDB) GetRequestsByUserID(id, requestType string) ([]models.Request, error) {
 	var requests []models.Request
-	filter := bson.D{}
+	filter := bson.D{notDeleted}
 	if requestType != "" {
 		filter = append(filter, bson.E{Key: "type", Value: requestType})
 	}
@@ -56,6 +64,7 @@ func (db *MongoDB) NewRequest(request *models.Request) (string, error) {
 	return oid.Hex(), nil
 }
 
+// GetRequestByGroupIDAndUserID returns the requests of the user targeting the group, the deleted requests are left out
 func (db *MongoDB) GetRequestByGroupIDAndUserID(groupID string, userID string) ([]models.Request, error) {
 	var requests []models.Request
 
@@ -65,6 +74,7 @@ func (db *MongoDB) GetRequestByGroupIDAndUserID(groupID string, userID string) (
 				bson.D{{Key: "group.group_id", Value: groupID}},
 				bson.D{{Key: "user_id", Value: userID}},
 			}},
+		notDeleted,
 	}
 
 	collection := db.Database.Collection("requests")
@@ -81,7 +91,7 @@ func (db *MongoDB) GetRequestByGroupIDAndUserID(groupID string, userID string) (
 	return requests, nil
 }
 
-// GetRequestByID returns a request by its ID
+// GetRequestByID returns a request by its ID, the deleted request is returned too for its audit trail
 func (db *MongoDB) GetRequestByID(id string) (*models.Request, error) {
 	var request models.Request
 
@@ -106,7 +116,9 @@ func (db *MongoDB) GetRequestByID(id string) (*models.Request, error) {
 	return &request, nil
 }
 
-func (db *MongoDB) DeleteRequest(id string) error {
+// UpdateRequestState moves the request through the transition and appends the transition to the history of the
+// request, provided the request is still in the state the transition is from.
+func (db *MongoDB) UpdateRequestState(id string, transition models.RequestTransition) error {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return fmt.Errorf("invalid id: %w", err)
@@ -114,15 +126,59 @@ func (db *MongoDB) DeleteRequest(id string) error {
 	collection := db.Database.Collection("requests")
 	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
 	defer cancel()
-	_, err = collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: objectId}})
+	result, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: objectId}, {Key: "state", Value: transition.From}},
+		getTransitionUpdate(transition, bson.D{}))
 	if err != nil {
-		return fmt.Errorf("error deleting request: %w", err)
+		return fmt.Errorf("error updating request: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrResourceModified
+	}
+	return nil
+}
+
+// getTransitionUpdate returns the update setting the state of the transition along with the fields, and appending the
+// transition to the history
+func getTransitionUpdate(transition models.RequestTransition, fields bson.D) bson.D {
+	fields = append(fields, bson.E{Key: "state", Value: transition.To})
+	if transition.Comment != "" {
+		fields = append(fields, bson.E{Key: "comment", Value: transition.Comment})
+	}
+	return bson.D{
+		{Key: "$set", Value: fields},
+		{Key: "$push", Value: bson.D{{Key: "history", Value: transition}}},
 	}
+}
 
+// UpdateRequestApproval records the approval chain of the request, provided the request is still pending and no other
+// approval is recorded since the request was read.
+func (db *MongoDB) UpdateRequestApproval(id string, approval *models.Approval, previousApprovals int) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	filter := bson.D{{Key: "_id", Value: objectId}, {Key: "state", Value: models.RequestStateNew}}
+	if previousApprovals == 0 {
+		filter = append(filter, bson.E{Key: "approval", Value: bson.D{{Key: "$exists", Value: false}}})
+	} else {
+		filter = append(filter, bson.E{Key: "approval.approvals", Value: bson.D{{Key: "$size", Value: previousApprovals}}})
+	}
+	collection := db.Database.Collection("requests")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	result, err := collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: bson.D{{Key: "approval", Value: approval}}}})
+	if err != nil {
+		return fmt.Errorf("error updating request approval: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrResourceModified
+	}
 	return nil
 }
 
-func (db *MongoDB) UpdateRequestState(id string, state models.RequestStateType) error {
+// UpdateRequestDecision records the decision taken by a rule on the request along with the transition, provided the
+// request is still in the state the transition is from.
+func (db *MongoDB) UpdateRequestDecision(id string, transition models.RequestTransition, decision *models.RuleDecision) error {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return fmt.Errorf("invalid id: %w", err)
@@ -130,15 +186,20 @@ func (db *MongoDB) UpdateRequestState(id string, state models.RequestStateType)
 	collection := db.Database.Collection("requests")
 	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
 	defer cancel()
-	_, err = collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: objectId}}, bson.D{{Key: "$set", Value: bson.D{{Key: "state", Value: state}}}})
+	result, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: objectId}, {Key: "state", Value: transition.From}},
+		getTransitionUpdate(transition, bson.D{{Key: "decision", Value: decision}}))
 	if err != nil {
 		return fmt.Errorf("error updating request: %w", err)
 	}
-
+	if result.MatchedCount == 0 {
+		return utils.ErrResourceModified
+	}
 	return nil
 }
 
-func (db *MongoDB) UpdateRequestStateWithComment(id string, state models.RequestStateType, comment string) error {
+// RevertRequestDecision reverts the decision recorded by a rule on the request, provided the request is still in the
+// state the rule has moved it to, e.g. once the decision could not be carried out.
+func (db *MongoDB) RevertRequestDecision(id string, transition models.RequestTransition) error {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return fmt.Errorf("invalid id: %w", err)
@@ -146,20 +207,28 @@ func (db *MongoDB) UpdateRequestStateWithComment(id string, state models.Request
 	collection := db.Database.Collection("requests")
 	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
 	defer cancel()
-	_, err = collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: objectId}}, bson.D{{Key: "$set", Value: bson.D{{Key: "state", Value: state}, {Key: "comment", Value: comment}}}})
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "state", Value: transition.To}}},
+		{Key: "$unset", Value: bson.D{{Key: "decision", Value: ""}, {Key: "comment", Value: ""}}},
+		{Key: "$push", Value: bson.D{{Key: "history", Value: transition}}},
+	}
+	result, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: objectId}, {Key: "state", Value: transition.From}}, update)
 	if err != nil {
 		return fmt.Errorf("error updating request: %w", err)
 	}
-
+	if result.MatchedCount == 0 {
+		return utils.ErrResourceModified
+	}
 	return nil
 }
 
+// GetRequestByServiceName returns the requests targeting the service, the deleted requests are left out
 func (db *MongoDB) GetRequestByServiceName(serviceName string) ([]models.Request, error) {
 	var requests []models.Request
 	if serviceName == "" {
 		return nil, errors.New("serviceName name is not set")
 	}
-	filter := bson.D{{Key: "service.name", Value: serviceName}}
+	filter := bson.D{{Key: "service.name", Value: serviceName}, notDeleted}
 	collection := db.Database.Collection("requests")
 	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
 	defer cancel()
@@ -174,3 +243,120 @@ func (db *MongoDB) GetRequestByServiceName(serviceName string) ([]models.Request
 	}
 	return requests, nil
 }
+
+// GetRequests returns a page of the requests matching the filter along with the count of all the matching requests
+func (db *MongoDB) GetRequests(filter models.RequestFilter) ([]models.Request, int64, error) {
+	requests := []models.Request{}
+	query := bson.D{}
+	switch {
+	case filter.UserID != "" && len(filter.OwnedGroupIDs) > 0:
+		query = append(query, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "user_id", Value: filter.UserID}},
+			bson.D{
+				{Key: "group.group_id", Value: bson.D{{Key: "$in", Value: filter.OwnedGroupIDs}}},
+				{Key: "type", Value: bson.D{{Key: "$in", Value: bson.A{models.RequestAddToGroup, models.RequestExitFromGroup}}}},
+			},
+		}})
+	case filter.UserID != "":
+		query = append(query, bson.E{Key: "user_id", Value: filter.UserID})
+	}
+	if filter.RequesterID != "" {
+		query = append(query, bson.E{Key: "user_id", Value: filter.RequesterID})
+	}
+	if filter.Type != "" {
+		query = append(query, bson.E{Key: "type", Value: filter.Type})
+	}
+	if filter.State != "" {
+		query = append(query, bson.E{Key: "state", Value: filter.State})
+	} else {
+		// deleted requests are listed only if asked for
+		query = append(query, notDeleted)
+	}
+	if filter.GroupID != "" {
+		query = append(query, bson.E{Key: "group.group_id", Value: filter.GroupID})
+	}
+	if filter.ServiceName != "" {
+		query = append(query, bson.E{Key: "service.name", Value: filter.ServiceName})
+	}
+	createdAt := bson.D{}
+	if !filter.CreatedAfter.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$gte", Value: filter.CreatedAfter})
+	}
+	if !filter.CreatedBefore.IsZero() {
+		createdAt = append(createdAt, bson.E{Key: "$lt", Value: filter.CreatedBefore})
+	}
+	if len(createdAt) > 0 {
+		query = append(query, bson.E{Key: "created_at", Value: createdAt})
+	}
+
+	order := 1
+	if filter.Descending {
+		order = -1
+	}
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: sortBy, Value: order}, {Key: "_id", Value: order}}).
+		SetSkip(filter.StartIndex)
+	if filter.PerPage > 0 {
+		findOptions.SetLimit(filter.PerPage)
+	}
+
+	collection := db.Database.Collection("requests")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	cur, err := collection.Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting requests: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &requests); err != nil {
+		return nil, 0, fmt.Errorf("error fetching requests: %w", err)
+	}
+	totalCount, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error getting total count of requests: %w", err)
+	}
+	return requests, totalCount, nil
+}
+
+// GetPendingRequestsBefore returns the pending requests of the type created before the time
+func (db *MongoDB) GetPendingRequestsBefore(requestType models.RequestType, createdBefore time.Time) ([]models.Request, error) {
+	requests := []models.Request{}
+	filter := bson.D{
+		{Key: "state", Value: models.RequestStateNew},
+		{Key: "type", Value: requestType},
+		{Key: "created_at", Value: bson.D{{Key: "$lt", Value: createdBefore}}},
+	}
+	collection := db.Database.Collection("requests")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	cur, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error getting pending requests: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &requests); err != nil {
+		return nil, fmt.Errorf("error fetching pending requests: %w", err)
+	}
+	return requests, nil
+}
+
+// UpdateRequestReminded records the time the approvers were reminded of the pending request
+func (db *MongoDB) UpdateRequestReminded(id string, remindedAt time.Time) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	collection := db.Database.Collection("requests")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	if _, err := collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: objectId}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "reminded_at", Value: remindedAt}}}}); err != nil {
+		return fmt.Errorf("error updating request reminder: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+func TestRequestLookupsExcludeDeleted(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	testcases := []struct {
+		name   string
+		lookup func(db *MongoDB) error
+	}{
+		{
+			name: "requests of the user",
+			lookup: func(db *MongoDB) error {
+				_, err := db.GetRequestsByUserID("12345", string(models.RequestAddToGroup))
+				return err
+			},
+		},
+		{
+			name: "requests of all the users",
+			lookup: func(db *MongoDB) error {
+				_, err := db.GetRequestsByUserID("", "")
+				return err
+			},
+		},
+		{
+			name: "requests of the user for the group",
+			lookup: func(db *MongoDB) error {
+				_, err := db.GetRequestByGroupIDAndUserID("group-id", "12345")
+				return err
+			},
+		},
+		{
+			name: "requests for the service",
+			lookup: func(db *MongoDB) error {
+				_, err := db.GetRequestByServiceName("test-service")
+				return err
+			},
+		},
+	}
+	for _, tc := range testcases {
+		mt.Run(tc.name, func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "pac.requests", mtest.FirstBatch))
+			db := &MongoDB{Database: mt.Client.Database("pac")}
+			assert.NoError(mt, tc.lookup(db))
+
+			filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+			state, err := filter.LookupErr("state", "$ne")
+			assert.NoError(mt, err)
+			assert.Equal(mt, string(models.RequestStateDeleted), state.StringValue())
+		})
+	}
+}
+
+func TestGetRequestByIDIncludesDeleted(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("deleted request kept for the audit trail", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "pac.requests", mtest.FirstBatch,
+			bson.D{{Key: "state", Value: models.RequestStateDeleted}}))
+		db := &MongoDB{Database: mt.Client.Database("pac")}
+		request, err := db.GetRequestByID("6511b5a2f9fb1b8a1e2b3c4d")
+		assert.NoError(mt, err)
+		assert.Equal(mt, models.RequestStateDeleted, request.State)
+
+		_, err = mt.GetStartedEvent().Command.Lookup("filter").Document().LookupErr("state")
+		assert.Error(mt, err)
+	})
+}
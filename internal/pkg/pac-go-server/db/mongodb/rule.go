@@ -0,0 +1,102 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+func (db *MongoDB) NewRule(rule *models.Rule) (string, error) {
+	collection := db.Database.Collection("rules")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	result, err := collection.InsertOne(ctx, rule)
+	if err != nil {
+		return "", fmt.Errorf("error inserting rule: %w", err)
+	}
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// GetRules returns all the rules in the order of evaluation
+func (db *MongoDB) GetRules() ([]models.Rule, error) {
+	rules := []models.Rule{}
+	collection := db.Database.Collection("rules")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := collection.Find(ctx, bson.D{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting rules: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &rules); err != nil {
+		return nil, fmt.Errorf("error fetching rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (db *MongoDB) GetRuleByID(id string) (*models.Rule, error) {
+	var rule models.Rule
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id: %w", err)
+	}
+	collection := db.Database.Collection("rules")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	if err := collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&rule); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, utils.ErrResourceNotFound
+		}
+		return nil, fmt.Errorf("error getting rule: %w", err)
+	}
+	return &rule, nil
+}
+
+func (db *MongoDB) UpdateRule(rule *models.Rule) error {
+	collection := db.Database.Collection("rules")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": rule.ID}, bson.D{{Key: "$set", Value: bson.D{
+		{Key: "name", Value: rule.Name},
+		{Key: "priority", Value: rule.Priority},
+		{Key: "enabled", Value: rule.Enabled},
+		{Key: "action", Value: rule.Action},
+		{Key: "reason", Value: rule.Reason},
+		{Key: "match", Value: rule.Match},
+	}}})
+	if err != nil {
+		return fmt.Errorf("error updating rule: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrResourceNotFound
+	}
+	return nil
+}
+
+func (db *MongoDB) DeleteRule(id string) error {
+	objectId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid id: %w", err)
+	}
+	collection := db.Database.Collection("rules")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	result, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: objectId}})
+	if err != nil {
+		return fmt.Errorf("error deleting rule: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return utils.ErrResourceNotFound
+	}
+	return nil
+}
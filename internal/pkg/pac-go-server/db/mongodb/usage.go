@@ -0,0 +1,134 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+func (db *MongoDB) NewServiceUsage(usage *models.ServiceUsage) error {
+	collection := db.Database.Collection("usage")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	if _, err := collection.InsertOne(ctx, usage); err != nil {
+		return fmt.Errorf("error while adding an entry for service usage: %w", err)
+	}
+	return nil
+}
+
+// GetServiceUsage returns the usage of the services of the users which were running at any point between start and end,
+// usage of all the users is returned if userIDs is empty.
+func (db *MongoDB) GetServiceUsage(userIDs []string, start, end time.Time) ([]models.ServiceUsage, error) {
+	var usage []models.ServiceUsage
+	filter := bson.D{
+		{Key: "started_at", Value: bson.D{{Key: "$lt", Value: end}}},
+		{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: start}}},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "ended_at", Value: nil}},
+			bson.D{{Key: "ended_at", Value: bson.D{{Key: "$gt", Value: start}}}},
+		}},
+	}
+	if len(userIDs) > 0 {
+		filter = append(filter, bson.E{Key: "user_id", Value: bson.D{{Key: "$in", Value: userIDs}}})
+	}
+
+	collection := db.Database.Collection("usage")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	cur, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error getting service usage: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &usage); err != nil {
+		return nil, fmt.Errorf("error fetching service usage: %w", err)
+	}
+	return usage, nil
+}
+
+func (db *MongoDB) UpdateServiceUsageExpiry(serviceName string, expiry time.Time) error {
+	collection := db.Database.Collection("usage")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	filter := bson.D{{Key: "service_name", Value: serviceName}, {Key: "ended_at", Value: nil}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "expires_at", Value: expiry}}}}
+	if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("error updating service usage: %w", err)
+	}
+	return nil
+}
+
+// DeleteServiceUsage removes the usage recorded for the service, deleting the usage not recorded is a no-op
+func (db *MongoDB) DeleteServiceUsage(serviceName string) error {
+	collection := db.Database.Collection("usage")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	if _, err := collection.DeleteOne(ctx, bson.D{{Key: "service_name", Value: serviceName}, {Key: "ended_at", Value: nil}}); err != nil {
+		return fmt.Errorf("error deleting service usage: %w", err)
+	}
+	return nil
+}
+
+// EndServiceUsage marks the service as stopped and returns the ended usage, nil is returned if the service is already
+// ended or its usage was never recorded.
+func (db *MongoDB) EndServiceUsage(serviceName string, endedAt time.Time) (*models.ServiceUsage, error) {
+	var usage models.ServiceUsage
+	collection := db.Database.Collection("usage")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	filter := bson.D{{Key: "service_name", Value: serviceName}, {Key: "ended_at", Value: nil}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "ended_at", Value: endedAt}}}}
+	err := collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&usage)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating service usage: %w", err)
+	}
+	return &usage, nil
+}
+
+// NewUsageRecord adds the transition to the usage history, recording the expiry of a service again is a no-op
+func (db *MongoDB) NewUsageRecord(record *models.UsageRecord) error {
+	collection := db.Database.Collection("usage_history")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	if _, err := collection.InsertOne(ctx, record); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return fmt.Errorf("error while adding an entry for usage history: %w", err)
+	}
+	return nil
+}
+
+// GetUsageRecords returns the usage history of the users between start and end sorted by time, history of all the users
+// is returned if userIDs is empty.
+func (db *MongoDB) GetUsageRecords(userIDs []string, start, end time.Time) ([]models.UsageRecord, error) {
+	var records []models.UsageRecord
+	filter := bson.D{{Key: "timestamp", Value: bson.D{{Key: "$gte", Value: start}, {Key: "$lt", Value: end}}}}
+	if len(userIDs) > 0 {
+		filter = append(filter, bson.E{Key: "user_id", Value: bson.D{{Key: "$in", Value: userIDs}}})
+	}
+
+	collection := db.Database.Collection("usage_history")
+	ctx, cancel := context.WithTimeout(context.Background(), dbContextTimeout)
+	defer cancel()
+	cur, err := collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("error getting usage history: %w", err)
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("error fetching usage history: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// ApprovalPolicies are the approval chains required for the sensitive requests, the requests not matching any of the
+// policies are approved by a single approval.
+var ApprovalPolicies []ApprovalPolicy
+
+// ApprovalPolicy is the chain of approvals required for the requests matching the policy
+type ApprovalPolicy struct {
+	Name        string      `json:"name"`
+	RequestType RequestType `json:"request_type"`
+	// Groups are the names of the groups, joining which requires the approval chain, all the groups if empty
+	Groups []string `json:"groups,omitempty"`
+	// MinExtensionDays is the number of days from the request, beyond which extending the service expiry requires the
+	// approval chain
+	MinExtensionDays int `json:"min_extension_days,omitempty"`
+	// Stages are approved in sequence
+	Stages []ApprovalStage `json:"stages"`
+}
+
+// ApprovalStage is a step of the approval chain
+type ApprovalStage struct {
+	// ApproverGroup is the name of the group the approvers must be a member of, any admin can approve if empty
+	ApproverGroup string `json:"approver_group,omitempty" bson:"approver_group,omitempty"`
+	// Approvals is the number of distinct approvals required to complete the stage
+	Approvals int `json:"approvals" bson:"approvals"`
+}
+
+// Approval is the progress of the approval chain of a request
+type Approval struct {
+	// Policy is the name of the policy the request matched on the first approval
+	Policy    string            `json:"policy" bson:"policy"`
+	Stages    []ApprovalStage   `json:"stages" bson:"stages"`
+	Approvals []RequestApproval `json:"approvals" bson:"approvals"`
+}
+
+// RequestApproval is an approval given to the request
+type RequestApproval struct {
+	UserID     string    `json:"user_id" bson:"user_id"`
+	Username   string    `json:"username" bson:"username"`
+	Stage      int       `json:"stage" bson:"stage"`
+	ApprovedAt time.Time `json:"approved_at" bson:"approved_at"`
+}
+
+// CurrentStage returns the index of the stage pending approval, or the number of stages if the chain is satisfied
+func (a *Approval) CurrentStage() int {
+	count := 0
+	for i, stage := range a.Stages {
+		count += stage.Approvals
+		if len(a.Approvals) < count {
+			return i
+		}
+	}
+	return len(a.Stages)
+}
+
+// IsSatisfied returns true if all the stages of the chain are approved
+func (a *Approval) IsSatisfied() bool {
+	return a.CurrentStage() == len(a.Stages)
+}
+
+// HasApproved returns true if the user has already approved the request
+func (a *Approval) HasApproved(userID string) bool {
+	for _, approval := range a.Approvals {
+		if approval.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
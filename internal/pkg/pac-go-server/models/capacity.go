@@ -3,4 +3,10 @@ package models
 type Capacity struct {
 	CPU    float64 `json:"cpu" bson:"cpu,omitempty"`
 	Memory int     `json:"memory" bson:"memory,omitempty"`
+	// Services is the number of services that can be run at the same time, 0 means no limit
+	Services int `json:"services,omitempty" bson:"services,omitempty"`
+	// MaxExtensionDays is the maximum number of days the expiry of a service can be extended by, 0 means no limit
+	MaxExtensionDays int `json:"max_extension_days,omitempty" bson:"max_extension_days,omitempty"`
+	// CoreHours is the core-hours the services can consume in a calendar month, 0 means no limit
+	CoreHours float64 `json:"core_hours,omitempty" bson:"core_hours,omitempty"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RequestComment is a comment on the thread of a request between the requester and the approvers
+type RequestComment struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RequestID string             `json:"request_id" bson:"request_id"`
+	UserID    string             `json:"user_id" bson:"user_id"`
+	Username  string             `json:"username" bson:"username"`
+	Message   string             `json:"message" bson:"message"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
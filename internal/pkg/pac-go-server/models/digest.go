@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+)
+
+// EventDigest is the events of the user mailed together once a day, grouped by type
+type EventDigest struct {
+	UserID    string
+	UserEmail string
+	// Groups are the events grouped by type, in the order of EventTypes
+	Groups    []EventDigestGroup
+	CreatedAt time.Time
+}
+
+type EventDigestGroup struct {
+	Type   EventType
+	Events []Event
+}
+
+const digestBodyTemplate = `
+Hi,
+
+Here is the digest of your IBM® Power® Access Cloud notifications.
+{{ range .Groups }}
+{{ .Type }} ({{ len .Events }})
+{{- range .Events }}
+  - {{ .CreatedAt.Format "Jan 02, 2006 15:04:05 UTC" }}: {{ if .Log.Message }}{{ .Log.Message }}{{ else }}{{ .Type }}{{ end }}
+{{- end }}
+{{ end }}
+Please visit the IBM® Power® Access Cloud portal for more details. You can choose the notifications to be included in the digest in your notification preferences.
+
+Note: This is an auto-generated email. Please do not reply to this email.
+Generated at: {{ .CreatedAt.Format "Jan 02, 2006 15:04:05 UTC" }}
+
+Thanks,
+IBM® Power® Access Cloud Support.
+`
+
+const digestHTMLBodyTemplate = `
+<html>
+<body>
+<p>Hi,</p>
+<p>Here is the digest of your IBM® Power® Access Cloud notifications.</p>
+{{- range .Groups }}
+<h3>{{ .Type }} ({{ len .Events }})</h3>
+<ul>
+{{- range .Events }}
+<li>{{ .CreatedAt.Format "Jan 02, 2006 15:04:05 UTC" }}: {{ if .Log.Message }}{{ .Log.Message }}{{ else }}{{ .Type }}{{ end }}</li>
+{{- end }}
+</ul>
+{{- end }}
+<p>Please visit the IBM® Power® Access Cloud portal for more details. You can choose the notifications to be included in the digest in your notification preferences.</p>
+<p><small>Note: This is an auto-generated email. Please do not reply to this email.<br>
+Generated at: {{ .CreatedAt.Format "Jan 02, 2006 15:04:05 UTC" }}</small></p>
+<p>Thanks,<br>
+IBM® Power® Access Cloud Support.</p>
+</body>
+</html>
+`
+
+// NewEventDigest returns the digest of the events of the user, the event types not known are listed last
+func NewEventDigest(userID, userEmail string, events []Event, now time.Time) *EventDigest {
+	byType := make(map[EventType][]Event)
+	var unknownTypes []EventType
+	for _, event := range events {
+		if _, ok := byType[event.Type]; !ok && !IsValidEventType(event.Type) {
+			unknownTypes = append(unknownTypes, event.Type)
+		}
+		byType[event.Type] = append(byType[event.Type], event)
+	}
+	digest := &EventDigest{UserID: userID, UserEmail: userEmail, CreatedAt: now}
+	for _, eventType := range append(append([]EventType{}, EventTypes...), unknownTypes...) {
+		if typeEvents, ok := byType[eventType]; ok {
+			digest.Groups = append(digest.Groups, EventDigestGroup{Type: eventType, Events: typeEvents})
+		}
+	}
+	return digest
+}
+
+// ComposeMailSubject composes the subject of the digest mail
+func (d *EventDigest) ComposeMailSubject() (string, error) {
+	return eventTemplates.execute(digestTemplateKey, templatePartSubject, d)
+}
+
+// ComposeMailBody composes the plain text mail body of the digest
+func (d *EventDigest) ComposeMailBody() (string, error) {
+	return eventTemplates.execute(digestTemplateKey, templatePartPlain, d)
+}
+
+// ComposeMailHTMLBody composes the HTML alternative of the digest mail body
+func (d *EventDigest) ComposeMailHTMLBody() (string, error) {
+	return eventTemplates.execute(digestTemplateKey, templatePartHTML, d)
+}
@@ -1,8 +1,6 @@
 package models
 
 import (
-	"bytes"
-	"html/template"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,6 +11,9 @@ type EventLogLevel string
 
 var ExpiryNotificationDuration time.Duration
 
+// EventRetention is the duration the events are kept for, the events are kept forever if it is zero
+var EventRetention time.Duration
+
 const (
 	EventGroupJoinRequest           EventType = "GROUP_JOIN_REQUEST"
 	EventServiceExpiryRequest       EventType = "SERVICE_EXPIRY_REQUEST"
@@ -20,10 +21,15 @@ const (
 	EventServiceExpiryNotification  EventType = "SERVICE_EXPIRY_NOTIFICATION"
 	EventServiceExpiredNotification EventType = "SERVICE_EXPIRED_NOTIFICATION"
 	EventDeletUserRequest           EventType = "DELETE_USER_REQUEST"
+	EventQuotaIncreaseRequest       EventType = "QUOTA_INCREASE_REQUEST"
 
-	EventTypeRequestApproved EventType = "REQUEST_APPROVED"
-	EventTypeRequestRejected EventType = "REQUEST_REJECTED"
-	EventTypeRequestDeleted  EventType = "REQUEST_DELETED"
+	EventTypeRequestApproved          EventType = "REQUEST_APPROVED"
+	EventTypeRequestPartiallyApproved EventType = "REQUEST_PARTIALLY_APPROVED"
+	EventTypeRequestRejected          EventType = "REQUEST_REJECTED"
+	EventTypeRequestDeleted           EventType = "REQUEST_DELETED"
+	EventTypeRequestExpired           EventType = "REQUEST_EXPIRED"
+	EventRequestExpiryReminder        EventType = "REQUEST_EXPIRY_REMINDER"
+	EventTypeRequestComment           EventType = "REQUEST_COMMENT"
 
 	EventCatalogCreate EventType = "CATALOG_CREATE"
 	EventCatalogUpdate EventType = "CATALOG_UPDATE"
@@ -35,10 +41,90 @@ const (
 	EventServiceDelete       EventType = "SERVICE_DELETE"
 	EventServiceDeleteFailed EventType = "SERVICE_DELETE_FAILED"
 
+	EventQuotaThreshold EventType = "QUOTA_THRESHOLD"
+
+	EventGroupExitBlocked       EventType = "GROUP_EXIT_BLOCKED"
+	EventServiceExpiryScheduled EventType = "SERVICE_EXPIRY_SCHEDULED"
+
 	EventLogLevelINFO  EventLogLevel = "INFO"
 	EventLogLevelERROR EventLogLevel = "ERROR"
+
+	// EventDeliveryPending is the state of the events not attempted to notify yet, the events created before the
+	// delivery is tracked have no state and are pending too
+	EventDeliveryPending    EventDeliveryState = "PENDING"
+	EventDeliveryRetrying   EventDeliveryState = "RETRYING"
+	EventDeliveryDelivered  EventDeliveryState = "DELIVERED"
+	EventDeliveryDeadLetter EventDeliveryState = "DEAD_LETTER"
+	// EventDeliverySkipped is the state of the events not to be mailed as per the preferences of the user
+	EventDeliverySkipped EventDeliveryState = "SKIPPED"
+	// EventDeliveryDigest is the state of the events to be mailed in the digest as per the preferences of the user
+	EventDeliveryDigest EventDeliveryState = "DIGEST"
+)
+
+// EventTypes are all the types of the events
+var EventTypes = []EventType{
+	EventGroupJoinRequest, EventServiceExpiryRequest, EventGroupExitRequest, EventServiceExpiryNotification,
+	EventServiceExpiredNotification, EventDeletUserRequest, EventQuotaIncreaseRequest,
+	EventTypeRequestApproved, EventTypeRequestPartiallyApproved, EventTypeRequestRejected, EventTypeRequestDeleted,
+	EventTypeRequestExpired, EventRequestExpiryReminder, EventTypeRequestComment,
+	EventCatalogCreate, EventCatalogUpdate, EventCatalogDelete, EventCatalogRetire,
+	EventServiceCreate, EventServiceUpdate, EventServiceDelete, EventServiceDeleteFailed,
+	EventQuotaThreshold, EventGroupExitBlocked, EventServiceExpiryScheduled,
+}
+
+// IsValidEventType returns true if the type is one of EventTypes
+func IsValidEventType(eventType EventType) bool {
+	for _, t := range EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type EventDeliveryState string
+
+const (
+	// EventMetadataServiceName is the name of the service the event is about
+	EventMetadataServiceName = "service_name"
+	// EventMetadataExpiry is the expiry of the service in RFC 3339 format
+	EventMetadataExpiry = "expiry"
+	// EventMetadataRequestID is the id of the request the event is about
+	EventMetadataRequestID = "request_id"
+	// EventMetadataRequestType is the type of the request the event is about
+	EventMetadataRequestType = "request_type"
+	// EventMetadataGroup is the name of the group the event is about
+	EventMetadataGroup = "group"
+	// EventMetadataServices are the comma separated names of the services the event is about
+	EventMetadataServices = "services"
+	// EventMetadataRuleID is the id of the rule decided the request
+	EventMetadataRuleID = "rule_id"
+	// EventMetadataThreshold is the quota utilization threshold in percent crossed
+	EventMetadataThreshold = "threshold"
+)
+
+type EventResourceKind string
+
+const (
+	EventResourceRequest EventResourceKind = "REQUEST"
+	EventResourceService EventResourceKind = "SERVICE"
+	EventResourceCatalog EventResourceKind = "CATALOG"
+	EventResourceGroup   EventResourceKind = "GROUP"
+	EventResourceUser    EventResourceKind = "USER"
 )
 
+// EventResourceKinds are the valid kinds of the resources the events are about
+var EventResourceKinds = []EventResourceKind{EventResourceRequest, EventResourceService, EventResourceCatalog,
+	EventResourceGroup, EventResourceUser}
+
+// EventResource is the reference to the resource the event is about, the resources are referred by the id, by the
+// name or by both as per the kind, e.g. the services by the name and the requests by the id
+type EventResource struct {
+	Kind EventResourceKind `json:"kind" bson:"kind"`
+	ID   string            `json:"id,omitempty" bson:"id,omitempty"`
+	Name string            `json:"name,omitempty" bson:"name,omitempty"`
+}
+
 type Event struct {
 	// ID is the event identifier
 	ID primitive.ObjectID `json:"id" bson:"_id,omitempty"`
@@ -60,6 +146,35 @@ type Event struct {
 	Notified bool `json:"notified" bson:"notified"`
 	// Log contains all the event information
 	Log EventLog `json:"log" bson:"log"`
+	// Resource is the resource the event is about
+	Resource *EventResource `json:"resource,omitempty" bson:"resource,omitempty"`
+	// Metadata are the structured details of the event, e.g. the service name, keyed by the EventMetadata keys
+	Metadata map[string]string `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	// Delivery tracks the attempts to notify the event
+	Delivery EventDelivery `json:"delivery" bson:"delivery"`
+	// ExpiresAt is the time the event is removed at by the TTL index
+	ExpiresAt *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+}
+
+type EventDelivery struct {
+	State EventDeliveryState `json:"state" bson:"state"`
+	// Attempts is the number of the failed attempts to notify the event
+	Attempts int `json:"attempts" bson:"attempts"`
+	// LastError is the failure of the last attempt
+	LastError     string     `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty" bson:"last_attempt_at,omitempty"`
+	// NextRetryAt is the time the event is notified again at, set only while retrying
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty" bson:"next_retry_at,omitempty"`
+	// DeliveredTo are the notifier backends the event is delivered through so far, the failed delivery is retried only
+	// through the others
+	DeliveredTo []string `json:"delivered_to,omitempty" bson:"delivered_to,omitempty"`
+	// DigestDeliveredTo are the notifier backends the digest including the event is mailed through so far, the failed
+	// digest is mailed again only through the others
+	DigestDeliveredTo []string `json:"digest_delivered_to,omitempty" bson:"digest_delivered_to,omitempty"`
+	// LeaseOwner is the notifier claimed the event for delivery till LeasedUntil, the event can be claimed by another
+	// notifier once the lease is over
+	LeaseOwner  string     `json:"lease_owner,omitempty" bson:"lease_owner,omitempty"`
+	LeasedUntil *time.Time `json:"leased_until,omitempty" bson:"leased_until,omitempty"`
 }
 
 type EventLog struct {
@@ -67,6 +182,27 @@ type EventLog struct {
 	Message string        `json:"message" bson:"message"`
 }
 
+// EventFilter is the criteria the events are listed by, the criteria not set are ignored
+type EventFilter struct {
+	// UserID limits the events to the ones of the user
+	UserID     string
+	Type       EventType
+	Level      EventLogLevel
+	Originator string
+	// ResourceKind, ResourceID and ResourceName limit the events to the ones about the resource
+	ResourceKind EventResourceKind
+	ResourceID   string
+	ResourceName string
+	// CreatedAfter and CreatedBefore bound the time the events were created at
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// SortBy is the field the events are sorted by, the events are sorted in the ascending order unless Descending
+	SortBy     string
+	Descending bool
+	StartIndex int64
+	PerPage    int64
+}
+
 type EventResponse struct {
 	// TotalPages is the total number of pages
 	TotalPages int64 `json:"total_pages"`
@@ -79,13 +215,19 @@ type EventResponse struct {
 }
 
 func NewEvent(userid, originator string, typ EventType) (*Event, error) {
-	return &Event{
+	event := &Event{
 		Type:      typ,
 		CreatedAt: time.Now(),
 
 		UserID:     userid,
 		Originator: originator,
-	}, nil
+		Delivery:   EventDelivery{State: EventDeliveryPending},
+	}
+	if EventRetention > 0 {
+		expiresAt := event.CreatedAt.Add(EventRetention)
+		event.ExpiresAt = &expiresAt
+	}
+	return event, nil
 }
 
 // SetType sets the event type
@@ -114,6 +256,84 @@ func (e *Event) SetNotifiyBoth() {
 	e.NotifyAdmin = true
 }
 
+// SetDelivered records the successful delivery of the event
+func (e *Event) SetDelivered(now time.Time) {
+	e.Notified = true
+	e.releaseLease()
+	e.Delivery.State = EventDeliveryDelivered
+	e.Delivery.LastAttemptAt = &now
+	e.Delivery.NextRetryAt = nil
+}
+
+// SetDeliveryFailed records the failed delivery of the event, the event is retried after the backoff doubling with
+// every attempt until it is dead-lettered on reaching the max attempts
+func (e *Event) SetDeliveryFailed(err error, now time.Time, maxAttempts int, backoff time.Duration) {
+	e.releaseLease()
+	e.Delivery.Attempts++
+	e.Delivery.LastError = err.Error()
+	e.Delivery.LastAttemptAt = &now
+	if e.Delivery.Attempts >= maxAttempts {
+		e.Delivery.State = EventDeliveryDeadLetter
+		e.Delivery.NextRetryAt = nil
+		return
+	}
+	nextRetryAt := now.Add(backoff << (e.Delivery.Attempts - 1))
+	e.Delivery.State = EventDeliveryRetrying
+	e.Delivery.NextRetryAt = &nextRetryAt
+}
+
+// ReplayDelivery makes the failed delivery of the event due for retry right away with the attempts reset
+func (e *Event) ReplayDelivery(now time.Time) {
+	e.releaseLease()
+	e.Delivery.State = EventDeliveryRetrying
+	e.Delivery.Attempts = 0
+	e.Delivery.NextRetryAt = &now
+}
+
+// SetDigestFailed records the failed attempt to mail the digest including the event, the event is mailed in the next
+// digest until it is dead-lettered on reaching the max attempts
+func (e *Event) SetDigestFailed(err error, now time.Time, maxAttempts int) {
+	e.releaseLease()
+	e.Delivery.Attempts++
+	e.Delivery.LastError = err.Error()
+	e.Delivery.LastAttemptAt = &now
+	if e.Delivery.Attempts >= maxAttempts {
+		e.Delivery.State = EventDeliveryDeadLetter
+	}
+}
+
+// SetDeliverySkipped records that the event is not to be mailed
+func (e *Event) SetDeliverySkipped() {
+	e.releaseLease()
+	e.Delivery.State = EventDeliverySkipped
+	e.Delivery.NextRetryAt = nil
+}
+
+// SetDeliveryDigest records that the event is to be mailed in the digest
+func (e *Event) SetDeliveryDigest() {
+	e.releaseLease()
+	e.Delivery.State = EventDeliveryDigest
+	e.Delivery.NextRetryAt = nil
+}
+
+func (e *Event) releaseLease() {
+	e.Delivery.LeaseOwner = ""
+	e.Delivery.LeasedUntil = nil
+}
+
+// SetResource sets the resource the event is about
+func (e *Event) SetResource(kind EventResourceKind, id, name string) {
+	e.Resource = &EventResource{Kind: kind, ID: id, Name: name}
+}
+
+// SetMetadata sets the metadata of the event
+func (e *Event) SetMetadata(key, value string) {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]string)
+	}
+	e.Metadata[key] = value
+}
+
 // SetNotified sets the notified flag
 func (e *Event) SetNotified(b bool) {
 	e.Notified = b
@@ -121,35 +341,22 @@ func (e *Event) SetNotified(b bool) {
 
 func (e *Event) SetLog(level EventLogLevel, message string) {
 	e.Log = EventLog{
-		Level:   EventLogLevelINFO,
+		Level:   level,
 		Message: message,
 	}
 }
 
-var bodyTemplate = `
-{{- if .Log.Message -}}
-Hi,
-
-{{ .Log.Message }}
-
-Please visit the IBM® Power® Access Cloud portal for more details.
-
-Note: This is an auto-generated email. Please do not reply to this email.
-Generated at: {{ .CreatedAt.Format "Jan 02, 2006 15:04:05 UTC" }}
-
-Thanks,
-IBM® Power® Access Cloud Support.
-{{- end -}}
-`
+// ComposeMailSubject composes the mail subject from the template registered for the event type
+func (e *Event) ComposeMailSubject() (string, error) {
+	return eventTemplates.compose(e, templatePartSubject)
+}
 
+// ComposeMailBody composes the plain text mail body from the template registered for the event type
 func (e *Event) ComposeMailBody() (string, error) {
-	tmpl, err := template.New("pac").Parse(bodyTemplate)
-	if err != nil {
-		return "", err
-	}
-	var tpl bytes.Buffer
-	if err := tmpl.Execute(&tpl, e); err != nil {
-		return "", err
-	}
-	return tpl.String(), nil
+	return eventTemplates.compose(e, templatePartPlain)
+}
+
+// ComposeMailHTMLBody composes the HTML alternative of the mail body from the template registered for the event type
+func (e *Event) ComposeMailHTMLBody() (string, error) {
+	return eventTemplates.compose(e, templatePartHTML)
 }
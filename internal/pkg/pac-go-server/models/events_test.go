@@ -0,0 +1,169 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDeliveryFailed(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	leasedUntil := now.Add(time.Minute)
+	testcases := []struct {
+		name            string
+		attempts        int
+		wantState       EventDeliveryState
+		wantAttempts    int
+		wantNextRetryAt *time.Time
+	}{
+		{
+			name:            "first failure retried after the backoff",
+			attempts:        0,
+			wantState:       EventDeliveryRetrying,
+			wantAttempts:    1,
+			wantNextRetryAt: ptr(now.Add(time.Minute)),
+		},
+		{
+			name:            "backoff doubled with every attempt",
+			attempts:        1,
+			wantState:       EventDeliveryRetrying,
+			wantAttempts:    2,
+			wantNextRetryAt: ptr(now.Add(2 * time.Minute)),
+		},
+		{
+			name:            "backoff doubled again",
+			attempts:        2,
+			wantState:       EventDeliveryRetrying,
+			wantAttempts:    3,
+			wantNextRetryAt: ptr(now.Add(4 * time.Minute)),
+		},
+		{
+			name:         "dead-lettered at max attempts",
+			attempts:     4,
+			wantState:    EventDeliveryDeadLetter,
+			wantAttempts: 5,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			event := &Event{Delivery: EventDelivery{
+				State:       EventDeliveryRetrying,
+				Attempts:    tc.attempts,
+				LeaseOwner:  "notifier",
+				LeasedUntil: &leasedUntil,
+			}}
+			event.SetDeliveryFailed(errors.New("unavailable"), now, 5, time.Minute)
+			assert.Equal(t, tc.wantState, event.Delivery.State)
+			assert.Equal(t, tc.wantAttempts, event.Delivery.Attempts)
+			assert.Equal(t, tc.wantNextRetryAt, event.Delivery.NextRetryAt)
+			assert.Equal(t, "unavailable", event.Delivery.LastError)
+			assert.Equal(t, &now, event.Delivery.LastAttemptAt)
+			assert.Empty(t, event.Delivery.LeaseOwner)
+			assert.Nil(t, event.Delivery.LeasedUntil)
+			assert.False(t, event.Notified)
+		})
+	}
+}
+
+func TestReplayDelivery(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	lastAttemptAt := now.Add(-time.Hour)
+	testcases := []struct {
+		name     string
+		delivery EventDelivery
+	}{
+		{
+			name: "dead-lettered delivery replayed",
+			delivery: EventDelivery{
+				State:         EventDeliveryDeadLetter,
+				Attempts:      5,
+				LastError:     "unavailable",
+				LastAttemptAt: &lastAttemptAt,
+				DeliveredTo:   []string{"mail"},
+			},
+		},
+		{
+			name: "retrying delivery replayed",
+			delivery: EventDelivery{
+				State:         EventDeliveryRetrying,
+				Attempts:      2,
+				LastError:     "unavailable",
+				LastAttemptAt: &lastAttemptAt,
+				NextRetryAt:   ptr(now.Add(time.Hour)),
+				DeliveredTo:   []string{"mail"},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			event := &Event{Delivery: tc.delivery}
+			event.ReplayDelivery(now)
+			assert.Equal(t, EventDeliveryRetrying, event.Delivery.State)
+			assert.Equal(t, 0, event.Delivery.Attempts)
+			assert.Equal(t, &now, event.Delivery.NextRetryAt)
+			// the last error is kept until the replay is attempted and the backends delivered are not notified again
+			assert.Equal(t, "unavailable", event.Delivery.LastError)
+			assert.Equal(t, []string{"mail"}, event.Delivery.DeliveredTo)
+		})
+	}
+}
+
+func TestSetDelivered(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	event := &Event{Delivery: EventDelivery{
+		State:       EventDeliveryRetrying,
+		Attempts:    1,
+		NextRetryAt: &now,
+		LeaseOwner:  "notifier",
+		LeasedUntil: &now,
+	}}
+	event.SetDelivered(now)
+	assert.True(t, event.Notified)
+	assert.Equal(t, EventDeliveryDelivered, event.Delivery.State)
+	assert.Equal(t, &now, event.Delivery.LastAttemptAt)
+	assert.Nil(t, event.Delivery.NextRetryAt)
+	assert.Empty(t, event.Delivery.LeaseOwner)
+	assert.Nil(t, event.Delivery.LeasedUntil)
+}
+
+func TestSetDigestFailed(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	testcases := []struct {
+		name      string
+		attempts  int
+		wantState EventDeliveryState
+	}{
+		{
+			name:      "kept for the next digest",
+			attempts:  0,
+			wantState: EventDeliveryDigest,
+		},
+		{
+			name:      "dead-lettered at max attempts",
+			attempts:  4,
+			wantState: EventDeliveryDeadLetter,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			event := &Event{Delivery: EventDelivery{
+				State:       EventDeliveryDigest,
+				Attempts:    tc.attempts,
+				LeaseOwner:  "notifier",
+				LeasedUntil: &now,
+			}}
+			event.SetDigestFailed(errors.New("unavailable"), now, 5)
+			assert.Equal(t, tc.wantState, event.Delivery.State)
+			assert.Equal(t, tc.attempts+1, event.Delivery.Attempts)
+			assert.Equal(t, "unavailable", event.Delivery.LastError)
+			assert.Empty(t, event.Delivery.LeaseOwner)
+			assert.Nil(t, event.Delivery.LeasedUntil)
+		})
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
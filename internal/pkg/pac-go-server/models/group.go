@@ -1,10 +1,32 @@
 package models
 
-import "context"
+import (
+	"context"
+	"slices"
+	"time"
+)
 
 // ExcludeGroups is a list of groups to exclude from the list of groups
 var ExcludeGroups []string
 
+// GroupExitPolicy decides the approval of the group exit which leaves the user with the services exceeding the quota
+// of the user after the exit
+type GroupExitPolicy string
+
+const (
+	// GroupExitPolicyBlock blocks the approval until the user deletes the services exceeding the quota
+	GroupExitPolicyBlock GroupExitPolicy = "block"
+	// GroupExitPolicyExpire approves the exit and schedules the services exceeding the quota for expiry
+	GroupExitPolicyExpire GroupExitPolicy = "expire"
+)
+
+var (
+	// GroupExitOverQuotaPolicy is the policy applied to the group exits leaving the services of the user over the quota
+	GroupExitOverQuotaPolicy = GroupExitPolicyBlock
+	// GroupExitExpiryNotice is the notice given to the user before the services exceeding the quota are expired
+	GroupExitExpiryNotice time.Duration
+)
+
 type Group struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
@@ -22,3 +44,20 @@ func IsMemberOfGroup(ctx context.Context, name string) bool {
 	}
 	return false
 }
+
+// GroupOwnership delegates the administration of a group to its owners, the owners can decide the requests targeting
+// the group and manage the quota of the group within the limit set by the platform admin
+type GroupOwnership struct {
+	GroupID string `json:"group_id" bson:"group_id"`
+	// Owners are the IDs of the users administering the group
+	Owners []string `json:"owners" bson:"owners"`
+	// QuotaLimit is the maximum capacity the owners can set on the group, the owners cannot manage the quota if not set
+	QuotaLimit *Capacity `json:"quota_limit,omitempty" bson:"quota_limit,omitempty"`
+	UpdatedBy  string    `json:"updated_by" bson:"updated_by"`
+	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// IsOwner returns true if the user is an owner of the group
+func (o *GroupOwnership) IsOwner(userID string) bool {
+	return o != nil && slices.Contains(o.Owners, userID)
+}
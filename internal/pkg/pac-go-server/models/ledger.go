@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuotaLedger tracks the capacity reserved by the services of a user, or by the services of the members of a pooled
+// group, it is updated atomically to avoid overshooting the quota when services are created in parallel.
+type QuotaLedger struct {
+	ID primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	// UserID is the user who owns the reservations
+	UserID string `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	// GroupID is the pooled group whose members own the reservations
+	GroupID string `json:"group_id,omitempty" bson:"group_id,omitempty"`
+	// CPU is the total CPU reserved by the user
+	CPU float64 `json:"cpu" bson:"cpu"`
+	// Memory is the total memory reserved by the user
+	Memory int `json:"memory" bson:"memory"`
+	// Reservations is the list of capacity reserved per service
+	Reservations []QuotaReservation `json:"reservations" bson:"reservations"`
+}
+
+type QuotaReservation struct {
+	// ServiceName is the service for which the capacity is reserved
+	ServiceName string `json:"service_name" bson:"service_name"`
+	// Capacity is the capacity reserved for the service
+	Capacity Capacity `json:"capacity" bson:"capacity"`
+	// ReservedAt is the time the capacity was reserved
+	ReservedAt time.Time `json:"reserved_at" bson:"reserved_at"`
+}
+
+// GetReservation returns the reservation made for the service
+func (l *QuotaLedger) GetReservation(serviceName string) *QuotaReservation {
+	for i := range l.Reservations {
+		if l.Reservations[i].ServiceName == serviceName {
+			return &l.Reservations[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationChannel is how the user is notified of the events of a type
+type NotificationChannel string
+
+const (
+	// NotificationChannelEmail mails the events right away, the default for all the event types
+	NotificationChannelEmail NotificationChannel = "EMAIL"
+	// NotificationChannelInApp only lists the events in the portal
+	NotificationChannelInApp NotificationChannel = "IN_APP"
+	// NotificationChannelDigest mails the events together in a digest
+	NotificationChannelDigest NotificationChannel = "DIGEST"
+	// NotificationChannelMuted doesn't notify the events at all
+	NotificationChannelMuted NotificationChannel = "MUTED"
+)
+
+// NotificationChannels are the valid notification channels
+var NotificationChannels = []NotificationChannel{NotificationChannelEmail, NotificationChannelInApp, NotificationChannelDigest,
+	NotificationChannelMuted}
+
+// MandatoryEventTypes are always mailed right away, the users can't choose another channel for them
+var MandatoryEventTypes = []EventType{
+	EventServiceExpiryNotification,
+	EventServiceExpiredNotification,
+	EventServiceExpiryScheduled,
+	EventGroupExitBlocked,
+	EventServiceDeleteFailed,
+}
+
+// NotificationPreferences are the channels the user chose to be notified of the events through, per event type
+type NotificationPreferences struct {
+	// UserID is the user the preferences are of
+	UserID string `json:"-" bson:"user_id"`
+	// Channels are the channels chosen per event type, the event types not set are mailed
+	Channels map[EventType]NotificationChannel `json:"channels" bson:"channels"`
+	// UpdatedAt is the time the preferences were last updated at
+	UpdatedAt *time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// NotificationPreferencesResponse are the channels the events of every type are notified through for the user
+type NotificationPreferencesResponse struct {
+	Channels map[EventType]NotificationChannel `json:"channels"`
+	// Mandatory are the event types the channel can't be chosen for
+	Mandatory []EventType `json:"mandatory"`
+	UpdatedAt *time.Time  `json:"updated_at,omitempty"`
+}
+
+// IsMandatoryEventType returns true if the events of the type are always mailed
+func IsMandatoryEventType(eventType EventType) bool {
+	for _, mandatory := range MandatoryEventTypes {
+		if eventType == mandatory {
+			return true
+		}
+	}
+	return false
+}
+
+// GetChannel returns the channel the events of the type are notified through, the preferences can be nil
+func (p *NotificationPreferences) GetChannel(eventType EventType) NotificationChannel {
+	if p == nil || IsMandatoryEventType(eventType) {
+		return NotificationChannelEmail
+	}
+	if channel, ok := p.Channels[eventType]; ok {
+		return channel
+	}
+	return NotificationChannelEmail
+}
@@ -1,9 +1,75 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// QuotaThresholds is the list of quota utilization percentages, crossing which the users are alerted
+var QuotaThresholds []int
+
+type QuotaType string
+
+const (
+	// QuotaTypePerUser applies the group quota to every member of the group individually
+	QuotaTypePerUser QuotaType = "PER_USER"
+	// QuotaTypePooled shares the group quota across all the members of the group
+	QuotaTypePooled QuotaType = "POOLED"
+)
 
 type Quota struct {
 	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	GroupID  string             `json:"group_id" bson:"group_id"`
 	Capacity Capacity           `json:"capacity" bson:"capacity"`
+	// Type is the mode of the quota, quota without type is treated as PER_USER
+	Type QuotaType `json:"type,omitempty" bson:"type,omitempty"`
+	// MemberCapacity is the maximum capacity a single member can consume out of a POOLED quota
+	MemberCapacity *Capacity `json:"member_capacity,omitempty" bson:"member_capacity,omitempty"`
+	// Used is the capacity consumed by all the members of the group, only set for POOLED quota
+	Used *Capacity `json:"used,omitempty" bson:"-"`
+	// Available is the capacity left in the pool, only set for POOLED quota
+	Available *Capacity `json:"available,omitempty" bson:"-"`
+}
+
+// UserQuota is the capacity granted to a user on top of the group quota for a limited time
+type UserQuota struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID   string             `json:"user_id" bson:"user_id"`
+	Capacity Capacity           `json:"capacity" bson:"capacity"`
+	// RequestID is the quota increase request the quota is granted for
+	RequestID string    `json:"request_id" bson:"request_id"`
+	StartsAt  time.Time `json:"starts_at" bson:"starts_at"`
+	// ExpiresAt is the time the quota reverts
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+// IsPooled returns true if the quota is shared across all the members of the group
+func (q *Quota) IsPooled() bool {
+	return q.Type == QuotaTypePooled
+}
+
+type QuotaAlertSubject string
+
+const (
+	// QuotaAlertUser alerts on the utilization of the quota of a user
+	QuotaAlertUser QuotaAlertSubject = "USER"
+	// QuotaAlertGroup alerts on the utilization of a POOLED group quota
+	QuotaAlertGroup QuotaAlertSubject = "GROUP"
+)
+
+// QuotaAlert tracks the quota threshold reached by a user or a pooled group, so that an alert is raised only once per
+// crossing of a threshold.
+type QuotaAlert struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	SubjectType QuotaAlertSubject  `json:"subject_type" bson:"subject_type"`
+	// SubjectID is the ID of the user or the group
+	SubjectID string `json:"subject_id" bson:"subject_id"`
+	// GroupIDs are the groups of the user as seen on the last evaluation
+	GroupIDs []string `json:"group_ids,omitempty" bson:"group_ids,omitempty"`
+	// MemberIDs are the members of the group as seen on the last evaluation
+	MemberIDs []string `json:"member_ids,omitempty" bson:"member_ids,omitempty"`
+	// Threshold is the highest threshold reached on the last evaluation, 0 if none
+	Threshold   int       `json:"threshold" bson:"threshold"`
+	EvaluatedAt time.Time `json:"evaluated_at" bson:"evaluated_at"`
 }
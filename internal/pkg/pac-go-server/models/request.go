@@ -17,7 +17,20 @@ const (
 	RequestExitFromGroup       RequestType      = "GROUP_EXIT"
 	RequestExtendServiceExpiry RequestType      = "SERVICE_EXPIRY"
 	RequestStateExpired        RequestStateType = "EXPIRED"
+	RequestStateDeleted        RequestStateType = "DELETED"
 	RequestDeleteUser          RequestType      = "USER_DELETE"
+	RequestQuotaIncrease       RequestType      = "QUOTA_INCREASE"
+
+	// RequestActorSystem is the actor of the state transitions made by the server itself, e.g. expiry of the requests
+	RequestActorSystem = "system"
+)
+
+var (
+	// StaleRequestAges is the age after which the pending requests of a type are expired, the requests of the types
+	// not set are never expired
+	StaleRequestAges map[RequestType]time.Duration
+	// StaleRequestReminder is the duration before the expiry of a pending request the approvers are reminded at
+	StaleRequestReminder time.Duration
 )
 
 type Request struct {
@@ -30,6 +43,100 @@ type Request struct {
 	RequestType    RequestType        `json:"type" bson:"type,omitempty"`
 	GroupAdmission *GroupAdmission    `json:"group,omitempty" bson:"group,omitempty"`
 	ServiceExpiry  *ServiceExpiry     `json:"service,omitempty" bson:"service,omitempty"`
+	QuotaIncrease  *QuotaIncrease     `json:"quota,omitempty" bson:"quota,omitempty"`
+	// Approval is the progress of the approval chain, set only for the requests requiring multiple approvals
+	Approval *Approval `json:"approval,omitempty" bson:"approval,omitempty"`
+	// Decision is set if the request is auto-approved or auto-rejected by a rule
+	Decision *RuleDecision `json:"decision,omitempty" bson:"decision,omitempty"`
+	// RemindedAt is the time the approvers were reminded of the pending request about to expire
+	RemindedAt *time.Time `json:"reminded_at,omitempty" bson:"reminded_at,omitempty"`
+	// History is the append-only record of the state transitions of the request
+	History []RequestTransition `json:"history,omitempty" bson:"history,omitempty"`
+}
+
+// RequestFilter is the criteria the requests are listed by, the criteria not set are ignored
+type RequestFilter struct {
+	// UserID limits the requests to the ones of the user, and of the groups owned by the user if OwnedGroupIDs is set
+	UserID string
+	// OwnedGroupIDs lists along with the requests of the user, the requests to join or exit any of the groups
+	OwnedGroupIDs []string
+	RequesterID   string
+	Type          RequestType
+	State         RequestStateType
+	GroupID       string
+	ServiceName   string
+	// CreatedAfter and CreatedBefore bound the time the requests were created at
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	// SortBy is the field the requests are sorted by, the requests are sorted in the ascending order unless Descending
+	SortBy     string
+	Descending bool
+	StartIndex int64
+	PerPage    int64
+}
+
+type RequestResponse struct {
+	// TotalPages is the total number of pages
+	TotalPages int64 `json:"total_pages"`
+	// TotalItems is the total number of items
+	TotalItems int64 `json:"total_items"`
+	// Requests is the list of requests
+	Requests []Request `json:"requests"`
+	// Links contains the links for the current page, next page and last page
+	Links Links `json:"links"`
+}
+
+type BulkAction string
+
+const (
+	BulkActionApprove BulkAction = "APPROVE"
+	BulkActionReject  BulkAction = "REJECT"
+)
+
+// RequestTransition records a change of the state of the request
+type RequestTransition struct {
+	From RequestStateType `json:"from" bson:"from"`
+	To   RequestStateType `json:"to" bson:"to"`
+	// Actor is the ID of the user who changed the state, RequestActorSystem if changed by the server
+	Actor     string    `json:"actor" bson:"actor"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+	Comment   string    `json:"comment,omitempty" bson:"comment,omitempty"`
+}
+
+// NewRequestTransition returns the transition of the request from its current state to the state
+func NewRequestTransition(request *Request, to RequestStateType, actor, comment string) RequestTransition {
+	return RequestTransition{
+		From:      request.State,
+		To:        to,
+		Actor:     actor,
+		Timestamp: time.Now(),
+		Comment:   comment,
+	}
+}
+
+// BulkDecision approves or rejects the requests in bulk
+type BulkDecision struct {
+	IDs    []string   `json:"ids"`
+	Action BulkAction `json:"action"`
+	// Comment is required to reject the requests
+	Comment string `json:"comment"`
+}
+
+// BulkDecisionResult is the outcome of the bulk decision for a request
+type BulkDecisionResult struct {
+	ID string `json:"id"`
+	// Status is the HTTP status the request would have been decided with on its own
+	Status int              `json:"status"`
+	State  RequestStateType `json:"state,omitempty"`
+	// Approval is set if the request is pending the approval of the later stages of its approval chain
+	Approval *Approval `json:"approval,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type BulkDecisionResponse struct {
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []BulkDecisionResult `json:"results"`
 }
 
 type ServiceExpiry struct {
@@ -37,6 +144,13 @@ type ServiceExpiry struct {
 	Expiry time.Time `json:"expiry" bson:"expiry,omitempty"`
 }
 
+type QuotaIncrease struct {
+	// Capacity is the CPU, memory, services and core-hours requested on top of the group quota
+	Capacity Capacity `json:"capacity" bson:"capacity"`
+	// Duration is the number of days the increased quota is required for, up to 90 days
+	Duration int `json:"duration" bson:"duration"`
+}
+
 type GroupAdmission struct {
 	GroupID   string `json:"group_id" bson:"group_id,omitempty"`
 	Group     string `json:"group" bson:"group,omitempty"`
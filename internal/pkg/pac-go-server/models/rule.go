@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RuleAction string
+
+const (
+	RuleActionApprove RuleAction = "APPROVE"
+	RuleActionReject  RuleAction = "REJECT"
+)
+
+// Rule auto-approves or auto-rejects the requests matching all the conditions of the rule
+type Rule struct {
+	ID   primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name string             `json:"name" bson:"name"`
+	// Priority orders the evaluation of the rules, the first matching rule with the lowest priority decides the request
+	Priority int        `json:"priority" bson:"priority"`
+	Enabled  bool       `json:"enabled" bson:"enabled"`
+	Action   RuleAction `json:"action" bson:"action"`
+	// Reason is recorded on the request decided by the rule
+	Reason    string    `json:"reason" bson:"reason"`
+	Match     RuleMatch `json:"match" bson:"match"`
+	CreatedBy string    `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// RuleMatch is the set of conditions of a rule, the conditions not set are ignored
+type RuleMatch struct {
+	RequestType RequestType `json:"request_type" bson:"request_type"`
+	// Groups are the names of the groups requested to join
+	Groups []string `json:"groups,omitempty" bson:"groups,omitempty"`
+	// EmailDomains are the domains of the email of the requester, e.g. example.com
+	EmailDomains []string `json:"email_domains,omitempty" bson:"email_domains,omitempty"`
+	// MemberOf are the names of the groups, the requester is a member of any of
+	MemberOf []string `json:"member_of,omitempty" bson:"member_of,omitempty"`
+	// MaxExtensionPercent matches the expiry requested up to the percentage of the catalog expiry, counted from the
+	// time of the request
+	MaxExtensionPercent float64 `json:"max_extension_percent,omitempty" bson:"max_extension_percent,omitempty"`
+	// MinExtensionPercent matches the expiry requested beyond the percentage of the catalog expiry, counted from the
+	// time of the request
+	MinExtensionPercent float64 `json:"min_extension_percent,omitempty" bson:"min_extension_percent,omitempty"`
+}
+
+// RuleDecision is the decision taken on a request by a rule
+type RuleDecision struct {
+	RuleID    string     `json:"rule_id" bson:"rule_id"`
+	Action    RuleAction `json:"action" bson:"action"`
+	Reason    string     `json:"reason" bson:"reason"`
+	DecidedAt time.Time  `json:"decided_at" bson:"decided_at"`
+}
+
+// RuleInput are the facts about a request the rules are evaluated against
+type RuleInput struct {
+	Request *Request
+	Email   string
+	// Groups are the names of the groups the requester is a member of
+	Groups []string
+	// ExtensionDays is the number of days between the request and the requested expiry
+	ExtensionDays float64
+	// CatalogExpiry is the expiry of the catalog of the service in days, 0 if not known
+	CatalogExpiry int
+}
+
+// RuleDryRunResult is the decision a rule would have taken on a historical request
+type RuleDryRunResult struct {
+	RequestID string           `json:"request_id"`
+	UserID    string           `json:"user_id"`
+	State     RequestStateType `json:"state"`
+	Matched   bool             `json:"matched"`
+	// Differs is set if the rule would have decided the request differently than it was decided
+	Differs bool `json:"differs"`
+	// Error is set if the facts of the request could not be collected
+	Error string `json:"error,omitempty"`
+}
+
+// RuleDryRun is the outcome of evaluating a rule against the historical requests
+type RuleDryRun struct {
+	Requests int                `json:"requests"`
+	Matched  int                `json:"matched"`
+	Differs  int                `json:"differs"`
+	Results  []RuleDryRunResult `json:"results"`
+}
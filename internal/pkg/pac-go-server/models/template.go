@@ -0,0 +1,312 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// templatePart is a part of the mail composed from a template, the value is the extension of the template file
+// overriding it
+type templatePart string
+
+const (
+	templatePartSubject templatePart = "subject"
+	templatePartPlain   templatePart = "txt"
+	templatePartHTML    templatePart = "html"
+
+	// defaultTemplateKey is the key of the templates used for the event types without their own
+	defaultTemplateKey EventType = "default"
+	// digestTemplateKey is the key of the templates of the daily digest of the events, executed with EventDigest
+	digestTemplateKey EventType = "digest"
+)
+
+var templateParts = []templatePart{templatePartSubject, templatePartPlain, templatePartHTML}
+
+// eventTitles are the human readable titles of the event types used in the mail subjects
+var eventTitles = map[EventType]string{
+	EventGroupJoinRequest:             "Group join request",
+	EventServiceExpiryRequest:         "Service expiry extension request",
+	EventGroupExitRequest:             "Group exit request",
+	EventServiceExpiryNotification:    "Service expiring soon",
+	EventServiceExpiredNotification:   "Service expired",
+	EventDeletUserRequest:             "User deletion request",
+	EventQuotaIncreaseRequest:         "Quota increase request",
+	EventTypeRequestApproved:          "Request approved",
+	EventTypeRequestPartiallyApproved: "Request partially approved",
+	EventTypeRequestRejected:          "Request rejected",
+	EventTypeRequestDeleted:           "Request deleted",
+	EventTypeRequestExpired:           "Request expired",
+	EventRequestExpiryReminder:        "Request pending decision",
+	EventTypeRequestComment:           "New comment on request",
+	EventCatalogCreate:                "Catalog created",
+	EventCatalogUpdate:                "Catalog updated",
+	EventCatalogDelete:                "Catalog deleted",
+	EventCatalogRetire:                "Catalog retired",
+	EventServiceCreate:                "Service created",
+	EventServiceUpdate:                "Service updated",
+	EventServiceDelete:                "Service deleted",
+	EventServiceDeleteFailed:          "Service deletion failed",
+	EventQuotaThreshold:               "Quota usage alert",
+	EventGroupExitBlocked:             "Group exit blocked",
+	EventServiceExpiryScheduled:       "Service expiry scheduled",
+}
+
+const plainTemplateFooter = `
+Please visit the IBM® Power® Access Cloud portal for more details.
+
+Note: This is an auto-generated email. Please do not reply to this email.
+Generated at: {{ .CreatedAt.Format "Jan 02, 2006 15:04:05 UTC" }}
+
+Thanks,
+IBM® Power® Access Cloud Support.`
+
+const htmlTemplateFooter = `
+<p>Please visit the IBM® Power® Access Cloud portal for more details.</p>
+<p><small>Note: This is an auto-generated email. Please do not reply to this email.<br>
+Generated at: {{ .CreatedAt.Format "Jan 02, 2006 15:04:05 UTC" }}</small></p>
+<p>Thanks,<br>
+IBM® Power® Access Cloud Support.</p>
+</body>
+</html>`
+
+// logMessage is the message of the event log, or the title and the service of the event for the events logged without
+// a message
+const logMessage = `{{ if .Log.Message }}{{ .Log.Message }}{{ else }}{{ .Title }}{{ if .ServiceName }}: {{ .ServiceName }}{{ end }}{{ end }}`
+
+// plainTemplate returns the plain text body template with the message
+func plainTemplate(message string) string {
+	return "Hi,\n\n" + message + "\n" + plainTemplateFooter
+}
+
+// htmlTemplate returns the HTML body template with the message
+func htmlTemplate(message string) string {
+	return "<html>\n<body>\n<p>Hi,</p>\n<p>" + message + "</p>\n" + htmlTemplateFooter
+}
+
+// defaultEventTemplates are the built-in templates per event type, the parts not set are composed from the default
+// templates
+var defaultEventTemplates = map[EventType]map[templatePart]string{
+	digestTemplateKey: {
+		templatePartSubject: "IBM® Power® Access Cloud - Daily digest",
+		templatePartPlain:   digestBodyTemplate,
+		templatePartHTML:    digestHTMLBodyTemplate,
+	},
+	defaultTemplateKey: {
+		templatePartSubject: "IBM® Power® Access Cloud - {{ .Title }}",
+		templatePartPlain:   plainTemplate(logMessage),
+		templatePartHTML:    htmlTemplate(logMessage),
+	},
+	EventServiceExpiryNotification: {
+		templatePartSubject: "IBM® Power® Access Cloud - {{ if .ServiceName }}Service {{ .ServiceName }} expiring soon{{ else }}{{ .Title }}{{ end }}",
+		templatePartPlain: plainTemplate(`{{ if .ServiceName }}Your service {{ .ServiceName }} is expiring on {{ .Expiry.Format "Jan 02, 2006 15:04:05 UTC" }}. ` +
+			`It will be deleted post-expiry, if not extended.{{ else }}` + logMessage + `{{ end }}`),
+		templatePartHTML: htmlTemplate(`{{ if .ServiceName }}Your service <b>{{ .ServiceName }}</b> is expiring on {{ .Expiry.Format "Jan 02, 2006 15:04:05 UTC" }}. ` +
+			`It will be deleted post-expiry, if not extended.{{ else }}` + logMessage + `{{ end }}`),
+	},
+	EventServiceExpiredNotification: {
+		templatePartSubject: "IBM® Power® Access Cloud - {{ if .ServiceName }}Service {{ .ServiceName }} expired{{ else }}{{ .Title }}{{ end }}",
+		templatePartPlain:   plainTemplate(`{{ if .ServiceName }}Your service {{ .ServiceName }} is expired. It is going to be deleted.{{ else }}` + logMessage + `{{ end }}`),
+		templatePartHTML:    htmlTemplate(`{{ if .ServiceName }}Your service <b>{{ .ServiceName }}</b> is expired. It is going to be deleted.{{ else }}` + logMessage + `{{ end }}`),
+	},
+	EventTypeRequestApproved: {
+		templatePartPlain: plainTemplate(logMessage + `{{ if .RequestID }}
+
+Request ID: {{ .RequestID }}{{ end }}`),
+		templatePartHTML: htmlTemplate(logMessage + `{{ if .RequestID }}<br>
+Request ID: <code>{{ .RequestID }}</code>{{ end }}`),
+	},
+	EventTypeRequestRejected: {
+		templatePartPlain: plainTemplate(logMessage + `{{ if .RequestID }}
+
+Request ID: {{ .RequestID }}{{ end }}`),
+		templatePartHTML: htmlTemplate(logMessage + `{{ if .RequestID }}<br>
+Request ID: <code>{{ .RequestID }}</code>{{ end }}`),
+	},
+}
+
+// EventTemplateData is the data the event templates are executed with, the structured fields are read from the
+// metadata of the event and are empty if not set
+type EventTemplateData struct {
+	Event
+	// Title is the human readable title of the event type
+	Title       string
+	ServiceName string
+	Expiry      time.Time
+	RequestID   string
+}
+
+func newEventTemplateData(e *Event) EventTemplateData {
+	data := EventTemplateData{
+		Event:       *e,
+		Title:       string(e.Type),
+		ServiceName: e.Metadata[EventMetadataServiceName],
+		RequestID:   e.Metadata[EventMetadataRequestID],
+	}
+	if title, ok := eventTitles[e.Type]; ok {
+		data.Title = title
+	}
+	if expiry, err := time.Parse(time.RFC3339, e.Metadata[EventMetadataExpiry]); err == nil {
+		data.Expiry = expiry
+	}
+	return data
+}
+
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// templateRegistry holds the parsed templates keyed by the event type and the part
+type templateRegistry map[EventType]map[templatePart]executor
+
+// eventTemplates are the templates the events are composed from, the built-in ones unless overridden by
+// LoadEventTemplates
+var eventTemplates = mustNewTemplateRegistry(defaultEventTemplates)
+
+func mustNewTemplateRegistry(templates map[EventType]map[templatePart]string) templateRegistry {
+	registry := make(templateRegistry)
+	for eventType, parts := range templates {
+		for part, text := range parts {
+			if err := registry.add(eventType, part, text); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return registry
+}
+
+// add parses and registers the template of the part, the HTML templates escape the data
+func (r templateRegistry) add(eventType EventType, part templatePart, text string) error {
+	name := fmt.Sprintf("%s.%s", eventType, part)
+	var tmpl executor
+	var err error
+	if part == templatePartHTML {
+		tmpl, err = htmltemplate.New(name).Parse(text)
+	} else {
+		tmpl, err = texttemplate.New(name).Parse(text)
+	}
+	if err != nil {
+		return fmt.Errorf("error parsing template %s: %w", name, err)
+	}
+	if r[eventType] == nil {
+		r[eventType] = make(map[templatePart]executor)
+	}
+	r[eventType][part] = tmpl
+	return nil
+}
+
+// compose executes the template of the part registered for the type of the event, or the default one
+func (r templateRegistry) compose(e *Event, part templatePart) (string, error) {
+	key := e.Type
+	if _, ok := r[key][part]; !ok {
+		key = defaultTemplateKey
+	}
+	return r.execute(key, part, newEventTemplateData(e))
+}
+
+// execute executes the template of the part registered for the key with the data
+func (r templateRegistry) execute(key EventType, part templatePart, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := r[key][part].Execute(&buf, data); err != nil {
+		return "", err
+	}
+	if part == templatePartSubject {
+		return strings.TrimSpace(buf.String()), nil
+	}
+	return buf.String(), nil
+}
+
+// dryRun executes the template of the part registered for the key with a sample event, so that the templates failing on
+// execution, e.g. referring to an unknown field, are caught on load rather than on mailing the events
+func (r templateRegistry) dryRun(key EventType, part templatePart) error {
+	now := time.Now().UTC()
+	sample := Event{
+		Type:      key,
+		CreatedAt: now,
+		Log:       EventLog{Level: EventLogLevelINFO, Message: "Sample message"},
+		Metadata: map[string]string{
+			EventMetadataServiceName: "sample-service",
+			EventMetadataExpiry:      now.Format(time.RFC3339),
+			EventMetadataRequestID:   "sample-request",
+		},
+	}
+	if key == defaultTemplateKey || key == digestTemplateKey {
+		sample.Type = EventTypeRequestApproved
+	}
+	var data interface{} = newEventTemplateData(&sample)
+	if key == digestTemplateKey {
+		data = NewEventDigest("sample-user", "sample-user@example.com", []Event{sample}, now)
+	}
+	if _, err := r.execute(key, part, data); err != nil {
+		return fmt.Errorf("error executing template %s.%s: %w", key, part, err)
+	}
+	return nil
+}
+
+// LoadEventTemplates overrides the built-in templates with the ones in the directory, e.g. a mounted ConfigMap. The
+// files are named <EVENT_TYPE>.<part> where the part is one of subject, txt and html, the templates for all the event
+// types without their own are named default.<part>. The templates are executed with EventTemplateData, but for the
+// ones of the daily digest named digest.<part> executed with EventDigest. Every template is executed once with a
+// sample on load, an error is returned if it fails.
+func LoadEventTemplates(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading templates directory: %w", err)
+	}
+	registry := mustNewTemplateRegistry(defaultEventTemplates)
+	for _, entry := range entries {
+		// the ConfigMap volumes have the hidden directories the files are linked to
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("error reading template %s: %w", entry.Name(), err)
+		}
+		if info.IsDir() {
+			continue
+		}
+		eventType, part, err := parseTemplateFileName(entry.Name())
+		if err != nil {
+			return err
+		}
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading template %s: %w", entry.Name(), err)
+		}
+		if err := registry.add(eventType, part, string(text)); err != nil {
+			return err
+		}
+		if err := registry.dryRun(eventType, part); err != nil {
+			return err
+		}
+	}
+	eventTemplates = registry
+	return nil
+}
+
+func parseTemplateFileName(name string) (EventType, templatePart, error) {
+	ext := filepath.Ext(name)
+	eventType := EventType(strings.TrimSuffix(name, ext))
+	part := templatePart(strings.TrimPrefix(ext, "."))
+	validPart := false
+	for _, p := range templateParts {
+		if p == part {
+			validPart = true
+		}
+	}
+	if !validPart {
+		return "", "", fmt.Errorf("invalid template %s, the extension must be one of %v", name, templateParts)
+	}
+	if eventType != defaultTemplateKey && eventType != digestTemplateKey && !IsValidEventType(eventType) {
+		return "", "", fmt.Errorf("invalid template %s, unknown event type %s", name, eventType)
+	}
+	return eventType, part, nil
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeMail(t *testing.T) {
+	event := &Event{
+		Type:      EventServiceExpiryNotification,
+		CreatedAt: time.Now(),
+		Log:       EventLog{Level: EventLogLevelINFO, Message: "Service test-service is expiring"},
+		Metadata: map[string]string{
+			EventMetadataServiceName: "test-service",
+			EventMetadataExpiry:      "2026-01-02T15:04:05Z",
+		},
+	}
+	subject, err := event.ComposeMailSubject()
+	assert.NoError(t, err)
+	assert.Equal(t, "IBM® Power® Access Cloud - Service test-service expiring soon", subject)
+	body, err := event.ComposeMailBody()
+	assert.NoError(t, err)
+	assert.Contains(t, body, "Your service test-service is expiring on Jan 02, 2026 15:04:05 UTC.")
+	htmlBody, err := event.ComposeMailHTMLBody()
+	assert.NoError(t, err)
+	assert.Contains(t, htmlBody, "<b>test-service</b>")
+
+	// the events without the structured fields fall back to the message
+	event.Metadata = nil
+	body, err = event.ComposeMailBody()
+	assert.NoError(t, err)
+	assert.Contains(t, body, "Service test-service is expiring")
+
+	event.Type = EventTypeRequestComment
+	subject, err = event.ComposeMailSubject()
+	assert.NoError(t, err)
+	assert.Equal(t, "IBM® Power® Access Cloud - New comment on request", subject)
+
+	// the events logged without a message fall back to the title and the service
+	event.Log.Message = ""
+	event.Metadata = map[string]string{EventMetadataServiceName: "test-service"}
+	body, err = event.ComposeMailBody()
+	assert.NoError(t, err)
+	assert.Contains(t, body, "Hi,\n\nNew comment on request: test-service\n")
+	htmlBody, err = event.ComposeMailHTMLBody()
+	assert.NoError(t, err)
+	assert.Contains(t, htmlBody, "<p>New comment on request: test-service</p>")
+}
+
+func TestComposeDigest(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	digest := NewEventDigest("12345", "user@example.com", []Event{
+		{Type: EventTypeRequestApproved, CreatedAt: now, Log: EventLog{Message: "Request <b>approved</b> & done"}},
+		{Type: EventGroupJoinRequest, CreatedAt: now, Log: EventLog{Message: "Join request raised"}},
+	}, now)
+	subject, err := digest.ComposeMailSubject()
+	assert.NoError(t, err)
+	assert.Equal(t, "IBM® Power® Access Cloud - Daily digest", subject)
+	// the plain text body is not escaped, unlike the HTML one
+	body, err := digest.ComposeMailBody()
+	assert.NoError(t, err)
+	assert.Contains(t, body, "Jan 02, 2026 15:04:05 UTC: Request <b>approved</b> & done")
+	assert.Contains(t, body, "REQUEST_APPROVED (1)")
+	htmlBody, err := digest.ComposeMailHTMLBody()
+	assert.NoError(t, err)
+	assert.Contains(t, htmlBody, "Request &lt;b&gt;approved&lt;/b&gt; &amp; done")
+}
+
+func TestLoadEventTemplates(t *testing.T) {
+	defer func() { eventTemplates = mustNewTemplateRegistry(defaultEventTemplates) }()
+
+	testcases := []struct {
+		name    string
+		files   map[string]string
+		wantErr bool
+	}{
+		{
+			name: "templates overridden",
+			files: map[string]string{
+				"default.subject":          "PAC: {{ .Title }}",
+				"REQUEST_APPROVED.txt":     "Approved {{ .RequestID }}",
+				"REQUEST_APPROVED.html":    "<p>Approved {{ .RequestID }}</p>",
+				"digest.txt":               "{{ len .Groups }} notifications for {{ .UserEmail }}",
+				".hidden/REQUEST_APPROVED": "ignored",
+			},
+		},
+		{
+			name:    "unknown event type",
+			files:   map[string]string{"UNKNOWN.txt": "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid part",
+			files:   map[string]string{"REQUEST_APPROVED.md": "approved"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid template",
+			files:   map[string]string{"REQUEST_APPROVED.txt": "{{ .RequestID "},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			files:   map[string]string{"REQUEST_APPROVED.txt": "Approved {{ .Request.ID }}"},
+			wantErr: true,
+		},
+		{
+			name:    "event field in the digest",
+			files:   map[string]string{"digest.subject": "{{ .Title }}"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := LoadEventTemplates(dir)
+			assert.Equal(t, tc.wantErr, err != nil)
+			if tc.wantErr {
+				return
+			}
+			event := &Event{
+				Type:      EventTypeRequestApproved,
+				CreatedAt: time.Now(),
+				Log:       EventLog{Level: EventLogLevelINFO, Message: "Request has been approved"},
+				Metadata:  map[string]string{EventMetadataRequestID: "<id>"},
+			}
+			subject, err := event.ComposeMailSubject()
+			assert.NoError(t, err)
+			assert.Equal(t, "PAC: Request approved", subject)
+			body, err := event.ComposeMailBody()
+			assert.NoError(t, err)
+			assert.Equal(t, "Approved <id>", body)
+			htmlBody, err := event.ComposeMailHTMLBody()
+			assert.NoError(t, err)
+			assert.Equal(t, "<p>Approved &lt;id&gt;</p>", htmlBody)
+
+			digest := NewEventDigest("12345", "<user>@example.com", []Event{*event}, time.Now())
+			body, err = digest.ComposeMailBody()
+			assert.NoError(t, err)
+			assert.Equal(t, "1 notifications for <user>@example.com", body)
+			subject, err = digest.ComposeMailSubject()
+			assert.NoError(t, err)
+			assert.Equal(t, "IBM® Power® Access Cloud - Daily digest", subject)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ServiceUsage records the lifetime of a service provisioned by the user, used to compute the core-hours consumed.
+type ServiceUsage struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      string             `json:"user_id" bson:"user_id"`
+	ServiceName string             `json:"service_name" bson:"service_name"`
+	CatalogName string             `json:"catalog_name" bson:"catalog_name"`
+	CPU         float64            `json:"cpu" bson:"cpu"`
+	Memory      int                `json:"memory" bson:"memory"`
+	StartedAt   time.Time          `json:"started_at" bson:"started_at"`
+	// ExpiresAt is the expiry of the service, the service stops consuming resources once expired
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+	// EndedAt is set when the service is deleted before its expiry
+	EndedAt *time.Time `json:"ended_at,omitempty" bson:"ended_at"`
+}
+
+// CoreHours returns the core-hours consumed by the service between start and end
+func (u *ServiceUsage) CoreHours(start, end time.Time) float64 {
+	return u.CPU * u.runningHours(start, end)
+}
+
+// MemoryHours returns the memory-hours consumed by the service between start and end
+func (u *ServiceUsage) MemoryHours(start, end time.Time) float64 {
+	return float64(u.Memory) * u.runningHours(start, end)
+}
+
+// runningHours returns the number of hours the service was running between start and end
+func (u *ServiceUsage) runningHours(start, end time.Time) float64 {
+	stoppedAt := u.ExpiresAt
+	if u.EndedAt != nil && u.EndedAt.Before(stoppedAt) {
+		stoppedAt = *u.EndedAt
+	}
+	if u.StartedAt.After(start) {
+		start = u.StartedAt
+	}
+	if stoppedAt.Before(end) {
+		end = stoppedAt
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Hours()
+}
+
+// UsageTransition is a lifecycle transition of a service, the capacity of a service is fixed by its catalog hence
+// services are never resized
+type UsageTransition string
+
+const (
+	UsageTransitionCreated UsageTransition = "CREATED"
+	UsageTransitionExpired UsageTransition = "EXPIRED"
+	UsageTransitionDeleted UsageTransition = "DELETED"
+)
+
+// UsageRecord is an entry in the usage history, recorded for every lifecycle transition of a service
+type UsageRecord struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      string             `json:"user_id" bson:"user_id"`
+	ServiceName string             `json:"service_name" bson:"service_name"`
+	CatalogName string             `json:"catalog_name" bson:"catalog_name"`
+	Transition  UsageTransition    `json:"transition" bson:"transition"`
+	// Capacity is the capacity of the catalog the service is provisioned from
+	Capacity  Capacity  `json:"capacity" bson:"capacity"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+}
+
+// UsageSummary is the usage aggregated over a period
+type UsageSummary struct {
+	// Period is the start of the aggregated period
+	Period      time.Time `json:"period"`
+	CoreHours   float64   `json:"core_hours"`
+	MemoryHours float64   `json:"memory_hours"`
+	Created     int       `json:"created"`
+	Expired     int       `json:"expired"`
+	Deleted     int       `json:"deleted"`
+}
+
+type UsageResponse struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Aggregation is the period the usage is aggregated by, records are returned as is if not set
+	Aggregation string         `json:"aggregation,omitempty"`
+	Usage       []UsageSummary `json:"usage,omitempty"`
+	Records     []UsageRecord  `json:"records,omitempty"`
+}
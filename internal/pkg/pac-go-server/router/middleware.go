@@ -10,6 +10,7 @@ import (
 
 	pacClient "github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/services"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
 )
 
@@ -23,6 +24,26 @@ func AllowAdminOnly(c *gin.Context) {
 	c.Next()
 }
 
+// AllowAdminOrGroupOwner allows the admins and the owners of the group set in the request path
+func AllowAdminOrGroupOwner(c *gin.Context) {
+	config := pacClient.GetConfigFromContext(c.Request.Context())
+	kc := pacClient.NewKeyCloakClient(config, c)
+	if kc.IsRole(utils.ManagerRole) {
+		c.Next()
+		return
+	}
+	owner, err := services.IsGroupOwner(kc.GetUserID(), c.Param("id"))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to check the group owners, err: %v", err)})
+		return
+	}
+	if !owner {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Not authorized to perform this action"})
+		return
+	}
+	c.Next()
+}
+
 func RetrospectKeycloakToken(c *gin.Context) {
 	//nolint:staticcheck
 	ctx := context.WithValue(c.Request.Context(), "keycloak_client", client)
@@ -48,21 +48,34 @@ func CreateRouter() *gin.Engine {
 	authorizedAdmin := authorized.Group("")
 	authorizedAdmin.Use(AllowAdminOnly)
 
+	// admins and the owners of the group in the path
+	authorizedGroupAdmin := authorized.Group("")
+	authorizedGroupAdmin.Use(AllowAdminOrGroupOwner)
+
 	// Group routes
 	authorized.GET("/groups", services.GetAllGroups)
 	authorized.GET("/groups/:id", services.GetGroup)
 	authorized.POST("/groups/:id/request", services.NewGroupRequest)
-	// authorized.POST("/groups/:id/exit", services.ExitGroup)
+	authorized.POST("/groups/:id/exit", services.ExitGroup)
 
 	authorized.GET("/groups/:id/quota", services.GetQuota)
+	authorizedGroupAdmin.POST("/groups/:id/quota", services.CreateQuota)
+	authorizedGroupAdmin.PUT("/groups/:id/quota", services.UpdateQuota)
+	authorizedGroupAdmin.GET("/groups/:id/owners", services.GetGroupOwners)
 
 	// Request routes
 	// /requests?type=group to list only group add requests
 	authorized.GET("/requests", services.GetAllRequests)
+	authorized.POST("/requests", services.CreateRequest)
 	authorized.GET("/requests/:id", services.GetRequest)
 	authorized.DELETE("/requests/:id", services.DeleteRequest)
-	authorizedAdmin.POST("/requests/:id/approve", services.ApproveRequest)
-	authorizedAdmin.POST("/requests/:id/reject", services.RejectRequest)
+	// comment threads between the requester and the approvers
+	authorized.GET("/requests/:id/comments", services.GetRequestComments)
+	authorized.POST("/requests/:id/comments", services.CreateRequestComment)
+	// admins and the owners of the group targeted by the request
+	authorized.POST("/requests/:id/approve", services.ApproveRequest)
+	authorized.POST("/requests/:id/reject", services.RejectRequest)
+	authorized.POST("/requests/bulk", services.BulkDecideRequests)
 
 	// key related routes
 
@@ -83,12 +96,26 @@ func CreateRouter() *gin.Engine {
 		authorizedAdmin.DELETE("/catalogs/:name", services.DeleteCatalog)
 		authorizedAdmin.PUT("/catalogs/:name/retire", services.RetireCatalog)
 
-		authorizedAdmin.POST("/groups/:id/quota", services.CreateQuota)
-		authorizedAdmin.PUT("/groups/:id/quota", services.UpdateQuota)
 		authorizedAdmin.DELETE("/groups/:id/quota", services.DeleteQuota)
 
+		// owners administering the groups
+		authorizedAdmin.PUT("/groups/:id/owners", services.UpdateGroupOwners)
+		authorizedAdmin.DELETE("/groups/:id/owners", services.DeleteGroupOwners)
+
 		authorizedAdmin.GET("/users", services.GetUsers)
 		authorizedAdmin.GET("/users/:id", services.GetUser)
+
+		// rules auto-deciding the requests
+		authorizedAdmin.GET("/rules", services.GetRules)
+		authorizedAdmin.GET("/rules/:id", services.GetRule)
+		authorizedAdmin.POST("/rules", services.CreateRule)
+		authorizedAdmin.PUT("/rules/:id", services.UpdateRule)
+		authorizedAdmin.DELETE("/rules/:id", services.DeleteRule)
+		authorizedAdmin.POST("/rules/dry-run", services.DryRunRule)
+
+		// failed notifications of the events
+		authorizedAdmin.GET("/events/failed", services.GetFailedEvents)
+		authorizedAdmin.POST("/events/:id/replay", services.ReplayEvent)
 	}
 
 	// user related endpoints
@@ -109,9 +136,18 @@ func CreateRouter() *gin.Engine {
 
 	// list user quota
 	authorized.GET("/quota", services.GetUserQuota)
+	// request to increase the user quota for a limited duration
+	authorized.POST("/quota/request", services.NewQuotaIncreaseRequest)
 
 	authorized.GET("/events", services.GetEvents)
 
+	// usage history of the services
+	authorized.GET("/usage", services.GetUsage)
+
+	// notification preferences of the user
+	authorized.GET("/me/notifications", services.GetNotificationPreferences)
+	authorized.PUT("/me/notifications", services.UpdateNotificationPreferences)
+
 	// terms and conditions related endpoints
 	authorized.GET("/tnc", services.GetTermsAndConditionsStatus)
 	authorized.POST("/tnc", services.AcceptTermsAndConditions)
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+var errApproverNotAllowed = errors.New("approver is not allowed to approve the current stage of the request")
+
+// LoadApprovalPolicies loads the approval policies from the JSON file
+func LoadApprovalPolicies(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read approval policies: %w", err)
+	}
+	var policies []models.ApprovalPolicy
+	if err := json.Unmarshal(content, &policies); err != nil {
+		return fmt.Errorf("failed to parse approval policies: %w", err)
+	}
+	for _, policy := range policies {
+		if err := validateApprovalPolicy(policy); err != nil {
+			return fmt.Errorf("invalid approval policy %s: %w", policy.Name, err)
+		}
+	}
+	models.ApprovalPolicies = policies
+	return nil
+}
+
+func validateApprovalPolicy(policy models.ApprovalPolicy) error {
+	if policy.Name == "" {
+		return errors.New("name is not set")
+	}
+	if _, err := getRequestHandler(policy.RequestType); err != nil {
+		return err
+	}
+	if len(policy.Stages) == 0 {
+		return errors.New("at least one stage is required")
+	}
+	for _, stage := range policy.Stages {
+		if stage.Approvals < 1 {
+			return errors.New("every stage requires at least one approval")
+		}
+	}
+	return nil
+}
+
+// getApprovalPolicy returns the first policy matching the request, nil if the request does not require an approval chain
+func getApprovalPolicy(request *models.Request) *models.ApprovalPolicy {
+	for i, policy := range models.ApprovalPolicies {
+		if policy.RequestType != request.RequestType {
+			continue
+		}
+		if len(policy.Groups) > 0 && (request.GroupAdmission == nil || !slices.Contains(policy.Groups, request.GroupAdmission.Group)) {
+			continue
+		}
+		if policy.MinExtensionDays > 0 && (request.ServiceExpiry == nil ||
+			!request.ServiceExpiry.Expiry.After(request.CreatedAt.AddDate(0, 0, policy.MinExtensionDays))) {
+			continue
+		}
+		return &models.ApprovalPolicies[i]
+	}
+	return nil
+}
+
+// approveStage records the approval of the user making the request on the approval chain of the request, the approval
+// chain is started from the policy on the first approval, later approvals follow the chain recorded on the request.
+func approveStage(c *gin.Context, request *models.Request, policy *models.ApprovalPolicy) error {
+	// the request is updated only once the approval is recorded
+	var approval *models.Approval
+	if request.Approval != nil {
+		approval = &models.Approval{Policy: request.Approval.Policy, Stages: request.Approval.Stages,
+			Approvals: slices.Clone(request.Approval.Approvals)}
+	} else {
+		approval = &models.Approval{Policy: policy.Name, Stages: policy.Stages}
+	}
+	userID := c.Request.Context().Value("userid").(string)
+	if approval.HasApproved(userID) {
+		return fmt.Errorf("%w, user has already approved the request", errApproverNotAllowed)
+	}
+	stage := approval.CurrentStage()
+	if approverGroup := approval.Stages[stage].ApproverGroup; approverGroup != "" {
+		groups, _ := c.Request.Context().Value("groups").([]models.Group)
+		var member bool
+		for _, group := range groups {
+			if group.Name == approverGroup {
+				member = true
+				break
+			}
+		}
+		if !member {
+			return fmt.Errorf("%w, stage %d requires approval from a member of the group %s", errApproverNotAllowed, stage+1, approverGroup)
+		}
+	} else {
+		// stage without the approver group is approved by the admins only, not by the owners of the group
+		config := client.GetConfigFromContext(c.Request.Context())
+		if !client.NewKeyCloakClient(config, c.Request.Context()).IsRole(utils.ManagerRole) {
+			return fmt.Errorf("%w, stage %d requires approval from an admin", errApproverNotAllowed, stage+1)
+		}
+	}
+
+	previousApprovals := len(approval.Approvals)
+	approval.Approvals = append(approval.Approvals, models.RequestApproval{
+		UserID:     userID,
+		Username:   c.Request.Context().Value("username").(string),
+		Stage:      stage,
+		ApprovedAt: time.Now(),
+	})
+	if err := dbCon.UpdateRequestApproval(request.ID.Hex(), approval, previousApprovals); err != nil {
+		return err
+	}
+	request.Approval = approval
+	return nil
+}
@@ -47,6 +47,9 @@ func setUp(t testing.TB) (mockedKubeClient *kubernetes.MockClient, mockedDBClien
 	client.NewKeyCloakClient = func(config client.KeyCloakConfig, ctx context.Context) client.Keycloak {
 		return mockKeyCloakClient
 	}
+	client.NewServiceAccountClient = func(ctx context.Context) (client.Keycloak, error) {
+		return mockKeyCloakClient, nil
+	}
 
 	return mockkubeclient, mockDBClient, mockKeyCloakClient, func() {
 		ctrlKube.Finish()
@@ -503,6 +506,48 @@ func getResource(apiType string, customValues map[string]interface{}) interface{
 			Notified:    false,
 		}
 		return []models.Event{event}
+	case "get-event-by-id":
+		lastAttemptAt := time.Now()
+		event := &models.Event{
+			ID:         [12]byte{1},
+			Type:       models.EventTypeRequestApproved,
+			CreatedAt:  time.Now(),
+			Originator: "12345",
+			UserID:     "12345",
+			Notify:     true,
+			Delivery: models.EventDelivery{
+				State:         models.EventDeliveryDeadLetter,
+				Attempts:      5,
+				LastError:     "error sending mail",
+				LastAttemptAt: &lastAttemptAt,
+			},
+		}
+		for key, value := range customValues {
+			if fieldValue := reflect.ValueOf(event).Elem().FieldByName(key); fieldValue.IsValid() {
+				if value != nil {
+					fieldValue.Set(reflect.ValueOf(value))
+				}
+			}
+		}
+		return event
+	case "get-notification-preferences":
+		updatedAt := time.Now()
+		preferences := &models.NotificationPreferences{
+			UserID: "12345",
+			Channels: map[models.EventType]models.NotificationChannel{
+				models.EventTypeRequestComment: models.NotificationChannelInApp,
+				models.EventQuotaThreshold:     models.NotificationChannelDigest,
+			},
+			UpdatedAt: &updatedAt,
+		}
+		for key, value := range customValues {
+			if fieldValue := reflect.ValueOf(preferences).Elem().FieldByName(key); fieldValue.IsValid() {
+				if value != nil {
+					fieldValue.Set(reflect.ValueOf(value))
+				}
+			}
+		}
+		return preferences
 	case "get-all-users":
 		user := gocloak.User{
 			ID:        utils.Ptr("12345"),
@@ -647,3 +692,22 @@ func formQuota(params customValues) models.Capacity {
 	}
 	return cap
 }
+
+// transitionMatcher matches the request transitions to the state made by the actor
+type transitionMatcher struct {
+	to    models.RequestStateType
+	actor string
+}
+
+func transitionTo(to models.RequestStateType, actor string) gomock.Matcher {
+	return transitionMatcher{to: to, actor: actor}
+}
+
+func (m transitionMatcher) Matches(x interface{}) bool {
+	transition, ok := x.(models.RequestTransition)
+	return ok && transition.To == m.to && transition.Actor == m.actor && !transition.Timestamp.IsZero()
+}
+
+func (m transitionMatcher) String() string {
+	return fmt.Sprintf("is transition to %s by %s", m.to, m.actor)
+}
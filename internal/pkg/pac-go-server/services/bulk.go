@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+const maxBulkRequests = 100
+
+// BulkDecideRequests	godoc
+// @Summary			Approve or reject requests in bulk
+// @Description		Approve or reject the requests in bulk, every request is decided on its own and reported with its outcome
+// @Tags			requests
+// @Accept			json
+// @Produce			json
+// @Param			decision body models.BulkDecision true "Bulk decision"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			200
+// @Router			/api/v1/requests/bulk [post]
+func BulkDecideRequests(c *gin.Context) {
+	logger := log.GetLogger()
+	var decision models.BulkDecision
+	if err := c.BindJSON(&decision); err != nil {
+		logger.Error("failed to bind bulk decision", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to load the body, please feed the proper json body: %s", err)})
+		return
+	}
+	if err := validateBulkDecision(&decision); err != nil {
+		logger.Error("bulk decision validation has failed", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := models.BulkDecisionResponse{Results: []models.BulkDecisionResult{}}
+	for _, id := range decision.IDs {
+		result := decideBulkRequest(c, id, decision)
+		if result.Error != "" {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+		response.Results = append(response.Results, result)
+	}
+	logger.Info("decided requests in bulk", zap.String("action", string(decision.Action)),
+		zap.Int("succeeded", response.Succeeded), zap.Int("failed", response.Failed))
+	c.JSON(http.StatusOK, response)
+}
+
+func validateBulkDecision(decision *models.BulkDecision) error {
+	switch decision.Action {
+	case models.BulkActionApprove:
+	case models.BulkActionReject:
+		if decision.Comment == "" {
+			return errors.New("comment is required to reject the requests")
+		}
+	default:
+		return fmt.Errorf("invalid action: \"%s\" is set, valid values are %s %s", decision.Action, models.BulkActionApprove, models.BulkActionReject)
+	}
+	// every request is decided once
+	var ids []string
+	for _, id := range decision.IDs {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return errors.New("at least one request ID is required")
+	}
+	if len(ids) > maxBulkRequests {
+		return fmt.Errorf("at most %d requests can be decided at once", maxBulkRequests)
+	}
+	decision.IDs = ids
+	return nil
+}
+
+// decideBulkRequest decides the request the same way it is decided on its own
+func decideBulkRequest(c *gin.Context, id string, decision models.BulkDecision) models.BulkDecisionResult {
+	logger := log.GetLogger()
+	result := models.BulkDecisionResult{ID: id}
+	request, err := dbCon.GetRequestByID(id)
+	if err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			result.Status = http.StatusNotFound
+			result.Error = fmt.Sprintf("request with id: %s not found", id)
+			return result
+		}
+		logger.Error("failed to get requests by id", zap.String("id", id), zap.Error(err))
+		result.Status = http.StatusInternalServerError
+		result.Error = fmt.Sprintf("failed to fetch the requested record from the db, err: %s", err.Error())
+		return result
+	}
+
+	switch decision.Action {
+	case models.BulkActionApprove:
+		result.Approval, err = approveRequest(c, request)
+		result.Status = http.StatusNoContent
+		if result.Approval != nil {
+			result.Status = http.StatusAccepted
+		}
+	case models.BulkActionReject:
+		err = rejectRequest(c, request, decision.Comment)
+		result.Status = http.StatusNoContent
+	}
+	if err != nil {
+		result.Status = getErrorHttpStatus(err)
+		result.Error = err.Error()
+	}
+	result.State = request.State
+	return result
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+func TestBulkDecideRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name           string
+		mockFunc       func()
+		requestContext testContext
+		decision       models.BulkDecision
+		httpStatus     int
+		response       *models.BulkDecisionResponse
+	}{
+		{
+			name: "requests approved in bulk",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID("1").Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
+				mockDBClient.EXPECT().GetRequestByID("2").Return(nil, utils.ErrResourceNotFound).Times(1)
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockClient.EXPECT().UpdateServiceExpiry(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().UpdateServiceUsageExpiry("test-service", gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestState("010000000000000000000000", transitionTo(models.RequestStateApproved, "12345")).Return(nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
+			},
+			requestContext: formContext(customValues{"userid": "12345", "roles": []string{"manager"}}),
+			decision:       models.BulkDecision{IDs: []string{"1", "2", "1"}, Action: models.BulkActionApprove},
+			httpStatus:     http.StatusOK,
+			response: &models.BulkDecisionResponse{Succeeded: 1, Failed: 1, Results: []models.BulkDecisionResult{
+				{ID: "1", Status: http.StatusNoContent, State: models.RequestStateApproved},
+				{ID: "2", Status: http.StatusNotFound, Error: "request with id: 2 not found"},
+			}},
+		},
+		{
+			name: "requests rejected in bulk",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID("1").Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
+				mockDBClient.EXPECT().GetRequestByID("2").Return(getResource("get-request-by-id", customValues{
+					"State": models.RequestStateApproved,
+				}).(*models.Request), nil).Times(1)
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(2)
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), transitionTo(models.RequestStateRejected, "12345")).Return(nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
+			},
+			requestContext: formContext(customValues{"userid": "12345", "roles": []string{"manager"}}),
+			decision:       models.BulkDecision{IDs: []string{"1", "2"}, Action: models.BulkActionReject, Comment: "not needed"},
+			httpStatus:     http.StatusOK,
+			response: &models.BulkDecisionResponse{Succeeded: 1, Failed: 1, Results: []models.BulkDecisionResult{
+				{ID: "1", Status: http.StatusNoContent, State: models.RequestStateRejected},
+				{ID: "2", Status: http.StatusBadRequest, State: models.RequestStateApproved, Error: "Request is already APPROVED."},
+			}},
+		},
+		{
+			name: "not authorized to decide requests",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID("1").Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+			},
+			requestContext: formContext(customValues{"userid": "67890"}),
+			decision:       models.BulkDecision{IDs: []string{"1"}, Action: models.BulkActionApprove},
+			httpStatus:     http.StatusOK,
+			response: &models.BulkDecisionResponse{Failed: 1, Results: []models.BulkDecisionResult{
+				{ID: "1", Status: http.StatusUnauthorized, Error: "You do not have permission to approve requests."},
+			}},
+		},
+		{
+			name:           "comment not set to reject requests",
+			mockFunc:       func() {},
+			requestContext: formContext(customValues{"userid": "12345", "roles": []string{"manager"}}),
+			decision:       models.BulkDecision{IDs: []string{"1"}, Action: models.BulkActionReject},
+			httpStatus:     http.StatusBadRequest,
+		},
+		{
+			name:           "invalid action",
+			mockFunc:       func() {},
+			requestContext: formContext(customValues{"userid": "12345", "roles": []string{"manager"}}),
+			decision:       models.BulkDecision{IDs: []string{"1"}, Action: "DELETE"},
+			httpStatus:     http.StatusBadRequest,
+		},
+		{
+			name:           "request IDs not set",
+			mockFunc:       func() {},
+			requestContext: formContext(customValues{"userid": "12345", "roles": []string{"manager"}}),
+			decision:       models.BulkDecision{Action: models.BulkActionApprove},
+			httpStatus:     http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			marshalledDecision, _ := json.Marshal(tc.decision)
+			req, err := http.NewRequest(http.MethodPost, "/requests/bulk", bytes.NewBuffer(marshalledDecision))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req.WithContext(getContext(tc.requestContext))
+			dbCon = mockDBClient
+			kubeClient = mockClient
+			BulkDecideRequests(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+			if tc.response != nil {
+				var response models.BulkDecisionResponse
+				if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, *tc.response, response)
+			}
+		})
+	}
+}
@@ -132,6 +132,7 @@ func CreateCatalog(c *gin.Context) {
 	}()
 
 	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Catalog %s created", catalog.Name))
+	event.SetResource(models.EventResourceCatalog, "", catalog.Name)
 
 	logger.Debug("successfully created catalog")
 	c.Status(http.StatusCreated)
@@ -181,6 +182,7 @@ func DeleteCatalog(c *gin.Context) {
 	}()
 
 	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Catalog %s deleted", catalogName))
+	event.SetResource(models.EventResourceCatalog, "", catalogName)
 	logger.Debug("successfully deleted catalog", zap.String("catalog name", catalogName))
 	c.Status(http.StatusNoContent)
 }
@@ -229,6 +231,7 @@ func RetireCatalog(c *gin.Context) {
 	}()
 
 	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Catalog %s retired", catalogName))
+	event.SetResource(models.EventResourceCatalog, "", catalogName)
 	logger.Debug("successfully retired catalog", zap.String("catalog name", catalogName))
 	c.Status(http.StatusNoContent)
 }
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+const maxCommentLength = 2000
+
+// GetRequestComments	godoc
+// @Summary			Get request comments
+// @Description		Get the comments on the request in the order they were made
+// @Tags			requests
+// @Accept			json
+// @Produce			json
+// @Param			id path string true "request-id of the request"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			200
+// @Router			/api/v1/requests/{id}/comments [get]
+func GetRequestComments(c *gin.Context) {
+	logger := log.GetLogger()
+	id := c.Param("id")
+	request, ok := getCommentableRequest(c, id)
+	if !ok {
+		return
+	}
+	comments, err := dbCon.GetRequestComments(request.ID.Hex())
+	if err != nil {
+		logger.Error("failed to get request comments", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to fetch the comments from the db, err: %s", err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, comments)
+}
+
+// CreateRequestComment	godoc
+// @Summary			Create request comment
+// @Description		Comment on the request, the other party of the request is notified of the comment
+// @Tags			requests
+// @Accept			json
+// @Produce			json
+// @Param			comment body models.RequestComment true "Create comment"
+// @Param			id path string true "request-id of the request"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			201
+// @Router			/api/v1/requests/{id}/comments [post]
+func CreateRequestComment(c *gin.Context) {
+	originator := c.Request.Context().Value("userid").(string)
+	logger := log.GetLogger()
+	id := c.Param("id")
+
+	var comment models.RequestComment
+	if err := c.BindJSON(&comment); err != nil {
+		logger.Error("failed to bind comment", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to load the body, please feed the proper json body: %s", err)})
+		return
+	}
+	comment.Message = strings.TrimSpace(comment.Message)
+	if comment.Message == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Message is required to comment on a request."})
+		return
+	}
+	if len(comment.Message) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Message cannot be longer than %d characters.", maxCommentLength)})
+		return
+	}
+
+	request, ok := getCommentableRequest(c, id)
+	if !ok {
+		return
+	}
+	comment = models.RequestComment{
+		RequestID: request.ID.Hex(),
+		UserID:    originator,
+		Username:  c.Request.Context().Value("username").(string),
+		Message:   comment.Message,
+		CreatedAt: time.Now(),
+	}
+	commentID, err := dbCon.NewRequestComment(&comment)
+	if err != nil {
+		logger.Error("failed to insert the comment", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to insert the comment into the db, err: %s", err.Error())})
+		return
+	}
+	notifyRequestComment(request, &comment)
+	c.JSON(http.StatusCreated, gin.H{"id": commentID})
+}
+
+// getCommentableRequest fetches the request, provided the user making the request is the requester or an approver of
+// the request, otherwise the error is set on the response
+func getCommentableRequest(c *gin.Context, id string) (*models.Request, bool) {
+	logger := log.GetLogger()
+	request, err := dbCon.GetRequestByID(id)
+	if err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("request with id: %s not found", id)})
+			return nil, false
+		}
+		logger.Error("failed to get requests by id", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to fetch the requested record from the db, err: %s", err.Error())})
+		return nil, false
+	}
+	if request.UserID == c.Request.Context().Value("userid").(string) {
+		return request, true
+	}
+	allowed, err := canDecideRequest(c, request)
+	if err != nil {
+		logger.Error("failed to check the group owners", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to check the group owners, err: %s", err.Error())})
+		return nil, false
+	}
+	if !allowed {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "You do not have permission to comment on this request."})
+		return nil, false
+	}
+	return request, true
+}
+
+// notifyRequestComment notifies the approvers of the comment made by the requester, and the requester of the comment
+// made by an approver
+func notifyRequestComment(request *models.Request, comment *models.RequestComment) {
+	logger := log.GetLogger()
+	message := fmt.Sprintf("%s commented on the request %s: %s", comment.Username, request.ID.Hex(), comment.Message)
+	event, err := models.NewEvent(request.UserID, comment.UserID, models.EventTypeRequestComment)
+	if err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+		return
+	}
+	if comment.UserID == request.UserID {
+		event.SetNotifyAdmin()
+		notifyGroupOwners(request, comment.UserID, models.EventTypeRequestComment, message)
+	} else {
+		event.SetNotify()
+	}
+	event.SetLog(models.EventLogLevelINFO, message)
+	setRequestResource(event, request.ID.Hex(), request)
+	if err := dbCon.NewEvent(event); err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+func TestCreateRequestComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name           string
+		mockFunc       func()
+		requestContext testContext
+		comment        models.RequestComment
+		httpStatus     int
+	}{
+		{
+			name: "requester commented",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
+				mockDBClient.EXPECT().NewRequestComment(gomock.Any()).DoAndReturn(func(comment *models.RequestComment) (string, error) {
+					assert.Equal(t, "12345", comment.UserID)
+					assert.Equal(t, "please approve", comment.Message)
+					return "123", nil
+				}).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).DoAndReturn(func(event *models.Event) error {
+					assert.True(t, event.NotifyAdmin)
+					assert.False(t, event.Notify)
+					return nil
+				}).Times(1)
+			},
+			requestContext: formContext(customValues{"userid": "12345", "username": "test-user"}),
+			comment:        models.RequestComment{Message: " please approve "},
+			httpStatus:     http.StatusCreated,
+		},
+		{
+			name: "admin commented",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().NewRequestComment(gomock.Any()).Return("123", nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).DoAndReturn(func(event *models.Event) error {
+					assert.Equal(t, "12345", event.UserID)
+					assert.True(t, event.Notify)
+					assert.False(t, event.NotifyAdmin)
+					return nil
+				}).Times(1)
+			},
+			requestContext: formContext(customValues{"userid": "67890", "username": "test-admin", "roles": []string{"manager"}}),
+			comment:        models.RequestComment{Message: "please justify"},
+			httpStatus:     http.StatusCreated,
+		},
+		{
+			name: "not authorized to comment",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+			},
+			requestContext: formContext(customValues{"userid": "67890", "username": "test-user-2"}),
+			comment:        models.RequestComment{Message: "hello"},
+			httpStatus:     http.StatusUnauthorized,
+		},
+		{
+			name:           "message not set",
+			mockFunc:       func() {},
+			requestContext: formContext(customValues{"userid": "12345", "username": "test-user"}),
+			comment:        models.RequestComment{Message: "  "},
+			httpStatus:     http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			marshalledComment, _ := json.Marshal(tc.comment)
+			req, err := http.NewRequest(http.MethodPost, "/requests/123/comments", bytes.NewBuffer(marshalledComment))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req.WithContext(getContext(tc.requestContext))
+			dbCon = mockDBClient
+			CreateRequestComment(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
+
+func TestGetRequestComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name           string
+		mockFunc       func()
+		requestContext testContext
+		httpStatus     int
+	}{
+		{
+			name: "requester fetched comments",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
+				mockDBClient.EXPECT().GetRequestComments(gomock.Any()).Return([]models.RequestComment{{UserID: "12345", Message: "hello"}}, nil).Times(1)
+			},
+			requestContext: formContext(customValues{"userid": "12345"}),
+			httpStatus:     http.StatusOK,
+		},
+		{
+			name: "not authorized to fetch comments",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+			},
+			requestContext: formContext(customValues{"userid": "67890"}),
+			httpStatus:     http.StatusUnauthorized,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			req, err := http.NewRequest(http.MethodGet, "/requests/123/comments", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req.WithContext(getContext(tc.requestContext))
+			dbCon = mockDBClient
+			GetRequestComments(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
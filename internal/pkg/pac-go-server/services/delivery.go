@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+// GetFailedEvents		godoc
+// @Summary			Get failed event deliveries
+// @Description		Get the events failed to notify, either dead-lettered after the max attempts or being retried
+// @Tags			events
+// @Accept			json
+// @Produce			json
+// @Param			state query string false "delivery state of the events, one of DEAD_LETTER, RETRYING" default(DEAD_LETTER)
+// @Param			page query int false "page number" default(1)
+// @Param			per_page query int false "number of events per page" default(10)
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			200
+// @Router			/api/v1/events/failed [get]
+func GetFailedEvents(c *gin.Context) {
+	logger := log.GetLogger()
+	state := models.EventDeliveryState(c.DefaultQuery("state", string(models.EventDeliveryDeadLetter)))
+	if state != models.EventDeliveryDeadLetter && state != models.EventDeliveryRetrying {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid delivery state - %s, valid values are %s, %s", state,
+			models.EventDeliveryDeadLetter, models.EventDeliveryRetrying)})
+		return
+	}
+	page, err := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "page must be a positive number"})
+		return
+	}
+	perPage, err := strconv.ParseInt(c.DefaultQuery("per_page", "10"), 10, 64)
+	if err != nil || perPage < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "per_page must be a positive number"})
+		return
+	}
+
+	events, totalCount, err := dbCon.GetEventsByDeliveryState(state, (page-1)*perPage, perPage)
+	if err != nil {
+		logger.Error("failed to get the failed events", zap.String("state", string(state)), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	totalPages := totalCount / perPage
+	if totalCount%perPage != 0 {
+		totalPages++
+	}
+	c.JSON(http.StatusOK, models.EventResponse{
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Events:     events,
+		Links: models.Links{
+			Self: c.Request.URL.String(),
+			Next: getNextPageLink(c, page, totalPages),
+			Last: getLastPageLink(c, totalPages),
+		},
+	})
+}
+
+// ReplayEvent			godoc
+// @Summary			Replay event delivery
+// @Description		Replay the failed delivery of the event, the event notifier retries it right away with the attempts reset
+// @Tags			events
+// @Accept			json
+// @Produce			json
+// @Param			id path string true "id of the event to be replayed"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			204
+// @Router			/api/v1/events/{id}/replay [post]
+func ReplayEvent(c *gin.Context) {
+	logger := log.GetLogger()
+	id := c.Param("id")
+	event, err := dbCon.GetEventByID(id)
+	if err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("event with id: %s not found", id)})
+			return
+		}
+		logger.Error("failed to get event", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if event.Delivery.State != models.EventDeliveryDeadLetter && event.Delivery.State != models.EventDeliveryRetrying {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Only the failed deliveries can be replayed, the event delivery is %s.",
+			event.Delivery.State)})
+		return
+	}
+
+	// the last error is kept until the replay is attempted
+	now := time.Now()
+	event.ReplayDelivery(now)
+	if err := dbCon.ReplayEventDelivery(id, now, event.Delivery); err != nil {
+		if errors.Is(err, utils.ErrResourceModified) {
+			c.JSON(http.StatusConflict, gin.H{"error": "The event is being delivered or was replayed meanwhile, please try again."})
+			return
+		}
+		logger.Error("failed to replay event", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to replay the event, err: %s", err.Error())})
+		return
+	}
+	logger.Debug("replaying event", zap.String("id", id))
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFailedEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, _, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name       string
+		mockFunc   func()
+		query      string
+		httpStatus int
+	}{
+		{
+			name: "dead-lettered events listed by default",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetEventsByDeliveryState(models.EventDeliveryDeadLetter, int64(0), int64(10)).
+					Return([]models.Event{*getResource("get-event-by-id", nil).(*models.Event)}, int64(1), nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+		{
+			name: "events being retried listed",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetEventsByDeliveryState(models.EventDeliveryRetrying, int64(20), int64(20)).
+					Return([]models.Event{}, int64(0), nil).Times(1)
+			},
+			query:      "?state=RETRYING&page=2&per_page=20",
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:       "invalid delivery state",
+			mockFunc:   func() {},
+			query:      "?state=DELIVERED",
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid page",
+			mockFunc:   func() {},
+			query:      "?page=0",
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			req, err := http.NewRequest(http.MethodGet, "/events/failed"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req
+			dbCon = mockDBClient
+			GetFailedEvents(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
+
+func TestReplayEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, _, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name       string
+		mockFunc   func()
+		httpStatus int
+	}{
+		{
+			name: "dead-lettered event replayed",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetEventByID(gomock.Any()).Return(getResource("get-event-by-id", nil).(*models.Event), nil).Times(1)
+				mockDBClient.EXPECT().ReplayEventDelivery("010000000000000000000000", gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ string, _ time.Time, delivery models.EventDelivery) error {
+						assert.Equal(t, models.EventDeliveryRetrying, delivery.State)
+						assert.Equal(t, 0, delivery.Attempts)
+						assert.NotNil(t, delivery.NextRetryAt)
+						return nil
+					}).Times(1)
+			},
+			httpStatus: http.StatusNoContent,
+		},
+		{
+			name: "event claimed meanwhile",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetEventByID(gomock.Any()).Return(getResource("get-event-by-id", nil).(*models.Event), nil).Times(1)
+				mockDBClient.EXPECT().ReplayEventDelivery("010000000000000000000000", gomock.Any(), gomock.Any()).
+					Return(utils.ErrResourceModified).Times(1)
+			},
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name: "delivered event not replayed",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetEventByID(gomock.Any()).Return(getResource("get-event-by-id", customValues{
+					"Delivery": models.EventDelivery{State: models.EventDeliveryDelivered},
+				}).(*models.Event), nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name: "event not found",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetEventByID(gomock.Any()).Return(nil, utils.ErrResourceNotFound).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			req, err := http.NewRequest(http.MethodPost, "/events/010000000000000000000000/replay", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req
+			c.Params = gin.Params{{Key: "id", Value: "010000000000000000000000"}}
+			dbCon = mockDBClient
+			ReplayEvent(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
@@ -1,53 +1,75 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // GetEvents			godoc
 // @Summary			Get events
-// @Description		Get events
+// @Description		Get events, newest first unless sorted otherwise. The users get their own events, the admin gets the events of all the users unless user_id is set.
 // @Tags			events
 // @Accept			json
 // @Produce			json
+// @Param			user_id query string false "ID of the user the events are of, admin only"
+// @Param			type query string false "type of the events, e.g. REQUEST_APPROVED"
+// @Param			level query string false "log level of the events, one of INFO, ERROR"
+// @Param			originator query string false "ID of the user originated the events"
+// @Param			created_after query string false "events created at or after the time in RFC3339 format"
+// @Param			created_before query string false "events created before the time in RFC3339 format"
+// @Param			resource_kind query string false "kind of the resource the events are about, one of REQUEST, SERVICE, CATALOG, GROUP, USER"
+// @Param			resource_id query string false "id of the resource the events are about, e.g. the request id"
+// @Param			resource_name query string false "name of the resource the events are about, e.g. the service name"
+// @Param			sort_by query string false "field to sort the events by, one of created_at, type, level" default(created_at)
+// @Param			order query string false "order to sort the events in, one of asc, desc" default(desc)
+// @Param			page query int false "page number" default(1)
+// @Param			per_page query int false "number of events per page" default(10)
 // @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
 // @Success			200
 // @Router			/api/v1/events [get]
 // GetEvents returns all events
 func GetEvents(c *gin.Context) {
+	logger := log.GetLogger()
 	config := client.GetConfigFromContext(c.Request.Context())
 	kc := client.NewKeyCloakClient(config, c.Request.Context())
 
-	page := c.DefaultQuery("page", "1")         // Get the page number from the query parameter
-	perPage := c.DefaultQuery("per_page", "10") // Get the number of items per page from the query parameter
-
-	// Convert the page and perPage values to integers
-	pageInt, _ := strconv.ParseInt(page, 10, 64)
-	perPageInt, _ := strconv.ParseInt(perPage, 10, 64)
-
-	// Calculate the starting index and ending index for the current page
-	startIndex := (pageInt - 1) * perPageInt
-
-	var userID string
+	filter, page, err := getEventFilter(c)
+	if err != nil {
+		logger.Error("invalid event filter", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.UserID = c.Query("user_id")
 	if !kc.IsRole(utils.ManagerRole) {
 		// Get authenticated user's ID
-		userID = kc.GetUserID()
+		userID := kc.GetUserID()
+		if filter.UserID != "" && filter.UserID != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the admin can get the events of other users."})
+			return
+		}
+		filter.UserID = userID
 	}
-	events, totalCount, err := dbCon.GetEventsByUserID(userID, startIndex, perPageInt)
+	logger.Debug("getting events", zap.Any("filter", filter))
+	events, totalCount, err := dbCon.GetEvents(filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Calculate the total number of pages based on the perPage value
-	totalPages := totalCount / perPageInt
-	if totalCount%perPageInt != 0 {
+	totalPages := totalCount / filter.PerPage
+	if totalCount%filter.PerPage != 0 {
 		totalPages++
 	}
 
@@ -57,7 +79,7 @@ func GetEvents(c *gin.Context) {
 		Events:     events,
 		Links: models.Links{
 			Self: c.Request.URL.String(),
-			Next: getNextPageLink(c, pageInt, totalPages),
+			Next: getNextPageLink(c, page, totalPages),
 			Last: getLastPageLink(c, totalPages),
 		},
 	}
@@ -65,6 +87,85 @@ func GetEvents(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// getEventFilter returns the filter and the page number set by the query parameters
+func getEventFilter(c *gin.Context) (models.EventFilter, int64, error) {
+	filter := models.EventFilter{
+		Type:         models.EventType(c.Query("type")),
+		Level:        models.EventLogLevel(c.Query("level")),
+		Originator:   c.Query("originator"),
+		ResourceKind: models.EventResourceKind(c.Query("resource_kind")),
+		ResourceID:   c.Query("resource_id"),
+		ResourceName: c.Query("resource_name"),
+		Descending:   true,
+	}
+	if filter.Type != "" && !models.IsValidEventType(filter.Type) {
+		return filter, 0, fmt.Errorf("invalid event type - %s", filter.Type)
+	}
+	switch filter.Level {
+	case models.EventLogLevelINFO, models.EventLogLevelERROR, "":
+	default:
+		return filter, 0, fmt.Errorf("invalid level - %s, valid values are %s, %s", filter.Level, models.EventLogLevelINFO,
+			models.EventLogLevelERROR)
+	}
+	if filter.ResourceKind != "" && !slices.Contains(models.EventResourceKinds, filter.ResourceKind) {
+		return filter, 0, fmt.Errorf("invalid resource kind - %s, valid values are %v", filter.ResourceKind, models.EventResourceKinds)
+	}
+	for param, createdAt := range map[string]*time.Time{"created_after": &filter.CreatedAfter, "created_before": &filter.CreatedBefore} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, 0, fmt.Errorf("%s must be in RFC3339 format, e.g. 2006-01-02T15:04:05Z: %w", param, err)
+		}
+		*createdAt = t
+	}
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		return filter, 0, errors.New("created_after must be before created_before")
+	}
+
+	switch sortBy := c.DefaultQuery("sort_by", "created_at"); sortBy {
+	case "created_at", "type":
+		filter.SortBy = sortBy
+	case "level":
+		filter.SortBy = "log.level"
+	default:
+		return filter, 0, fmt.Errorf("invalid sort field - %s, valid values are created_at, type, level", sortBy)
+	}
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "asc":
+		filter.Descending = false
+	case "desc":
+	default:
+		return filter, 0, fmt.Errorf("invalid sort order - %s, valid values are asc, desc", order)
+	}
+
+	page, err := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		return filter, 0, errors.New("page must be a positive number")
+	}
+	filter.PerPage, err = strconv.ParseInt(c.DefaultQuery("per_page", "10"), 10, 64)
+	if err != nil || filter.PerPage < 1 {
+		return filter, 0, errors.New("per_page must be a positive number")
+	}
+	filter.StartIndex = (page - 1) * filter.PerPage
+	return filter, page, nil
+}
+
+// setRequestResource refers the event to the request along with the group or the service the request targets
+func setRequestResource(event *models.Event, id string, request *models.Request) {
+	event.SetResource(models.EventResourceRequest, id, "")
+	event.SetMetadata(models.EventMetadataRequestID, id)
+	event.SetMetadata(models.EventMetadataRequestType, string(request.RequestType))
+	if request.GroupAdmission != nil && request.GroupAdmission.Group != "" {
+		event.SetMetadata(models.EventMetadataGroup, request.GroupAdmission.Group)
+	}
+	if request.ServiceExpiry != nil && request.ServiceExpiry.Name != "" {
+		event.SetMetadata(models.EventMetadataServiceName, request.ServiceExpiry.Name)
+	}
+}
+
 func getNextPageLink(c *gin.Context, currentPage, totalPages int64) string {
 	if currentPage >= totalPages {
 		return ""
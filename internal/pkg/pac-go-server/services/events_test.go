@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,7 @@ func TestGetEvents(t *testing.T) {
 	testcases := []struct {
 		name           string
 		mockFunc       func()
+		query          string
 		requestContext testContext
 		httpStatus     int
 	}{
@@ -26,7 +28,7 @@ func TestGetEvents(t *testing.T) {
 			name: "get events successfully",
 			mockFunc: func() {
 				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
-				mockDBClient.EXPECT().GetEventsByUserID(gomock.Any(), gomock.Any(), gomock.Any()).Return(getResource("get-events-by-userid", nil).([]models.Event), int64(1), nil).Times(1)
+				mockDBClient.EXPECT().GetEvents(models.EventFilter{SortBy: "created_at", Descending: true, PerPage: 10}).Return(getResource("get-events-by-userid", nil).([]models.Event), int64(1), nil).Times(1)
 			},
 			requestContext: formContext(customValues{
 				"userid":                "12345",
@@ -36,12 +38,94 @@ func TestGetEvents(t *testing.T) {
 			}),
 			httpStatus: http.StatusOK,
 		},
+		{
+			name: "get events of a resource for the user",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockKCClient.EXPECT().GetUserID().Return("12345").Times(1)
+				mockDBClient.EXPECT().GetEvents(models.EventFilter{
+					UserID:       "12345",
+					ResourceKind: models.EventResourceService,
+					ResourceName: "test-service",
+					SortBy:       "created_at",
+					Descending:   true,
+					StartIndex:   20,
+					PerPage:      20,
+				}).Return([]models.Event{}, int64(0), nil).Times(1)
+			},
+			query: "?resource_kind=SERVICE&resource_name=test-service&page=2&per_page=20",
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusOK,
+		},
+		{
+			name: "get filtered timeline of a user by admin",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetEvents(models.EventFilter{
+					UserID:        "67890",
+					Type:          models.EventTypeRequestApproved,
+					Level:         models.EventLogLevelINFO,
+					Originator:    "12345",
+					CreatedAfter:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+					CreatedBefore: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+					SortBy:        "log.level",
+					PerPage:       10,
+				}).Return([]models.Event{}, int64(0), nil).Times(1)
+			},
+			query: "?user_id=67890&type=REQUEST_APPROVED&level=INFO&originator=12345&created_after=2026-01-01T00:00:00Z" +
+				"&created_before=2026-02-01T00:00:00Z&sort_by=level&order=asc",
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusOK,
+		},
+		{
+			name: "events of another user forbidden for non-admin",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockKCClient.EXPECT().GetUserID().Return("12345").Times(1)
+			},
+			query: "?user_id=67890",
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusForbidden,
+		},
+		{
+			name:     "invalid level",
+			mockFunc: func() {},
+			query:    "?level=DEBUG",
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid date range",
+			mockFunc: func() {},
+			query:    "?created_after=2026-02-01T00:00:00Z&created_before=2026-01-01T00:00:00Z",
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid resource kind",
+			mockFunc: func() {},
+			query:    "?resource_kind=UNKNOWN",
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusBadRequest,
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mockFunc()
 			c, _ := gin.CreateTestContext(httptest.NewRecorder())
-			req, err := http.NewRequest(http.MethodGet, "/events", nil)
+			req, err := http.NewRequest(http.MethodGet, "/events"+tc.query, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+var (
+	groupExitBlockedMsg           = "Request to exit the group %s cannot be approved since the services %s exceed the quota left after the exit, the services need to be deleted before the exit, id: %s"
+	serviceExpiryScheduledMsg     = "Services %s exceed the quota left after exiting the group %s, they will expire by %s"
+	errGroupExitServicesOverQuota = fmt.Errorf("%w after exiting the group", utils.ErrQuotaExceeded)
+)
+
+// LoadGroupExitPolicy sets the policy applied to the group exits leaving the services of the user over the quota
+func LoadGroupExitPolicy(policy string) error {
+	switch models.GroupExitPolicy(policy) {
+	case models.GroupExitPolicyBlock, models.GroupExitPolicyExpire:
+	default:
+		return fmt.Errorf("invalid group exit policy %s, valid values are %s %s", policy, models.GroupExitPolicyBlock, models.GroupExitPolicyExpire)
+	}
+	if models.GroupExitPolicy(policy) == models.GroupExitPolicyExpire && models.GroupExitExpiryNotice <= 0 {
+		return fmt.Errorf("notice before the expiry of the services must be positive")
+	}
+	models.GroupExitOverQuotaPolicy = models.GroupExitPolicy(policy)
+	return nil
+}
+
+// checkGroupExitQuota returns the services of the user exceeding the quota left after exiting the group, the exit is
+// blocked with errGroupExitServicesOverQuota if the services are not to be expired
+func checkGroupExitQuota(c *gin.Context, request *models.Request) ([]pac.Service, error) {
+	logger := log.GetLogger()
+	kc, err := client.NewServiceAccountClient(c.Request.Context())
+	if err != nil {
+		logger.Error("failed to get the service account client", zap.Error(err))
+		return nil, err
+	}
+	groups, err := kc.GetUserGroups(request.UserID)
+	if err != nil {
+		logger.Error("failed to get groups for user", zap.String("user id", request.UserID), zap.Error(err))
+		return nil, err
+	}
+	var groupIDs []string
+	for _, grp := range groups {
+		if *grp.ID != request.GroupAdmission.GroupID {
+			groupIDs = append(groupIDs, *grp.ID)
+		}
+	}
+	// user without any group is left with the quota granted only
+	quota, err := getGroupsUserQuota(c, request.UserID, groupIDs)
+	if err != nil {
+		return nil, err
+	}
+	serviceList, err := kubeClient.GetServices(request.UserID)
+	if err != nil {
+		logger.Error("failed to get services", zap.String("user id", request.UserID), zap.Error(err))
+		return nil, err
+	}
+	overQuota, err := getServicesOverQuota(serviceList.Items, quota)
+	if err != nil {
+		logger.Error("failed to get services exceeding the quota", zap.String("user id", request.UserID), zap.Error(err))
+		return nil, err
+	}
+	if len(overQuota) == 0 || models.GroupExitOverQuotaPolicy == models.GroupExitPolicyExpire {
+		return overQuota, nil
+	}
+
+	logger.Debug("group exit is blocked by the services exceeding the quota", zap.String("id", request.ID.Hex()),
+		zap.Any("quota", quota), zap.Strings("services", getServiceNames(overQuota)))
+	event, err := models.NewEvent(request.UserID, c.Request.Context().Value("userid").(string), models.EventGroupExitBlocked)
+	if err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+		return nil, err
+	}
+	event.SetNotifiyBoth()
+	event.SetLog(models.EventLogLevelERROR, fmt.Sprintf(groupExitBlockedMsg, request.GroupAdmission.Group,
+		strings.Join(getServiceNames(overQuota), ", "), request.ID.Hex()))
+	setRequestResource(event, request.ID.Hex(), request)
+	event.SetMetadata(models.EventMetadataServices, strings.Join(getServiceNames(overQuota), ","))
+	if err := dbCon.NewEvent(event); err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+	}
+	return nil, fmt.Errorf("%w, services %s need to be deleted", errGroupExitServicesOverQuota, strings.Join(getServiceNames(overQuota), ", "))
+}
+
+// getServicesOverQuota returns the services which do not fit in the quota, the services created earlier are kept
+// within the quota first
+func getServicesOverQuota(services []pac.Service, quota models.Capacity) ([]pac.Service, error) {
+	var active []pac.Service
+	for _, svc := range services {
+		if svc.Status.State != pac.ServiceStateExpired {
+			active = append(active, svc)
+		}
+	}
+	slices.SortStableFunc(active, func(a, b pac.Service) int {
+		return a.CreationTimestamp.Time.Compare(b.CreationTimestamp.Time)
+	})
+
+	catalogs := make(map[string]pac.Catalog)
+	var used models.Capacity
+	var overQuota []pac.Service
+	for _, svc := range active {
+		catalog, ok := catalogs[svc.Spec.Catalog.Name]
+		if !ok {
+			var err error
+			if catalog, err = kubeClient.GetCatalog(svc.Spec.Catalog.Name); err != nil {
+				return nil, fmt.Errorf("failed to get catalog, name %s %v", svc.Spec.Catalog.Name, err)
+			}
+			catalogs[svc.Spec.Catalog.Name] = catalog
+		}
+		capacity, err := AddCapacity(used, catalog.Spec.Capacity)
+		if err != nil {
+			return nil, err
+		}
+		if capacity.CPU > quota.CPU || capacity.Memory > quota.Memory || (quota.Services > 0 && capacity.Services > quota.Services) {
+			overQuota = append(overQuota, svc)
+			continue
+		}
+		used = capacity
+	}
+	return overQuota, nil
+}
+
+// scheduleServicesExpiry expires the services exceeding the quota after the notice, unless they expire earlier, and
+// notifies the user of it
+func scheduleServicesExpiry(c *gin.Context, request *models.Request, services []pac.Service) {
+	logger := log.GetLogger()
+	if len(services) == 0 {
+		return
+	}
+	expiry := time.Now().Add(models.GroupExitExpiryNotice)
+	var scheduled []string
+	for _, svc := range services {
+		if svc.Spec.Expiry.Time.Before(expiry) {
+			scheduled = append(scheduled, svc.Name)
+			continue
+		}
+		if err := kubeClient.UpdateServiceExpiry(svc.Name, expiry); err != nil {
+			logger.Error("failed to update service", zap.String("service name", svc.Name), zap.Error(err))
+			continue
+		}
+		if err := dbCon.UpdateServiceUsageExpiry(svc.Name, expiry); err != nil {
+			logger.Error("failed to update service usage", zap.String("service name", svc.Name), zap.Error(err))
+		}
+		scheduled = append(scheduled, svc.Name)
+	}
+	if len(scheduled) == 0 {
+		return
+	}
+
+	event, err := models.NewEvent(request.UserID, c.Request.Context().Value("userid").(string), models.EventServiceExpiryScheduled)
+	if err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+		return
+	}
+	event.SetNotify()
+	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf(serviceExpiryScheduledMsg, strings.Join(scheduled, ", "),
+		request.GroupAdmission.Group, expiry.Format(time.RFC3339)))
+	setRequestResource(event, request.ID.Hex(), request)
+	event.SetMetadata(models.EventMetadataServices, strings.Join(scheduled, ","))
+	event.SetMetadata(models.EventMetadataExpiry, expiry.Format(time.RFC3339))
+	if err := dbCon.NewEvent(event); err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+	}
+}
+
+func getServiceNames(services []pac.Service) []string {
+	var names []string
+	for _, svc := range services {
+		names = append(names, svc.Name)
+	}
+	return names
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+func TestApproveGroupExit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+	models.GroupExitExpiryNotice = 72 * time.Hour
+	defer func() { models.GroupExitOverQuotaPolicy = models.GroupExitPolicyBlock }()
+
+	exitRequest := getResource("add-to-group-request", customValues{"RequestType": models.RequestExitFromGroup}).(*models.Request)
+	userGroups := func(ids ...string) []*gocloak.Group {
+		var groups []*gocloak.Group
+		for _, id := range ids {
+			groups = append(groups, &gocloak.Group{ID: utils.Ptr(id), Name: utils.Ptr(id)})
+		}
+		return groups
+	}
+
+	testcases := []struct {
+		name       string
+		mockFunc   func()
+		policy     models.GroupExitPolicy
+		httpStatus int
+		events     []models.EventType
+	}{
+		{
+			name: "services within the quota left",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetUserGroups("12345").Return(userGroups("test-group", "other-group"), nil).Times(1)
+				mockDBClient.EXPECT().GetGroupsQuota([]string{"other-group"}).Return(getResource("get-groups-quota", nil).([]models.Quota), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("12345").Return(nil, nil).Times(1)
+				mockKCClient.EXPECT().DeleteUserFromGroup("12345", "test-group").Return(nil).Times(1)
+			},
+			policy:     models.GroupExitPolicyBlock,
+			httpStatus: http.StatusNoContent,
+			events:     []models.EventType{models.EventTypeRequestApproved},
+		},
+		{
+			name: "services within the quota granted to the user left without any group",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetUserGroups("12345").Return(userGroups("test-group"), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("12345").Return([]models.UserQuota{
+					{UserID: "12345", Capacity: models.Capacity{CPU: 2, Memory: 2, Services: 1, CoreHours: 100}},
+				}, nil).Times(1)
+				mockKCClient.EXPECT().DeleteUserFromGroup("12345", "test-group").Return(nil).Times(1)
+			},
+			policy:     models.GroupExitPolicyBlock,
+			httpStatus: http.StatusNoContent,
+			events:     []models.EventType{models.EventTypeRequestApproved},
+		},
+		{
+			name: "exit blocked by services over the quota",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetUserGroups("12345").Return(userGroups("test-group"), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("12345").Return(nil, nil).Times(1)
+			},
+			policy:     models.GroupExitPolicyBlock,
+			httpStatus: http.StatusBadRequest,
+			events:     []models.EventType{models.EventGroupExitBlocked},
+		},
+		{
+			name: "services over the quota scheduled for expiry",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetUserGroups("12345").Return(userGroups("test-group"), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("12345").Return(nil, nil).Times(1)
+				mockKCClient.EXPECT().DeleteUserFromGroup("12345", "test-group").Return(nil).Times(1)
+				mockClient.EXPECT().UpdateServiceExpiry("test-service", gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().UpdateServiceUsageExpiry("test-service", gomock.Any()).Return(nil).Times(1)
+			},
+			policy:     models.GroupExitPolicyExpire,
+			httpStatus: http.StatusNoContent,
+			events:     []models.EventType{models.EventServiceExpiryScheduled, models.EventTypeRequestApproved},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			models.GroupExitOverQuotaPolicy = tc.policy
+			request := *exitRequest
+			mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+			mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(&request, nil).Times(1)
+			serviceList := getResource("get-all-services", nil).(pac.ServiceList)
+			serviceList.Items[0].Spec.Expiry = metav1.NewTime(time.Now().AddDate(0, 1, 0))
+			mockClient.EXPECT().GetServices("12345").Return(serviceList, nil).Times(1)
+			mockClient.EXPECT().GetCatalog("test-catalog").Return(getResource("get-catalog", nil).(pac.Catalog), nil).Times(1)
+			if tc.httpStatus == http.StatusNoContent {
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), transitionTo(models.RequestStateApproved, "67890")).Return(nil).Times(1)
+			}
+			var events []models.EventType
+			mockDBClient.EXPECT().NewEvent(gomock.Any()).DoAndReturn(func(event *models.Event) error {
+				events = append(events, event.Type)
+				return nil
+			}).Times(len(tc.events))
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			req, err := http.NewRequest(http.MethodPost, "/requests/123/approve", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req.WithContext(getContext(formContext(customValues{"userid": "67890", "roles": []string{"manager"}})))
+			dbCon = mockDBClient
+			kubeClient = mockClient
+			ApproveRequest(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+			assert.Equal(t, tc.events, events)
+		})
+	}
+}
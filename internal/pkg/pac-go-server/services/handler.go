@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+// RequestHandler carries out the handling specific to a type of the requests
+type RequestHandler interface {
+	// Validate validates the request being created by the user making the request and completes it with the details of
+	// its target, the facts of the request the rules are evaluated against are returned. The failure is reported with
+	// the HTTP status if it is a statusError.
+	Validate(c *gin.Context, request *models.Request) (models.RuleInput, error)
+	// OnApprove carries out the changes requested by the approved request
+	OnApprove(c *gin.Context, request *models.Request) error
+	// OnReject cleans up after the rejected request
+	OnReject(c *gin.Context, request *models.Request) error
+	// Describe describes what the request is made for, e.g. "to add to the group dev"
+	Describe(request *models.Request) string
+}
+
+type registeredRequestHandler struct {
+	RequestHandler
+	// eventType is the type of the event raised on creating the request
+	eventType models.EventType
+}
+
+var requestHandlers = map[models.RequestType]registeredRequestHandler{}
+
+// RegisterRequestHandler registers the handler of the requests of the type, the event of the type is raised on
+// creating a request
+func RegisterRequestHandler(requestType models.RequestType, eventType models.EventType, handler RequestHandler) {
+	requestHandlers[requestType] = registeredRequestHandler{RequestHandler: handler, eventType: eventType}
+}
+
+func getRequestHandler(requestType models.RequestType) (registeredRequestHandler, error) {
+	handler, ok := requestHandlers[requestType]
+	if !ok {
+		return handler, fmt.Errorf("invalid request_type: \"%s\" is set, valid values are %v", requestType, getRequestTypes())
+	}
+	return handler, nil
+}
+
+// getRequestTypes returns the types of the requests registered
+func getRequestTypes() []models.RequestType {
+	var requestTypes []models.RequestType
+	for requestType := range requestHandlers {
+		requestTypes = append(requestTypes, requestType)
+	}
+	slices.Sort(requestTypes)
+	return requestTypes
+}
+
+// CreateRequest		godoc
+// @Summary			Create request
+// @Description		Create request of any type but USER_DELETE, raised by deleting the user. The target of the request is set in the field of its type
+// @Tags			requests
+// @Accept			json
+// @Produce			json
+// @Param			request body models.Request true "Create request"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			201
+// @Router			/api/v1/requests [post]
+func CreateRequest(c *gin.Context) {
+	logger := log.GetLogger()
+	var request = models.GetRequest()
+	if err := c.BindJSON(&request); err != nil {
+		logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to load the body, please feed the proper json body: %s", err)})
+		return
+	}
+	logger.Debug("request body", zap.Any("request", request))
+	// the user deletion is requested on deleting the user, after the services and the keys of the user are cleaned up
+	if request.RequestType == models.RequestDeleteUser {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s requests are raised by deleting the user", models.RequestDeleteUser)})
+		return
+	}
+	respondRequestCreated(c, &request)
+}
+
+// respondRequestCreated creates the request and responds with the decision taken on it by a rule, if any
+func respondRequestCreated(c *gin.Context, request *models.Request) {
+	id, decision, err := createRequest(c, request)
+	if err != nil {
+		c.JSON(getErrorHttpStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if decision != nil {
+		c.JSON(http.StatusCreated, decision)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// createRequest creates the request of the user making the request after it is validated by the handler of its type,
+// and raises the event of the request. The request may be decided right away by a rule, the decision is returned then.
+func createRequest(c *gin.Context, request *models.Request) (string, *models.RuleDecision, error) {
+	logger := log.GetLogger()
+	userID := c.Request.Context().Value("userid").(string)
+
+	handler, err := getRequestHandler(request.RequestType)
+	if err != nil {
+		logger.Error("error in create request validation", zap.Error(err))
+		return "", nil, &statusError{http.StatusBadRequest, err}
+	}
+	if errs := validateCreateRequestParams(*request); len(errs) > 0 {
+		logger.Error("error in create request validation", zap.Errors("errors", errs))
+		return "", nil, &statusError{http.StatusBadRequest, fmt.Errorf("%v", errs)}
+	}
+	input, err := handler.Validate(c, request)
+	if err != nil {
+		logger.Error("error in create request validation", zap.String("type", string(request.RequestType)), zap.Error(err))
+		var statusErr *statusError
+		if !errors.As(err, &statusErr) {
+			err = &statusError{http.StatusInternalServerError, err}
+		}
+		return "", nil, err
+	}
+
+	// insert the request into the database
+	newRequest := &models.Request{
+		UserID:         userID,
+		CreatedAt:      time.Now(),
+		State:          models.RequestStateNew,
+		Justification:  request.Justification,
+		RequestType:    request.RequestType,
+		GroupAdmission: request.GroupAdmission,
+		ServiceExpiry:  request.ServiceExpiry,
+		QuotaIncrease:  request.QuotaIncrease,
+	}
+	id, err := dbCon.NewRequest(newRequest)
+	if err != nil {
+		logger.Error("failed to create request", zap.Error(err))
+		return "", nil, &statusError{http.StatusInternalServerError, fmt.Errorf("failed to insert the request into the db, err: %w", err)}
+	}
+	input.Request = newRequest
+	input.Groups = getContextGroupNames(c)
+	decision := decideRequest(c, id, input)
+
+	event, err := models.NewEvent(userID, userID, handler.eventType)
+	if err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+		return "", nil, &statusError{http.StatusInternalServerError, fmt.Errorf("failed to create event, err: %w", err)}
+	}
+
+	defer func() {
+		if err := dbCon.NewEvent(event); err != nil {
+			log.GetLogger().Error("failed to create event", zap.Error(err))
+		}
+	}()
+
+	if decision != nil {
+		setDecisionEvent(event, id, newRequest, decision)
+		return id, decision, nil
+	}
+	event.SetNotifiyBoth()
+	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("New Request(%s) has been submitted %s", id, handler.Describe(newRequest)))
+	setRequestResource(event, id, newRequest)
+
+	logger.Debug("successfully created request", zap.String("id", id))
+	return id, nil, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+func init() {
+	RegisterRequestHandler(models.RequestAddToGroup, models.EventGroupJoinRequest, groupRequestHandler{})
+	RegisterRequestHandler(models.RequestExitFromGroup, models.EventGroupExitRequest, groupExitRequestHandler{})
+}
+
+// groupRequestHandler handles the requests to join a group, the user is removed from the other groups once approved
+type groupRequestHandler struct{}
+
+func (groupRequestHandler) Validate(c *gin.Context, request *models.Request) (models.RuleInput, error) {
+	grp, err := getRequestedGroup(c, request)
+	if err != nil {
+		return models.RuleInput{}, err
+	}
+	if models.IsMemberOfGroup(c.Request.Context(), *grp.Name) {
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("You are already a member of this group.")}
+	}
+
+	// check if the user has already requested access to the group
+	requests, err := getGroupRequests(c, *grp.ID)
+	if err != nil {
+		return models.RuleInput{}, err
+	}
+	for _, r := range requests {
+		if r.State == models.RequestStateNew {
+			return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("You have already requested access to this group.")}
+		}
+	}
+	return models.RuleInput{}, nil
+}
+
+func (groupRequestHandler) OnApprove(c *gin.Context, request *models.Request) error {
+	logger := log.GetLogger()
+	// the membership is changed by the service account, as the group owners and the rules approve without the admin token
+	kc, err := client.NewServiceAccountClient(c.Request.Context())
+	if err != nil {
+		logger.Error("failed to get the service account client", zap.Error(err))
+		return err
+	}
+	if err := kc.AddUserToGroup(request.UserID, request.GroupAdmission.GroupID); err != nil {
+		logger.Error("failed to add user to group", zap.String("user id", request.UserID),
+			zap.String("group id", request.GroupAdmission.GroupID), zap.Error(err))
+		return err
+	}
+	deleteUserFromPreviousGroups(kc, request)
+	return nil
+}
+
+func (groupRequestHandler) OnReject(_ *gin.Context, _ *models.Request) error {
+	return nil
+}
+
+func (groupRequestHandler) Describe(request *models.Request) string {
+	return fmt.Sprintf("to add to the group: %s", request.GroupAdmission.Group)
+}
+
+// groupExitRequestHandler handles the requests to exit a group, the services exceeding the quota left after the exit
+// are dealt with as per models.GroupExitOverQuotaPolicy
+type groupExitRequestHandler struct{}
+
+func (groupExitRequestHandler) Validate(c *gin.Context, request *models.Request) (models.RuleInput, error) {
+	grp, err := getRequestedGroup(c, request)
+	if err != nil {
+		return models.RuleInput{}, err
+	}
+	if !models.IsMemberOfGroup(c.Request.Context(), *grp.Name) {
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("You are already not a member of this group.")}
+	}
+
+	// check if the user has already requested to exit from the group
+	requests, err := getGroupRequests(c, *grp.ID)
+	if err != nil {
+		return models.RuleInput{}, err
+	}
+	for _, r := range requests {
+		if r.RequestType == models.RequestExitFromGroup && r.State == models.RequestStateNew {
+			return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("You have already requested to exit from this group.")}
+		}
+	}
+	return models.RuleInput{}, nil
+}
+
+func (groupExitRequestHandler) OnApprove(c *gin.Context, request *models.Request) error {
+	logger := log.GetLogger()
+	overQuota, err := checkGroupExitQuota(c, request)
+	if err != nil {
+		if errors.Is(err, errGroupExitServicesOverQuota) {
+			return &statusError{http.StatusBadRequest, err}
+		}
+		return err
+	}
+	kc, err := client.NewServiceAccountClient(c.Request.Context())
+	if err != nil {
+		logger.Error("failed to get the service account client", zap.Error(err))
+		return err
+	}
+	if err := kc.DeleteUserFromGroup(request.UserID, request.GroupAdmission.GroupID); err != nil {
+		logger.Error("failed to remove user from group", zap.String("user id", request.UserID),
+			zap.String("group id", request.GroupAdmission.GroupID), zap.Error(err))
+		return err
+	}
+	scheduleServicesExpiry(c, request, overQuota)
+	return nil
+}
+
+func (groupExitRequestHandler) OnReject(_ *gin.Context, _ *models.Request) error {
+	return nil
+}
+
+func (groupExitRequestHandler) Describe(request *models.Request) string {
+	return fmt.Sprintf("to exit the group: %s", request.GroupAdmission.Group)
+}
+
+// getRequestedGroup fetches the group targeted by the request, and sets the group along with the requester on the
+// request
+func getRequestedGroup(c *gin.Context, request *models.Request) (*gocloak.Group, error) {
+	logger := log.GetLogger()
+	if request.GroupAdmission == nil || request.GroupAdmission.GroupID == "" {
+		return nil, &statusError{http.StatusBadRequest, errors.New("group id should be set")}
+	}
+	groupID := request.GroupAdmission.GroupID
+	grp, err := getGroup(c.Request.Context(), groupID)
+	if err != nil {
+		if err == client.ErrorGroupNotFound {
+			logger.Error("group not found", zap.String("group id", groupID))
+			return nil, &statusError{http.StatusNotFound, err}
+		}
+		logger.Error("failed to get groups", zap.String("group id", groupID), zap.Error(err))
+		return nil, err
+	}
+	logger.Debug("fetched group", zap.Any("groups", grp))
+	request.GroupAdmission = &models.GroupAdmission{
+		GroupID:   groupID,
+		Group:     *grp.Name,
+		Requester: c.Request.Context().Value("username").(string),
+	}
+	return grp, nil
+}
+
+// getGroupRequests returns the requests of the user making the request targeting the group
+func getGroupRequests(c *gin.Context, groupID string) ([]models.Request, error) {
+	logger := log.GetLogger()
+	userID := c.Request.Context().Value("userid").(string)
+	requests, err := dbCon.GetRequestByGroupIDAndUserID(groupID, userID)
+	if err != nil {
+		logger.Error("failed to fetch requests", zap.String("group id", groupID), zap.String("user id", userID), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch the requested record from the db, err: %w", err)
+	}
+	return requests, nil
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+// maxQuotaIncreaseDays is the max number of days the quota can be increased for
+const maxQuotaIncreaseDays = 90
+
+func init() {
+	RegisterRequestHandler(models.RequestQuotaIncrease, models.EventQuotaIncreaseRequest, quotaIncreaseRequestHandler{})
+}
+
+// quotaIncreaseRequestHandler handles the requests to increase the quota of the user for a limited duration
+type quotaIncreaseRequestHandler struct{}
+
+func (quotaIncreaseRequestHandler) Validate(c *gin.Context, request *models.Request) (models.RuleInput, error) {
+	logger := log.GetLogger()
+	userID := c.Request.Context().Value("userid").(string)
+	if request.QuotaIncrease == nil {
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("quota should be set")}
+	}
+	if err := utils.ValidateQuotaFields(nil, request.QuotaIncrease.Capacity.CPU, request.QuotaIncrease.Capacity.Memory); err != nil {
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, err}
+	}
+	if request.QuotaIncrease.Capacity.Services < 0 || request.QuotaIncrease.Capacity.CoreHours < 0 {
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("services and core-hours cannot be negative")}
+	}
+	if request.QuotaIncrease.Duration <= 0 || request.QuotaIncrease.Duration > maxQuotaIncreaseDays {
+		return models.RuleInput{}, &statusError{http.StatusBadRequest,
+			fmt.Errorf("duration should be set to a positive number of days up to %d", maxQuotaIncreaseDays)}
+	}
+
+	// check if the user has already requested quota increase
+	requests, err := dbCon.GetRequestsByUserID(userID, string(models.RequestQuotaIncrease))
+	if err != nil {
+		logger.Error("failed to fetch requests", zap.String("user id", userID), zap.Error(err))
+		return models.RuleInput{}, fmt.Errorf("failed to fetch the requested record from the db, err: %w", err)
+	}
+	for _, r := range requests {
+		if r.State == models.RequestStateNew {
+			logger.Debug("user has already requested quota increase", zap.Any("request", r))
+			return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("You have already requested quota increase.")}
+		}
+	}
+	// the extension days cannot be increased
+	request.QuotaIncrease = &models.QuotaIncrease{
+		Capacity: models.Capacity{
+			CPU:       request.QuotaIncrease.Capacity.CPU,
+			Memory:    request.QuotaIncrease.Capacity.Memory,
+			Services:  request.QuotaIncrease.Capacity.Services,
+			CoreHours: request.QuotaIncrease.Capacity.CoreHours,
+		},
+		Duration: request.QuotaIncrease.Duration,
+	}
+	return models.RuleInput{}, nil
+}
+
+func (quotaIncreaseRequestHandler) OnApprove(_ *gin.Context, request *models.Request) error {
+	logger := log.GetLogger()
+	// granted quota reverts on its own once expired
+	now := time.Now()
+	if err := dbCon.NewUserQuota(&models.UserQuota{
+		UserID:    request.UserID,
+		Capacity:  request.QuotaIncrease.Capacity,
+		RequestID: request.ID.Hex(),
+		StartsAt:  now,
+		ExpiresAt: now.AddDate(0, 0, request.QuotaIncrease.Duration),
+	}); err != nil {
+		logger.Error("failed to grant quota", zap.String("user id", request.UserID), zap.Error(err))
+		return fmt.Errorf("failed to grant the quota, err: %w", err)
+	}
+	return nil
+}
+
+func (quotaIncreaseRequestHandler) OnReject(_ *gin.Context, _ *models.Request) error {
+	return nil
+}
+
+func (quotaIncreaseRequestHandler) Describe(request *models.Request) string {
+	return fmt.Sprintf("to increase the quota by CPU: %v Memory: %v Services: %d Core-hours: %v for %d days",
+		request.QuotaIncrease.Capacity.CPU, request.QuotaIncrease.Capacity.Memory, request.QuotaIncrease.Capacity.Services,
+		request.QuotaIncrease.Capacity.CoreHours, request.QuotaIncrease.Duration)
+}
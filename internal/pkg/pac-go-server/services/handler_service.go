@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+func init() {
+	RegisterRequestHandler(models.RequestExtendServiceExpiry, models.EventServiceExpiryRequest, serviceExpiryRequestHandler{})
+}
+
+// serviceExpiryRequestHandler handles the requests to extend the expiry of a service
+type serviceExpiryRequestHandler struct{}
+
+func (serviceExpiryRequestHandler) Validate(c *gin.Context, request *models.Request) (models.RuleInput, error) {
+	logger := log.GetLogger()
+	userID := c.Request.Context().Value("userid").(string)
+	if request.ServiceExpiry == nil || request.ServiceExpiry.Name == "" {
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("service name should be set")}
+	}
+	if request.ServiceExpiry.Expiry.IsZero() {
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("expiry time should be set")}
+	}
+	now := time.Now()
+	if now.After(request.ServiceExpiry.Expiry) {
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("expiry time should be in future")}
+	}
+
+	serviceName := request.ServiceExpiry.Name
+	// verify service with name exist
+	service, err := kubeClient.GetService(serviceName)
+	if err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			logger.Error("service not found", zap.String("service name", serviceName))
+			return models.RuleInput{}, &statusError{http.StatusNotFound, fmt.Errorf("service with name %s not found", serviceName)}
+		}
+		logger.Error("failed to get service", zap.String("service name", serviceName), zap.Error(err))
+		return models.RuleInput{}, err
+	}
+	logger.Debug("fetched service", zap.Any("service", service))
+
+	// only the owner can extend the expiry of the service, hence the extension is checked against the quota of the owner
+	if service.Spec.UserID != userID {
+		logger.Error("user is not the owner of service", zap.String("user id", userID), zap.String("service name", serviceName))
+		return models.RuleInput{}, &statusError{http.StatusForbidden, fmt.Errorf("user id: %s is not owner of service %s", userID, serviceName)}
+	}
+
+	// service shouldn't be extended it is already expired
+	if now.After(service.Spec.Expiry.Time) {
+		logger.Error("service expired", zap.String("service name", serviceName))
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, fmt.Errorf("Service %s is expired, can't extend the expiry", serviceName)}
+	}
+
+	// service shouldn't be extended if catalog already retired
+	catalog, err := kubeClient.GetCatalog(service.Spec.Catalog.Name)
+	if err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			logger.Error("catalog does not exists", zap.String("catalog name", service.Spec.Catalog.Name))
+			return models.RuleInput{}, &statusError{http.StatusNotFound, err}
+		}
+		logger.Error("failed to get catalog", zap.String("catalog name", service.Spec.Catalog.Name), zap.Error(err))
+		return models.RuleInput{}, err
+	}
+	if catalog.Spec.Retired {
+		logger.Debug("catalog is retired, can't extend the expiry", zap.Any("catalog", catalog))
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, fmt.Errorf("catalog %s is retired, can't extend the expiry", catalog.Name)}
+	}
+
+	// verify the extension is within the limits of the user quota
+	quota, err := getUserQuota(c)
+	if err != nil {
+		logger.Error("failed to get user quota", zap.String("userid", userID), zap.Error(err))
+		return models.RuleInput{}, &statusError{http.StatusBadRequest, err}
+	}
+	if err := validateExpiryExtension(userID, quota, service, catalog, request.ServiceExpiry.Expiry); err != nil {
+		if errors.Is(err, utils.ErrQuotaExceeded) {
+			logger.Error("expiry extension exceeds the user quota", zap.String("service name", serviceName), zap.Error(err))
+			return models.RuleInput{}, &statusError{http.StatusBadRequest, fmt.Errorf("can't extend the expiry of service %s, %w", serviceName, err)}
+		}
+		logger.Error("failed to validate expiry extension", zap.String("service name", serviceName), zap.Error(err))
+		return models.RuleInput{}, err
+	}
+
+	// verify already request exist for service extension
+	requests, err := dbCon.GetRequestByServiceName(serviceName)
+	if err != nil {
+		logger.Error("failed to fetch the request", zap.String("service name", serviceName), zap.Error(err))
+		return models.RuleInput{}, fmt.Errorf("failed to fetch the request from the db, err: %w", err)
+	}
+	for _, r := range requests {
+		if r.State == models.RequestStateNew {
+			logger.Error("user have already requested to extend service expiry")
+			return models.RuleInput{}, &statusError{http.StatusBadRequest, errors.New("You have already requested to extend service expiry")}
+		}
+	}
+	request.ServiceExpiry = &models.ServiceExpiry{Name: serviceName, Expiry: request.ServiceExpiry.Expiry}
+	return models.RuleInput{
+		ExtensionDays: request.ServiceExpiry.Expiry.Sub(now).Hours() / 24,
+		CatalogExpiry: catalog.Spec.Expiry,
+	}, nil
+}
+
+func (serviceExpiryRequestHandler) OnApprove(_ *gin.Context, request *models.Request) error {
+	logger := log.GetLogger()
+	if err := kubeClient.UpdateServiceExpiry(request.ServiceExpiry.Name, request.ServiceExpiry.Expiry); err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			logger.Error("service does not exists", zap.String("service name", request.ServiceExpiry.Name))
+			return &statusError{http.StatusNotFound, fmt.Errorf("service with name %s does not exists", request.ServiceExpiry.Name)}
+		}
+		logger.Error("failed to update service", zap.String("service name", request.ServiceExpiry.Name), zap.Error(err))
+		return err
+	}
+	if err := dbCon.UpdateServiceUsageExpiry(request.ServiceExpiry.Name, request.ServiceExpiry.Expiry); err != nil {
+		logger.Error("failed to update service usage", zap.String("service name", request.ServiceExpiry.Name), zap.Error(err))
+	}
+	return nil
+}
+
+func (serviceExpiryRequestHandler) OnReject(_ *gin.Context, _ *models.Request) error {
+	return nil
+}
+
+func (serviceExpiryRequestHandler) Describe(request *models.Request) string {
+	return fmt.Sprintf("to change the expiry of the service %s to %s", request.ServiceExpiry.Name, request.ServiceExpiry.Expiry.Format(time.RFC3339))
+}
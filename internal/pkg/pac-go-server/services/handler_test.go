@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name       string
+		mockFunc   func()
+		httpStatus int
+		request    *models.Request
+	}{
+		{
+			name: "group request created successfully",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).Times(1)
+				mockDBClient.EXPECT().GetRequestByGroupIDAndUserID("test-group", "12345").Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().NewRequest(gomock.Any()).DoAndReturn(func(request *models.Request) (string, error) {
+					assert.Equal(t, models.RequestAddToGroup, request.RequestType)
+					assert.Equal(t, "test-group", request.GroupAdmission.Group)
+					assert.Equal(t, "test-user", request.GroupAdmission.Requester)
+					return "123", nil
+				}).Times(1)
+				mockDBClient.EXPECT().GetRules().Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).DoAndReturn(func(event *models.Event) error {
+					assert.Equal(t, models.EventGroupJoinRequest, event.Type)
+					return nil
+				}).Times(1)
+			},
+			request: &models.Request{
+				Justification:  "justification",
+				RequestType:    models.RequestAddToGroup,
+				GroupAdmission: &models.GroupAdmission{GroupID: "test-group"},
+			},
+			httpStatus: http.StatusCreated,
+		},
+		{
+			name: "group of the request not found",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).Times(1)
+			},
+			request: &models.Request{
+				Justification:  "justification",
+				RequestType:    models.RequestAddToGroup,
+				GroupAdmission: &models.GroupAdmission{GroupID: "unknown-group"},
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:     "group of the request not set",
+			mockFunc: func() {},
+			request: &models.Request{
+				Justification: "justification",
+				RequestType:   models.RequestExitFromGroup,
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "user delete request not allowed",
+			mockFunc: func() {},
+			request: &models.Request{
+				Justification: "justification",
+				RequestType:   models.RequestDeleteUser,
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid request type",
+			mockFunc: func() {},
+			request: &models.Request{
+				Justification: "justification",
+				RequestType:   "UNKNOWN",
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			marshalledRequest, _ := json.Marshal(tc.request)
+			req, err := http.NewRequest(http.MethodPost, "/requests", bytes.NewBuffer(marshalledRequest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req.WithContext(getContext(formContext(customValues{"userid": "12345", "username": "test-user"})))
+			dbCon = mockDBClient
+			CreateRequest(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
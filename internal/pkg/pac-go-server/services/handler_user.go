@@ -0,0 +1,43 @@
+package services
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+func init() {
+	RegisterRequestHandler(models.RequestDeleteUser, models.EventDeletUserRequest, deleteUserRequestHandler{})
+}
+
+// deleteUserRequestHandler handles the requests to delete the user along with the personal information of the user
+type deleteUserRequestHandler struct{}
+
+func (deleteUserRequestHandler) Validate(_ *gin.Context, _ *models.Request) (models.RuleInput, error) {
+	return models.RuleInput{}, nil
+}
+
+func (deleteUserRequestHandler) OnApprove(c *gin.Context, request *models.Request) error {
+	logger := log.GetLogger()
+	config := client.GetConfigFromContext(c.Request.Context())
+	if err := client.NewKeyCloakClient(config, c.Request.Context()).DeleteUser(request.UserID); err != nil {
+		logger.Error("failed to delete user", zap.String("user id", request.UserID))
+		return err
+	}
+	if err := dbCon.DeleteTermsAndConditionsByUserID(request.UserID); err != nil {
+		logger.Error("failed to delete tnc status for user", zap.String("user id", request.UserID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (deleteUserRequestHandler) OnReject(_ *gin.Context, _ *models.Request) error {
+	return nil
+}
+
+func (deleteUserRequestHandler) Describe(_ *models.Request) string {
+	return "for deleting the user"
+}
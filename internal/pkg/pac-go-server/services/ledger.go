@@ -0,0 +1,137 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+// reservationGracePeriod is the time given to a reservation for its service to get created, reservations older than
+// this without a service are considered stale and released.
+const reservationGracePeriod = 5 * time.Minute
+
+// syncQuotaLedger reconciles the quota reserved by the user with the services provisioned by the user. Services which
+// are not reserved yet, e.g. provisioned before the ledger existed, are added to the ledger and the reservations of the
+// deleted or expired services are released.
+func syncQuotaLedger(userID string) error {
+	ledger, err := dbCon.GetQuotaLedger(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get quota ledger %v", err)
+	}
+	serviceList, err := kubeClient.GetServices(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user services %v", err)
+	}
+	return reconcileQuotaLedger(ledger, serviceList.Items, func(reservation models.QuotaReservation) error {
+		return dbCon.AddQuotaReservation(userID, reservation)
+	}, dbCon.ReleaseQuota)
+}
+
+// syncGroupQuotaLedger reconciles the pooled quota reserved by the members of the group with the services provisioned
+// by the members, the reservations of the members who left the group are released as well.
+func syncGroupQuotaLedger(groupID string, services []pac.Service) error {
+	ledger, err := dbCon.GetGroupQuotaLedger(groupID)
+	if err != nil {
+		return fmt.Errorf("failed to get group quota ledger %v", err)
+	}
+	return reconcileQuotaLedger(ledger, services, func(reservation models.QuotaReservation) error {
+		return dbCon.AddGroupQuotaReservation(groupID, reservation)
+	}, func(serviceName string) error {
+		return dbCon.ReleaseGroupQuota(groupID, serviceName)
+	})
+}
+
+// reconcileQuotaLedger adds the services not reserved in the ledger yet and releases the reservations of the services
+// not provisioned anymore
+func reconcileQuotaLedger(ledger *models.QuotaLedger, services []pac.Service, add func(models.QuotaReservation) error,
+	release func(string) error) error {
+	logger := log.GetLogger()
+	activeServices := make(map[string]bool)
+	for _, svc := range services {
+		// expired services are not accounted in the quota
+		if svc.Status.State == pac.ServiceStateExpired {
+			continue
+		}
+		activeServices[svc.Name] = true
+		if ledger.GetReservation(svc.Name) != nil {
+			continue
+		}
+		capacity, err := getServicesCapacity([]pac.Service{svc})
+		if err != nil {
+			return err
+		}
+		logger.Debug("adding reservation for the service", zap.String("service name", svc.Name), zap.Any("capacity", capacity))
+		if err := add(models.QuotaReservation{
+			ServiceName: svc.Name,
+			Capacity:    capacity,
+			ReservedAt:  svc.CreationTimestamp.Time,
+		}); err != nil {
+			return fmt.Errorf("failed to add reservation for service %s %v", svc.Name, err)
+		}
+	}
+
+	for _, reservation := range ledger.Reservations {
+		if activeServices[reservation.ServiceName] || time.Since(reservation.ReservedAt) < reservationGracePeriod {
+			continue
+		}
+		logger.Debug("releasing stale reservation", zap.String("service name", reservation.ServiceName))
+		if err := release(reservation.ServiceName); err != nil {
+			return fmt.Errorf("failed to release reservation for service %s %v", reservation.ServiceName, err)
+		}
+	}
+	return nil
+}
+
+// reservePooledQuota reserves the capacity for the service in the ledgers of the pooled groups of the user making the
+// request as well, so that the parallel requests of the members cannot overshoot the pool together. The quota granted to
+// the user is available on top of the pool, as it is for the allowance of the user.
+func reservePooledQuota(c *gin.Context, userID string, reservation models.QuotaReservation) error {
+	userGroups, _ := c.Request.Context().Value("groups").([]models.Group)
+	var groupIDs []string
+	for _, grp := range userGroups {
+		groupIDs = append(groupIDs, grp.ID)
+	}
+	if len(groupIDs) == 0 {
+		return nil
+	}
+	groupsQuota, err := dbCon.GetGroupsQuota(groupIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get quota %v", err)
+	}
+	var granted *models.Capacity
+	for _, quota := range groupsQuota {
+		if !quota.IsPooled() {
+			continue
+		}
+		if granted == nil {
+			userQuotas, err := dbCon.GetActiveUserQuotas(userID)
+			if err != nil {
+				return fmt.Errorf("failed to get user granted quota %v", err)
+			}
+			granted = &models.Capacity{}
+			for _, grant := range userQuotas {
+				granted.CPU += grant.Capacity.CPU
+				granted.Memory += grant.Capacity.Memory
+			}
+		}
+		services, err := getGroupServices(c, quota.GroupID)
+		if err != nil {
+			return err
+		}
+		if err := syncGroupQuotaLedger(quota.GroupID, services); err != nil {
+			return err
+		}
+		// only CPU and memory are pooled
+		limit := models.Capacity{CPU: quota.Capacity.CPU + granted.CPU, Memory: quota.Capacity.Memory + granted.Memory}
+		if err := dbCon.ReserveGroupQuota(quota.GroupID, reservation, limit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -50,6 +50,7 @@ func raiseNotification() {
 		if isServiceExpired(service) {
 			logger.Debug("service is expired, expiry notification is not required", zap.Any("service", service.Name))
 			updateExpiryRequestinDB(service.Name)
+			recordServiceExpiry(service)
 			generateEvent(service, models.EventServiceExpiredNotification, fmt.Sprintf(serviceExpiredMsg, service.Name))
 			continue
 		}
@@ -70,6 +71,9 @@ func generateEvent(service models.Service, eventType models.EventType, eventLog
 		return
 	}
 	event.SetLog(models.EventLogLevelINFO, eventLog)
+	event.SetResource(models.EventResourceService, "", service.Name)
+	event.SetMetadata(models.EventMetadataServiceName, service.Name)
+	event.SetMetadata(models.EventMetadataExpiry, service.Expiry.Format(time.RFC3339))
 	if err := dbCon.NewEvent(event); err != nil {
 		log.GetLogger().Error("failed to create event", zap.Error(err))
 		return
@@ -96,9 +100,11 @@ func updateExpiryRequestinDB(serviceName string) {
 	// Updating state of service-expiry-extension request to "EXPIRED" since associated service is expired
 	logger.Debug("fetched request", zap.Any("request", req))
 	for _, request := range req {
-		if request.RequestType == models.RequestExtendServiceExpiry && request.State != models.RequestStateExpired {
-			if err := dbCon.UpdateRequestStateWithComment(request.ID.String(), models.RequestStateExpired, requestExpiryMsg); err != nil {
-				logger.Error("failed to update request in database", zap.String("id", request.ID.String()), zap.Error(err))
+		if request.RequestType == models.RequestExtendServiceExpiry && request.State != models.RequestStateExpired &&
+			request.State != models.RequestStateDeleted {
+			transition := models.NewRequestTransition(&request, models.RequestStateExpired, models.RequestActorSystem, requestExpiryMsg)
+			if err := dbCon.UpdateRequestState(request.ID.Hex(), transition); err != nil {
+				logger.Error("failed to update request in database", zap.String("id", request.ID.Hex()), zap.Error(err))
 			}
 		}
 
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+// GetNotificationPreferences	godoc
+// @Summary			Get notification preferences
+// @Description		Get the channel the events of every type are notified to the user through
+// @Tags			notifications
+// @Accept			json
+// @Produce			json
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			200
+// @Router			/api/v1/me/notifications [get]
+func GetNotificationPreferences(c *gin.Context) {
+	logger := log.GetLogger()
+	userID := c.Request.Context().Value("userid").(string)
+	preferences, err := dbCon.GetNotificationPreferences(userID)
+	if err != nil && !errors.Is(err, utils.ErrResourceNotFound) {
+		logger.Error("failed to get notification preferences", zap.String("user id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get notification preferences, err: %s", err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, getNotificationPreferencesResponse(preferences))
+}
+
+// UpdateNotificationPreferences	godoc
+// @Summary			Update notification preferences
+// @Description		Update the channel the events are notified to the user through, per event type. The channels are one of EMAIL, IN_APP, DIGEST and MUTED, the mandatory event types are always mailed
+// @Tags			notifications
+// @Accept			json
+// @Produce			json
+// @Param			preferences body models.NotificationPreferences true "channels per event type"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			200
+// @Router			/api/v1/me/notifications [put]
+func UpdateNotificationPreferences(c *gin.Context) {
+	logger := log.GetLogger()
+	userID := c.Request.Context().Value("userid").(string)
+	var preferences models.NotificationPreferences
+	if err := c.BindJSON(&preferences); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to load the preferences, err: %s", err.Error())})
+		return
+	}
+	if err := validateNotificationPreferences(preferences); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the mandatory types are mailed regardless, no need to store them
+	for eventType := range preferences.Channels {
+		if models.IsMandatoryEventType(eventType) {
+			delete(preferences.Channels, eventType)
+		}
+	}
+	now := time.Now()
+	preferences.UserID = userID
+	preferences.UpdatedAt = &now
+	if err := dbCon.UpdateNotificationPreferences(&preferences); err != nil {
+		logger.Error("failed to update notification preferences", zap.String("user id", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update notification preferences, err: %s", err.Error())})
+		return
+	}
+	c.JSON(http.StatusOK, getNotificationPreferencesResponse(&preferences))
+}
+
+// validateNotificationPreferences validates the event types and the channels, the mandatory event types can only be
+// mailed
+func validateNotificationPreferences(preferences models.NotificationPreferences) error {
+	for eventType, channel := range preferences.Channels {
+		if !models.IsValidEventType(eventType) {
+			return fmt.Errorf("invalid event type - %s", eventType)
+		}
+		if !isValidNotificationChannel(channel) {
+			return fmt.Errorf("invalid channel %s for event type %s, valid values are %v", channel, eventType, models.NotificationChannels)
+		}
+		if models.IsMandatoryEventType(eventType) && channel != models.NotificationChannelEmail {
+			return fmt.Errorf("event type %s is mandatory, it is always notified through %s", eventType, models.NotificationChannelEmail)
+		}
+	}
+	return nil
+}
+
+// getNotificationPreferencesResponse returns the channel of every event type, the preferences can be nil if the user
+// has not set any
+func getNotificationPreferencesResponse(preferences *models.NotificationPreferences) models.NotificationPreferencesResponse {
+	response := models.NotificationPreferencesResponse{
+		Channels:  make(map[models.EventType]models.NotificationChannel, len(models.EventTypes)),
+		Mandatory: models.MandatoryEventTypes,
+	}
+	for _, eventType := range models.EventTypes {
+		response.Channels[eventType] = preferences.GetChannel(eventType)
+	}
+	if preferences != nil {
+		response.UpdatedAt = preferences.UpdatedAt
+	}
+	return response
+}
+
+func isValidNotificationChannel(channel models.NotificationChannel) bool {
+	for _, ch := range models.NotificationChannels {
+		if ch == channel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNotificationPreferences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, _, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name         string
+		mockFunc     func()
+		httpStatus   int
+		wantChannels map[models.EventType]models.NotificationChannel
+	}{
+		{
+			name: "preferences fetched",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetNotificationPreferences("12345").Return(getResource("get-notification-preferences", nil).(*models.NotificationPreferences), nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			wantChannels: map[models.EventType]models.NotificationChannel{
+				models.EventTypeRequestComment:         models.NotificationChannelInApp,
+				models.EventQuotaThreshold:             models.NotificationChannelDigest,
+				models.EventTypeRequestApproved:        models.NotificationChannelEmail,
+				models.EventServiceExpiredNotification: models.NotificationChannelEmail,
+			},
+		},
+		{
+			name: "preferences defaulted if not set",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetNotificationPreferences("12345").Return(nil, utils.ErrResourceNotFound).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			wantChannels: map[models.EventType]models.NotificationChannel{
+				models.EventTypeRequestComment: models.NotificationChannelEmail,
+			},
+		},
+		{
+			name: "failed to get preferences",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetNotificationPreferences("12345").Return(nil, errors.New("error getting notification preferences")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			req, err := http.NewRequest(http.MethodGet, "/me/notifications", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req.WithContext(getContext(formContext(customValues{"userid": "12345"})))
+			dbCon = mockDBClient
+			GetNotificationPreferences(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+			if tc.httpStatus != http.StatusOK {
+				return
+			}
+			var response models.NotificationPreferencesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			assert.Len(t, response.Channels, len(models.EventTypes))
+			for eventType, channel := range tc.wantChannels {
+				assert.Equal(t, channel, response.Channels[eventType], eventType)
+			}
+			assert.Equal(t, models.MandatoryEventTypes, response.Mandatory)
+		})
+	}
+}
+
+func TestUpdateNotificationPreferences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, _, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name       string
+		mockFunc   func()
+		channels   map[models.EventType]models.NotificationChannel
+		httpStatus int
+	}{
+		{
+			name: "preferences updated",
+			mockFunc: func() {
+				mockDBClient.EXPECT().UpdateNotificationPreferences(gomock.Any()).DoAndReturn(
+					func(preferences *models.NotificationPreferences) error {
+						assert.Equal(t, "12345", preferences.UserID)
+						assert.NotNil(t, preferences.UpdatedAt)
+						assert.Equal(t, map[models.EventType]models.NotificationChannel{
+							models.EventTypeRequestComment: models.NotificationChannelMuted,
+						}, preferences.Channels)
+						return nil
+					}).Times(1)
+			},
+			channels: map[models.EventType]models.NotificationChannel{
+				models.EventTypeRequestComment:         models.NotificationChannelMuted,
+				models.EventServiceExpiredNotification: models.NotificationChannelEmail,
+			},
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:     "mandatory event type muted",
+			mockFunc: func() {},
+			channels: map[models.EventType]models.NotificationChannel{
+				models.EventServiceExpiredNotification: models.NotificationChannelMuted,
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid channel",
+			mockFunc: func() {},
+			channels: map[models.EventType]models.NotificationChannel{
+				models.EventTypeRequestComment: "SMS",
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid event type",
+			mockFunc: func() {},
+			channels: map[models.EventType]models.NotificationChannel{
+				"UNKNOWN": models.NotificationChannelEmail,
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failed to update preferences",
+			mockFunc: func() {
+				mockDBClient.EXPECT().UpdateNotificationPreferences(gomock.Any()).Return(errors.New("error updating notification preferences")).Times(1)
+			},
+			channels: map[models.EventType]models.NotificationChannel{
+				models.EventTypeRequestComment: models.NotificationChannelDigest,
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			marshalledRequest, _ := json.Marshal(models.NotificationPreferences{Channels: tc.channels})
+			req, err := http.NewRequest(http.MethodPut, "/me/notifications", bytes.NewBuffer(marshalledRequest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req.WithContext(getContext(formContext(customValues{"userid": "12345"})))
+			dbCon = mockDBClient
+			UpdateNotificationPreferences(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+var errQuotaLimitExceeded = errors.New("quota exceeds the limit set by the admin")
+
+// GetGroupOwners		godoc
+// @Summary			Get group owners
+// @Description		Get the owners administering the group and the quota limit set on the group
+// @Tags			groups
+// @Accept			json
+// @Produce			json
+// @Param			id path string true "group-id of the group"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			200
+// @Router			/api/v1/groups/{id}/owners [get]
+func GetGroupOwners(c *gin.Context) {
+	logger := log.GetLogger()
+	gid := c.Param("id")
+	ownership, err := dbCon.GetGroupOwnership(gid)
+	if err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("the group ID %s does not have owners", gid)})
+			return
+		}
+		logger.Error("failed to get group ownership", zap.String("group id", gid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, ownership)
+}
+
+// UpdateGroupOwners	godoc
+// @Summary			Update group owners
+// @Description		Set the owners administering the group and the quota limit the owners can set on the group
+// @Tags			groups
+// @Accept			json
+// @Produce			json
+// @Param			ownership body models.GroupOwnership true "Update group owners"
+// @Param			id path string true "group-id of the group"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			204
+// @Router			/api/v1/groups/{id}/owners [put]
+func UpdateGroupOwners(c *gin.Context) {
+	originator := c.Request.Context().Value("userid").(string)
+	logger := log.GetLogger()
+	gid := c.Param("id")
+	if err := checkGroupExists(c, gid); err != nil {
+		if errors.Is(err, client.ErrorGroupNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("The group ID %s does not exist.", gid)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("error occured while fetching group with id %s, err: %s", gid, err)})
+		return
+	}
+
+	var ownership models.GroupOwnership
+	if err := c.BindJSON(&ownership); err != nil {
+		logger.Error("failed to bind group ownership", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to load the body, please feed the proper json body: %s", err)})
+		return
+	}
+	if ownership.GroupID != "" && ownership.GroupID != gid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "GroupID must not be set in the request body, or must match the one set in request path."})
+		return
+	}
+	if err := validateGroupOwnership(c, &ownership); err != nil {
+		logger.Error("group ownership validation has failed", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ownership.GroupID = gid
+	ownership.UpdatedBy = originator
+	ownership.UpdatedAt = time.Now()
+	if err := dbCon.UpdateGroupOwnership(&ownership); err != nil {
+		logger.Error("failed to update group ownership", zap.String("group id", gid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update the group owners, err: %s", err.Error())})
+		return
+	}
+	logger.Info("updated group owners", zap.String("group id", gid), zap.Strings("owners", ownership.Owners))
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteGroupOwners	godoc
+// @Summary			Delete group owners
+// @Description		Revoke the delegated administration of the group
+// @Tags			groups
+// @Accept			json
+// @Produce			json
+// @Param			id path string true "group-id of the group"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			204
+// @Router			/api/v1/groups/{id}/owners [delete]
+func DeleteGroupOwners(c *gin.Context) {
+	logger := log.GetLogger()
+	gid := c.Param("id")
+	if err := dbCon.DeleteGroupOwnership(gid); err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("the group ID %s does not have owners", gid)})
+			return
+		}
+		logger.Error("failed to delete group ownership", zap.String("group id", gid), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to delete the group owners, err: %s", err.Error())})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func validateGroupOwnership(c *gin.Context, ownership *models.GroupOwnership) error {
+	if len(ownership.Owners) == 0 {
+		return errors.New("at least one owner is required")
+	}
+	slices.Sort(ownership.Owners)
+	ownership.Owners = slices.Compact(ownership.Owners)
+	if ownership.QuotaLimit == nil {
+		return nil
+	}
+	if err := utils.ValidateQuotaFields(c, ownership.QuotaLimit.CPU, ownership.QuotaLimit.Memory); err != nil {
+		return err
+	}
+	if ownership.QuotaLimit.Services < 0 || ownership.QuotaLimit.MaxExtensionDays < 0 || ownership.QuotaLimit.CoreHours < 0 {
+		return errors.New("services, max extension days and core hours cannot be negative")
+	}
+	return nil
+}
+
+// IsGroupOwner returns true if the user is an owner of the group
+func IsGroupOwner(userID, groupID string) (bool, error) {
+	ownership, err := dbCon.GetGroupOwnership(groupID)
+	if err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return ownership.IsOwner(userID), nil
+}
+
+// canDecideRequest returns true if the user making the request can approve or reject the request, i.e. the user is an
+// admin or an owner of the group targeted by the request
+func canDecideRequest(c *gin.Context, request *models.Request) (bool, error) {
+	config := client.GetConfigFromContext(c.Request.Context())
+	kc := client.NewKeyCloakClient(config, c.Request.Context())
+	if kc.IsRole(utils.ManagerRole) {
+		return true, nil
+	}
+	// group owners decide only the requests to join or exit their groups
+	if request.RequestType != models.RequestAddToGroup && request.RequestType != models.RequestExitFromGroup {
+		return false, nil
+	}
+	if request.GroupAdmission == nil || request.GroupAdmission.GroupID == "" {
+		return false, nil
+	}
+	return IsGroupOwner(c.Request.Context().Value("userid").(string), request.GroupAdmission.GroupID)
+}
+
+// getOwnedGroupIDs returns the IDs of the groups owned by the user
+func getOwnedGroupIDs(userID string) ([]string, error) {
+	ownerships, err := dbCon.GetOwnedGroups(userID)
+	if err != nil {
+		return nil, err
+	}
+	var groupIDs []string
+	for _, ownership := range ownerships {
+		groupIDs = append(groupIDs, ownership.GroupID)
+	}
+	return groupIDs, nil
+}
+
+// notifyGroupOwners raises an event for every owner of the group targeted by the request
+func notifyGroupOwners(request *models.Request, originator string, eventType models.EventType, message string) {
+	logger := log.GetLogger()
+	if request.RequestType != models.RequestAddToGroup && request.RequestType != models.RequestExitFromGroup {
+		return
+	}
+	if request.GroupAdmission == nil || request.GroupAdmission.GroupID == "" {
+		return
+	}
+	ownership, err := dbCon.GetGroupOwnership(request.GroupAdmission.GroupID)
+	if err != nil {
+		if !errors.Is(err, utils.ErrResourceNotFound) {
+			logger.Error("failed to get group owners", zap.String("group id", request.GroupAdmission.GroupID), zap.Error(err))
+		}
+		return
+	}
+	for _, owner := range ownership.Owners {
+		if owner == originator {
+			continue
+		}
+		event, err := models.NewEvent(owner, originator, eventType)
+		if err != nil {
+			logger.Error("failed to create event", zap.Error(err))
+			continue
+		}
+		event.SetNotify()
+		event.SetLog(models.EventLogLevelINFO, message)
+		setRequestResource(event, request.ID.Hex(), request)
+		if err := dbCon.NewEvent(event); err != nil {
+			logger.Error("failed to create event", zap.String("owner", owner), zap.Error(err))
+		}
+	}
+}
+
+// checkQuotaLimit validates the group quota set by a group owner against the limit set by the admin, the limits not
+// set by the admin cannot be lifted by the owner, nor can the type of the quota be changed
+func checkQuotaLimit(c *gin.Context, gid string, quota models.Quota) error {
+	config := client.GetConfigFromContext(c.Request.Context())
+	kc := client.NewKeyCloakClient(config, c.Request.Context())
+	if kc.IsRole(utils.ManagerRole) {
+		return nil
+	}
+	ownership, err := dbCon.GetGroupOwnership(gid)
+	if err != nil {
+		return err
+	}
+	if ownership.QuotaLimit == nil {
+		return fmt.Errorf("%w, quota limit is not set for the group", errQuotaLimitExceeded)
+	}
+	limit := ownership.QuotaLimit
+	if quota.Capacity.CPU > limit.CPU || quota.Capacity.Memory > limit.Memory {
+		return fmt.Errorf("%w, cpu and memory cannot be more than %v and %d", errQuotaLimitExceeded, limit.CPU, limit.Memory)
+	}
+	// 0 denotes no limit, hence an owner has to set the limits set by the admin
+	if exceedsLimit(quota.Capacity.Services, limit.Services) || exceedsLimit(quota.Capacity.MaxExtensionDays, limit.MaxExtensionDays) ||
+		exceedsLimit(quota.Capacity.CoreHours, limit.CoreHours) {
+		return fmt.Errorf("%w, services, max extension days and core hours cannot be more than %d, %d and %v",
+			errQuotaLimitExceeded, limit.Services, limit.MaxExtensionDays, limit.CoreHours)
+	}
+	// the limit caps the pool of a POOLED quota but every member of a PER_USER quota, hence the type of the quota is
+	// changed by the admin only, the member capacity stays within the pool anyway
+	current, err := dbCon.GetQuotaForGroupID(gid)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	if quota.IsPooled() != (current != nil && current.IsPooled()) {
+		return fmt.Errorf("%w, type of the quota can be changed by the admin only", errQuotaLimitExceeded)
+	}
+	return nil
+}
+
+// exceedsLimit returns true if the value is more than the limit, where 0 denotes no limit
+func exceedsLimit[T int | float64](value, limit T) bool {
+	return limit != 0 && (value == 0 || value > limit)
+}
@@ -1,14 +1,17 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 
+	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
 	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
@@ -48,6 +51,20 @@ func GetQuota(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "A quota policy does not exist for this group ID. You need to create one first."})
 		return
 	}
+	if quotaDb.IsPooled() {
+		used, err := getGroupUsedQuota(c, gid)
+		if err != nil {
+			logger.Error("failed to get used quota of the group", zap.String("group id", gid), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get used quota of the group, err: %s", err.Error())})
+			return
+		}
+		available := subtractCapacity(quotaDb.Capacity, used)
+		// only CPU and memory are pooled, the rest of the limits are available to every member individually
+		available.Services = quotaDb.Capacity.Services
+		available.CoreHours = quotaDb.Capacity.CoreHours
+		quotaDb.Used = &used
+		quotaDb.Available = &available
+	}
 	c.JSON(http.StatusOK, &quotaDb)
 }
 
@@ -89,12 +106,23 @@ func CreateQuota(c *gin.Context) {
 		return
 	}
 
-	if err := utils.ValidateQuotaFields(c, quota.Capacity.CPU, quota.Capacity.Memory); err != nil {
+	if err := validateQuotaParams(c, &quota); err != nil {
 		logger.Error("quota validation has failed", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// group owners can set the quota only within the limit set by the admin
+	if err := checkQuotaLimit(c, gid, quota); err != nil {
+		logger.Error("quota limit check has failed", zap.String("group id", gid), zap.Error(err))
+		if errors.Is(err, errQuotaLimitExceeded) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to check the quota limit, err: %s", err.Error())})
+		return
+	}
+
 	// TODO: check if the quota for the particular group first exists, before updating
 	quotaDb, err := dbCon.GetQuotaForGroupID(gid)
 	if err != nil && errors.Unwrap(err) != mongo.ErrNoDocuments {
@@ -107,8 +135,10 @@ func CreateQuota(c *gin.Context) {
 		return
 	}
 	if err := dbCon.NewQuota(&models.Quota{
-		GroupID:  gid,
-		Capacity: quota.Capacity,
+		GroupID:        gid,
+		Capacity:       quota.Capacity,
+		Type:           quota.Type,
+		MemberCapacity: quota.MemberCapacity,
 	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to insert the quota into the database, Error: %s", err.Error())})
 		return
@@ -156,12 +186,23 @@ func UpdateQuota(c *gin.Context) {
 		return
 	}
 
-	if err := utils.ValidateQuotaFields(c, quota.Capacity.CPU, quota.Capacity.Memory); err != nil {
+	if err := validateQuotaParams(c, &quota); err != nil {
 		logger.Error("quota validation has failed", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// group owners can set the quota only within the limit set by the admin
+	if err := checkQuotaLimit(c, gid, quota); err != nil {
+		logger.Error("quota limit check has failed", zap.String("group id", gid), zap.Error(err))
+		if errors.Is(err, errQuotaLimitExceeded) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to check the quota limit, err: %s", err.Error())})
+		return
+	}
+
 	// TODO: check if the quota for the particular group first exists, before updating
 	quotaDb, err := dbCon.GetQuotaForGroupID(gid)
 	if err != nil && err != mongo.ErrNoDocuments {
@@ -174,8 +215,10 @@ func UpdateQuota(c *gin.Context) {
 		return
 	}
 	if err := dbCon.UpdateQuota(&models.Quota{
-		GroupID:  gid,
-		Capacity: quota.Capacity,
+		GroupID:        gid,
+		Capacity:       quota.Capacity,
+		Type:           quota.Type,
+		MemberCapacity: quota.MemberCapacity,
 	}); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to insert the quota into the database, Error: %s", err.Error())})
 		return
@@ -234,6 +277,36 @@ func checkGroupExists(c *gin.Context, gid string) error {
 	return nil
 }
 
+// validateQuotaParams validates the quota set by admin and defaults the quota type to PER_USER
+func validateQuotaParams(c *gin.Context, quota *models.Quota) error {
+	if err := utils.ValidateQuotaFields(c, quota.Capacity.CPU, quota.Capacity.Memory); err != nil {
+		return err
+	}
+	if quota.Capacity.Services < 0 || quota.Capacity.MaxExtensionDays < 0 || quota.Capacity.CoreHours < 0 {
+		return errors.New("services, max extension days and core hours cannot be negative")
+	}
+	switch quota.Type {
+	case "":
+		quota.Type = models.QuotaTypePerUser
+	case models.QuotaTypePerUser, models.QuotaTypePooled:
+	default:
+		return fmt.Errorf("invalid quota type: \"%s\" is set, valid values are %s %s", quota.Type, models.QuotaTypePerUser, models.QuotaTypePooled)
+	}
+	if quota.MemberCapacity == nil {
+		return nil
+	}
+	if !quota.IsPooled() {
+		return fmt.Errorf("member capacity can only be set for %s quota", models.QuotaTypePooled)
+	}
+	if err := utils.ValidateQuotaFields(c, quota.MemberCapacity.CPU, quota.MemberCapacity.Memory); err != nil {
+		return err
+	}
+	if quota.MemberCapacity.CPU > quota.Capacity.CPU || quota.MemberCapacity.Memory > quota.Capacity.Memory {
+		return errors.New("member capacity cannot be more than the group capacity")
+	}
+	return nil
+}
+
 // GetUserQuota			godoc
 // @Summary				Get user quota
 // @Description			Get user quota
@@ -262,41 +335,53 @@ func GetUserQuota(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to get used quota %v", err)})
 		return
 	}
-	availableQuota.CPU = userQuota.CPU - usedQuota.CPU
-	availableQuota.Memory = userQuota.Memory - usedQuota.Memory
-
-	// in case of negative available quota set it to 0
-	if availableQuota.CPU < 0 {
-		availableQuota.CPU = 0
-	}
-	if availableQuota.Memory < 0 {
-		availableQuota.Memory = 0
+	monthStart, monthEnd := getMonthBounds(time.Now())
+	usedQuota.CoreHours, err = getCommittedCoreHours(userID, monthStart, monthEnd)
+	if err != nil {
+		logger.Error("failed to get used core-hours", zap.String("userid", userID), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get used core-hours %v", err)})
+		return
 	}
+	// in case of negative available quota set it to 0
+	availableQuota = subtractCapacity(userQuota, usedQuota)
 	logger.Debug("quotas of user", zap.Any("user quota", userQuota), zap.Any("used quota", usedQuota), zap.Any("available quota", availableQuota))
 	c.JSON(http.StatusOK, gin.H{"user_quota": userQuota, "used_quota": usedQuota, "available_quota": availableQuota})
 }
 
 func getMaxCapacity(quotas []models.Quota) models.Capacity {
-	var maxCPU float64
-	var maxMemory int
+	var maxCapacity models.Capacity
 
-	for _, quota := range quotas {
-		if quota.Capacity.CPU > maxCPU {
-			maxCPU = quota.Capacity.CPU
+	for i, quota := range quotas {
+		if quota.Capacity.CPU > maxCapacity.CPU {
+			maxCapacity.CPU = quota.Capacity.CPU
 		}
-		if quota.Capacity.Memory > maxMemory {
-			maxMemory = quota.Capacity.Memory
+		if quota.Capacity.Memory > maxCapacity.Memory {
+			maxCapacity.Memory = quota.Capacity.Memory
 		}
+		// limits which are not set mean no limit, hence a group without the limit takes precedence
+		if i == 0 {
+			maxCapacity.Services = quota.Capacity.Services
+			maxCapacity.MaxExtensionDays = quota.Capacity.MaxExtensionDays
+			maxCapacity.CoreHours = quota.Capacity.CoreHours
+			continue
+		}
+		maxCapacity.Services = maxLimit(maxCapacity.Services, quota.Capacity.Services)
+		maxCapacity.MaxExtensionDays = maxLimit(maxCapacity.MaxExtensionDays, quota.Capacity.MaxExtensionDays)
+		maxCapacity.CoreHours = maxLimit(maxCapacity.CoreHours, quota.Capacity.CoreHours)
 	}
-	return models.Capacity{
-		CPU:    maxCPU,
-		Memory: maxMemory,
+	return maxCapacity
+}
+
+// maxLimit returns the larger of the limits, where 0 denotes no limit
+func maxLimit[T int | float64](a, b T) T {
+	if a == 0 || b == 0 {
+		return 0
 	}
+	return max(a, b)
 }
 
 func getUserQuota(c *gin.Context) (models.Capacity, error) {
 	logger := log.GetLogger()
-	var userQuota models.Capacity
 	config := client.GetConfigFromContext(c.Request.Context())
 	kc := client.NewKeyCloakClient(config, c.Request.Context())
 	userID := kc.GetUserID()
@@ -304,23 +389,154 @@ func getUserQuota(c *gin.Context) (models.Capacity, error) {
 	userGroups := c.Request.Context().Value("groups").([]models.Group)
 	logger.Debug("user groups", zap.Any("user groups", userGroups))
 
-	if len(userGroups) == 0 {
-		logger.Debug("user does not belong to any group", zap.String("user id", userID))
-		return userQuota, nil
-
-	}
 	logger.Debug("fetching user quota for groups")
 	var userGroupIds []string
 	for _, grp := range userGroups {
 		userGroupIds = append(userGroupIds, grp.ID)
 	}
-	groupsQuota, err := dbCon.GetGroupsQuota(userGroupIds)
-	if err != nil {
-		logger.Error("failed to get quota", zap.String("user id", userID), zap.Error(err))
-		return userQuota, err
+	return getGroupsUserQuota(c, userID, userGroupIds)
+}
+
+// getGroupsUserQuota returns the quota of the user as a member of the groups, including the quota granted to the user
+// for a limited time
+func getGroupsUserQuota(c *gin.Context, userID string, groupIDs []string) (models.Capacity, error) {
+	logger := log.GetLogger()
+	var userQuota models.Capacity
+	var groupsQuota []models.Quota
+	var err error
+	// the user without groups has only the quota granted
+	if len(groupIDs) > 0 {
+		if groupsQuota, err = dbCon.GetGroupsQuota(groupIDs); err != nil {
+			logger.Error("failed to get quota", zap.String("user id", userID), zap.Error(err))
+			return userQuota, err
+		}
 	}
 	logger.Debug("user group quota", zap.String("user id", userID), zap.Any("group quota", groupsQuota))
+	for i, groupQuota := range groupsQuota {
+		if !groupQuota.IsPooled() {
+			continue
+		}
+		allowance, err := getPooledQuotaAllowance(c, userID, groupQuota)
+		if err != nil {
+			logger.Error("failed to get pooled quota allowance", zap.String("user id", userID), zap.String("group id", groupQuota.GroupID), zap.Error(err))
+			return userQuota, err
+		}
+		groupsQuota[i].Capacity = allowance
+	}
 	userQuota = getMaxCapacity(groupsQuota)
 	logger.Debug("user maximum quota", zap.Any("user maximum quota", userQuota))
+
+	// add the quota granted to the user for a limited time through quota increase requests
+	userQuotas, err := dbCon.GetActiveUserQuotas(userID)
+	if err != nil {
+		logger.Error("failed to get user granted quota", zap.String("user id", userID), zap.Error(err))
+		return userQuota, err
+	}
+	var granted models.Capacity
+	for _, grant := range userQuotas {
+		granted.CPU += grant.Capacity.CPU
+		granted.Memory += grant.Capacity.Memory
+		granted.Services += grant.Capacity.Services
+		granted.CoreHours += grant.Capacity.CoreHours
+	}
+	userQuota.CPU += granted.CPU
+	userQuota.Memory += granted.Memory
+	if len(groupsQuota) == 0 {
+		// the user without any group quota has only the limits granted, a limit which is not granted means that nothing
+		// can be run rather than no limit
+		if granted.Services == 0 || granted.CoreHours == 0 {
+			logger.Debug("user has no services or core-hours granted", zap.String("user id", userID), zap.Any("granted quota", granted))
+			return models.Capacity{}, nil
+		}
+		userQuota.Services = granted.Services
+		userQuota.CoreHours = granted.CoreHours
+	} else {
+		// limits which are not set mean no limit, the granted limits only raise the limits which are set
+		if userQuota.Services > 0 {
+			userQuota.Services += granted.Services
+		}
+		if userQuota.CoreHours > 0 {
+			userQuota.CoreHours += granted.CoreHours
+		}
+	}
+	logger.Debug("user quota including granted quota", zap.Any("user quota", userQuota))
 	return userQuota, nil
 }
+
+// getPooledQuotaAllowance returns the capacity the user can hold out of a pooled group quota, i.e. the capacity already
+// consumed by the user plus whatever is left in the pool, limited by the member capacity of the quota if set.
+func getPooledQuotaAllowance(c *gin.Context, userID string, quota models.Quota) (models.Capacity, error) {
+	groupUsed, err := getGroupUsedQuota(c, quota.GroupID)
+	if err != nil {
+		return models.Capacity{}, err
+	}
+	userUsed, err := getUsedQuota(userID)
+	if err != nil {
+		return models.Capacity{}, err
+	}
+	// only CPU and memory are pooled, the rest of the limits apply to every member individually
+	allowance := quota.Capacity
+	allowance.CPU = quota.Capacity.CPU - groupUsed.CPU + userUsed.CPU
+	allowance.Memory = quota.Capacity.Memory - groupUsed.Memory + userUsed.Memory
+	if quota.MemberCapacity != nil {
+		allowance.CPU = min(allowance.CPU, quota.MemberCapacity.CPU)
+		allowance.Memory = min(allowance.Memory, quota.MemberCapacity.Memory)
+	}
+	return allowance, nil
+}
+
+// getGroupUsedQuota calculates and returns the total capacity consumed by the services of all the members of the group
+func getGroupUsedQuota(c *gin.Context, groupID string) (models.Capacity, error) {
+	groupServices, err := getGroupServices(c, groupID)
+	if err != nil {
+		return models.Capacity{}, err
+	}
+	return getServicesCapacity(groupServices)
+}
+
+// getGroupServices returns the services of all the members of the group
+func getGroupServices(c *gin.Context, groupID string) ([]pac.Service, error) {
+	memberIDs, err := getGroupMemberIDs(c.Request.Context(), groupID)
+	if err != nil {
+		return nil, err
+	}
+	serviceList, err := kubeClient.GetServices("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services %v", err)
+	}
+	return filterServicesByUsers(serviceList.Items, memberIDs...), nil
+}
+
+// getGroupMemberIDs returns the IDs of the members of the group, the members are listed through the service account as
+// the members of the group aren't allowed to list them
+func getGroupMemberIDs(ctx context.Context, groupID string) ([]string, error) {
+	kc, err := client.NewServiceAccountClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := kc.GetGroupMembers(groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members of the group %s %v", groupID, err)
+	}
+	var memberIDs []string
+	for _, member := range members {
+		memberIDs = append(memberIDs, *member.ID)
+	}
+	return memberIDs, nil
+}
+
+// subtractCapacity returns the capacity left after consuming used from total, negative values are set to 0
+func subtractCapacity(total, used models.Capacity) models.Capacity {
+	available := models.Capacity{
+		CPU:              max(total.CPU-used.CPU, 0),
+		Memory:           max(total.Memory-used.Memory, 0),
+		MaxExtensionDays: total.MaxExtensionDays,
+	}
+	if total.Services > 0 {
+		available.Services = max(total.Services-used.Services, 0)
+	}
+	if total.CoreHours > 0 {
+		available.CoreHours = max(total.CoreHours-used.CoreHours, 0)
+	}
+	return available
+}
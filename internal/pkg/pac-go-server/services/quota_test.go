@@ -10,6 +10,7 @@ import (
 	"github.com/Nerzal/gocloak/v13"
 	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -17,7 +18,7 @@ import (
 
 func TestGetQuota(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	_, mockDBClient, mockKCClient, tearDown := setUp(t)
+	mockClient, mockDBClient, mockKCClient, tearDown := setUp(t)
 	defer tearDown()
 
 	testcases := []struct {
@@ -26,6 +27,7 @@ func TestGetQuota(t *testing.T) {
 		requestContext testContext
 		httpStatus     int
 		requestParams  gin.Param
+		available      *models.Capacity
 	}{
 		{
 			name: "quota fetched successfully",
@@ -51,11 +53,28 @@ func TestGetQuota(t *testing.T) {
 			httpStatus:    http.StatusNotFound,
 			requestParams: gin.Param{Key: "id", Value: "test-group"},
 		},
+		{
+			name: "pooled quota fetched with group usage",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+				mockDBClient.EXPECT().GetQuotaForGroupID(gomock.Any()).Return(getResource("create-quota", customValues{
+					"Type":     models.QuotaTypePooled,
+					"Capacity": models.Capacity{CPU: 10, Memory: 10},
+				}).(*models.Quota), nil).Times(1)
+				mockKCClient.EXPECT().GetGroupMembers("test-group").Return([]*gocloak.User{{ID: utils.Ptr("test-user")}}, nil).Times(1)
+				mockClient.EXPECT().GetServices("").Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(1)
+				mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).Times(1)
+			},
+			httpStatus:    http.StatusOK,
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			available:     &models.Capacity{CPU: 8, Memory: 8},
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mockFunc()
-			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
 			req, err := http.NewRequest(http.MethodGet, "/quota", nil)
 			if err != nil {
 				t.Fatal(err)
@@ -63,9 +82,15 @@ func TestGetQuota(t *testing.T) {
 			ctx := getContext(tc.requestContext)
 			c.Request = req.WithContext(ctx)
 			c.Params = gin.Params{tc.requestParams}
+			kubeClient = mockClient
 			dbCon = mockDBClient
 			GetQuota(c)
 			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+			if tc.available != nil {
+				var quota models.Quota
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &quota))
+				assert.Equal(t, tc.available, quota.Available)
+			}
 		})
 	}
 }
@@ -87,6 +112,7 @@ func TestCreateQuota(t *testing.T) {
 			name: "created quota successfully",
 			mockFunc: func() {
 				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
 				mockDBClient.EXPECT().GetQuotaForGroupID(gomock.Any()).Return(nil, nil).Times(1)
 				mockDBClient.EXPECT().NewQuota(gomock.Any()).Return(nil).AnyTimes()
 			},
@@ -100,6 +126,7 @@ func TestCreateQuota(t *testing.T) {
 		{
 			name: "user not part of group",
 			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
 				mockDBClient.EXPECT().GetQuotaForGroupID(gomock.Any()).Return(getResource("create-quota", nil).(*models.Quota), nil).Times(1)
 			},
 			httpStatus:    http.StatusConflict,
@@ -129,6 +156,82 @@ func TestCreateQuota(t *testing.T) {
 				Memory: 1,
 			}}).(*models.Quota),
 		},
+		{
+			name: "member capacity set for per user quota",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+			},
+			httpStatus:    http.StatusBadRequest,
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			quota: getResource("create-quota", customValues{
+				"Capacity":       models.Capacity{CPU: 10, Memory: 10},
+				"MemberCapacity": &models.Capacity{CPU: 2, Memory: 4},
+			}).(*models.Quota),
+		},
+		{
+			name: "created pooled quota successfully",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetQuotaForGroupID(gomock.Any()).Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().NewQuota(gomock.Any()).Return(nil).AnyTimes()
+			},
+			httpStatus:    http.StatusCreated,
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			quota: getResource("create-quota", customValues{
+				"Type":           models.QuotaTypePooled,
+				"Capacity":       models.Capacity{CPU: 10, Memory: 10},
+				"MemberCapacity": &models.Capacity{CPU: 2, Memory: 4},
+			}).(*models.Quota),
+		},
+		{
+			name: "group owner created quota within the limit",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockDBClient.EXPECT().GetGroupOwnership("test-group").Return(&models.GroupOwnership{GroupID: "test-group",
+					Owners: []string{"12345"}, QuotaLimit: &models.Capacity{CPU: 10, Memory: 10}}, nil).Times(1)
+				mockDBClient.EXPECT().GetQuotaForGroupID(gomock.Any()).Return(nil, nil).Times(2)
+				mockDBClient.EXPECT().NewQuota(gomock.Any()).Return(nil).AnyTimes()
+			},
+			httpStatus:    http.StatusCreated,
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			quota: getResource("create-quota", customValues{"Capacity": models.Capacity{
+				CPU:    10,
+				Memory: 10,
+			}}).(*models.Quota),
+		},
+		{
+			name: "group owner created pooled quota",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockDBClient.EXPECT().GetGroupOwnership("test-group").Return(&models.GroupOwnership{GroupID: "test-group",
+					Owners: []string{"12345"}, QuotaLimit: &models.Capacity{CPU: 10, Memory: 10}}, nil).Times(1)
+				mockDBClient.EXPECT().GetQuotaForGroupID(gomock.Any()).Return(nil, nil).Times(1)
+			},
+			httpStatus:    http.StatusBadRequest,
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			quota: getResource("create-quota", customValues{
+				"Type":     models.QuotaTypePooled,
+				"Capacity": models.Capacity{CPU: 10, Memory: 10},
+			}).(*models.Quota),
+		},
+		{
+			name: "group owner exceeded the quota limit",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockDBClient.EXPECT().GetGroupOwnership("test-group").Return(&models.GroupOwnership{GroupID: "test-group",
+					Owners: []string{"12345"}, QuotaLimit: &models.Capacity{CPU: 8, Memory: 16, Services: 5}}, nil).Times(1)
+			},
+			httpStatus:    http.StatusBadRequest,
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			quota: getResource("create-quota", customValues{"Capacity": models.Capacity{
+				CPU:    10,
+				Memory: 10,
+			}}).(*models.Quota),
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -166,6 +269,7 @@ func TestUpdateQuota(t *testing.T) {
 			name: "updated quota successfully",
 			mockFunc: func() {
 				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
 				mockDBClient.EXPECT().GetQuotaForGroupID(gomock.Any()).Return(getResource("create-quota", nil).(*models.Quota), nil).Times(1)
 				mockDBClient.EXPECT().UpdateQuota(gomock.Any()).Return(nil).AnyTimes()
 			},
@@ -179,6 +283,7 @@ func TestUpdateQuota(t *testing.T) {
 		{
 			name: "no quota policy exists for the groupid",
 			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
 				mockDBClient.EXPECT().GetQuotaForGroupID(gomock.Any()).Return(nil, nil).Times(1)
 			},
 			httpStatus:    http.StatusConflict,
@@ -208,6 +313,40 @@ func TestUpdateQuota(t *testing.T) {
 				Memory: 1,
 			}}).(*models.Quota),
 		},
+		{
+			name: "group owner changed pooled quota to per user quota",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockDBClient.EXPECT().GetGroupOwnership("test-group").Return(&models.GroupOwnership{GroupID: "test-group",
+					Owners: []string{"12345"}, QuotaLimit: &models.Capacity{CPU: 10, Memory: 10}}, nil).Times(1)
+				mockDBClient.EXPECT().GetQuotaForGroupID(gomock.Any()).Return(getResource("create-quota", customValues{
+					"Type":     models.QuotaTypePooled,
+					"Capacity": models.Capacity{CPU: 10, Memory: 10},
+				}).(*models.Quota), nil).Times(1)
+			},
+			httpStatus:    http.StatusBadRequest,
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			quota: getResource("create-quota", customValues{"Capacity": models.Capacity{
+				CPU:    10,
+				Memory: 10,
+			}}).(*models.Quota),
+		},
+		{
+			name: "quota limit not set for the group owner",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockDBClient.EXPECT().GetGroupOwnership("test-group").Return(&models.GroupOwnership{GroupID: "test-group",
+					Owners: []string{"12345"}}, nil).Times(1)
+			},
+			httpStatus:    http.StatusBadRequest,
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			quota: getResource("create-quota", customValues{"Capacity": models.Capacity{
+				CPU:    10,
+				Memory: 10,
+			}}).(*models.Quota),
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -288,14 +427,20 @@ func TestGetUserQuota(t *testing.T) {
 		requestContext testContext
 		httpStatus     int
 		requestParams  gin.Param
+		userQuota      models.Capacity
 	}{
 		{
 			name: "user quota fetched successfully",
 			mockFunc: func() {
 				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
-				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).AnyTimes()
+				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(1)
 				mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).Times(1)
 				mockKCClient.EXPECT().GetUserID().Return("test-user").AnyTimes()
+				mockDBClient.EXPECT().GetServiceUsage([]string{"test-user"}, gomock.Any(), gomock.Any()).Return([]models.ServiceUsage{}, nil).Times(1)
+				// the user without groups has the quota granted
+				mockDBClient.EXPECT().GetActiveUserQuotas("test-user").Return([]models.UserQuota{
+					{UserID: "test-user", Capacity: models.Capacity{CPU: 1, Memory: 4, Services: 2, CoreHours: 100}},
+				}, nil).Times(1)
 			},
 			httpStatus:    http.StatusOK,
 			requestParams: gin.Param{Key: "id", Value: "test-group"},
@@ -303,12 +448,33 @@ func TestGetUserQuota(t *testing.T) {
 				"userid": "test-user",
 				"roles":  []string{"manager"},
 			}),
+			userQuota: models.Capacity{CPU: 1, Memory: 4, Services: 2, CoreHours: 100},
+		},
+		{
+			name: "services not granted to the user without groups",
+			mockFunc: func() {
+				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(1)
+				mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).Times(1)
+				mockDBClient.EXPECT().GetServiceUsage([]string{"test-user"}, gomock.Any(), gomock.Any()).Return([]models.ServiceUsage{}, nil).Times(1)
+				// the CPU and memory granted alone do not lift the limits of the services and the core-hours
+				mockDBClient.EXPECT().GetActiveUserQuotas("test-user").Return([]models.UserQuota{
+					{UserID: "test-user", Capacity: models.Capacity{CPU: 1, Memory: 4}},
+				}, nil).Times(1)
+			},
+			httpStatus:    http.StatusOK,
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			requestContext: formContext(customValues{
+				"userid": "test-user",
+				"roles":  []string{"manager"},
+			}),
+			userQuota: models.Capacity{},
 		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mockFunc()
-			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
 			req, err := http.NewRequest(http.MethodGet, "/quota", nil)
 			if err != nil {
 				t.Fatal(err)
@@ -320,6 +486,11 @@ func TestGetUserQuota(t *testing.T) {
 			dbCon = mockDBClient
 			GetUserQuota(c)
 			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+			var resp struct {
+				UserQuota models.Capacity `json:"user_quota"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tc.userQuota, resp.UserQuota)
 		})
 	}
 }
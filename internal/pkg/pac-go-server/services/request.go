@@ -4,23 +4,38 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
 	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
 )
 
+const maxRequestsPerPage = 100
+
 // GetAllRequests		godoc
 // @Summary			Get all requests
-// @Description		Get all requests
+// @Description		Get all requests matching the filters, the users other than admin get their own requests and the requests of the groups they own
 // @Tags			requests
 // @Accept			json
 // @Produce			json
+// @Param			type query string false "type of the requests"
+// @Param			state query string false "state of the requests"
+// @Param			group query string false "ID of the group the requests target"
+// @Param			service query string false "name of the service the requests target"
+// @Param			requester query string false "ID of the user made the requests"
+// @Param			created_after query string false "requests created at or after the time in RFC3339 format"
+// @Param			created_before query string false "requests created before the time in RFC3339 format"
+// @Param			sort_by query string false "field to sort the requests by, one of created_at, state, type" default(created_at)
+// @Param			order query string false "order to sort the requests in, one of asc, desc" default(desc)
+// @Param			page query int false "page number" default(1)
+// @Param			per_page query int false "number of requests per page" default(10)
 // @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
 // @Success			200
 // @Router			/api/v1/requests [get]
@@ -28,35 +43,109 @@ func GetAllRequests(c *gin.Context) {
 	logger := log.GetLogger()
 	config := client.GetConfigFromContext(c.Request.Context())
 	kc := client.NewKeyCloakClient(config, c.Request.Context())
-	var requests []models.Request
-	var err error
 
-	var userID string
+	filter, page, err := getRequestFilter(c)
+	if err != nil {
+		logger.Error("invalid request filter", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if !kc.IsRole(utils.ManagerRole) {
 		// Get authenticated user's ID
-		userID = kc.GetUserID()
-	}
-	listByType := c.DefaultQuery("type", "")
-	switch listByType {
-	case string(models.RequestAddToGroup), string(models.RequestExtendServiceExpiry), "":
-		logger.Debug("getting requests", zap.String("type", listByType), zap.String("user id", userID))
-	default:
-		logger.Error("Invalid request type", zap.String("type", listByType))
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request type - %s", listByType)})
-		return
+		filter.UserID = kc.GetUserID()
+		// group owners also list the requests targeting their groups
+		if filter.OwnedGroupIDs, err = getOwnedGroupIDs(filter.UserID); err != nil {
+			logger.Error("failed to get the owned groups", zap.String("user id", filter.UserID), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 	}
-	requests, err = dbCon.GetRequestsByUserID(userID, listByType)
+	logger.Debug("getting requests", zap.Any("filter", filter))
+	requests, totalCount, err := dbCon.GetRequests(filter)
 	if err != nil {
-		logger.Error("failed to get requests", zap.String("user id", userID), zap.Error(err))
+		logger.Error("failed to get requests", zap.String("user id", filter.UserID), zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	logger.Debug("fetched requests", zap.Any("requests", requests))
-	if len(requests) == 0 {
-		c.JSON(http.StatusOK, []models.Request{})
-		return
+
+	// Calculate the total number of pages based on the perPage value
+	totalPages := totalCount / filter.PerPage
+	if totalCount%filter.PerPage != 0 {
+		totalPages++
+	}
+	c.JSON(http.StatusOK, models.RequestResponse{
+		TotalPages: totalPages,
+		TotalItems: totalCount,
+		Requests:   requests,
+		Links: models.Links{
+			Self: c.Request.URL.String(),
+			Next: getNextPageLink(c, page, totalPages),
+			Last: getLastPageLink(c, totalPages),
+		},
+	})
+}
+
+// getRequestFilter returns the filter and the page number set by the query parameters
+func getRequestFilter(c *gin.Context) (models.RequestFilter, int64, error) {
+	filter := models.RequestFilter{
+		RequesterID: c.Query("requester"),
+		GroupID:     c.Query("group"),
+		ServiceName: c.Query("service"),
+		Descending:  true,
+	}
+	requestType := models.RequestType(c.Query("type"))
+	if _, err := getRequestHandler(requestType); err != nil && requestType != "" {
+		return filter, 0, fmt.Errorf("invalid request type - %s", requestType)
+	}
+	filter.Type = requestType
+	state := models.RequestStateType(c.Query("state"))
+	switch state {
+	case models.RequestStateNew, models.RequestStateApproved, models.RequestStateRejected, models.RequestStateExpired,
+		models.RequestStateDeleted, "":
+		filter.State = state
+	default:
+		return filter, 0, fmt.Errorf("invalid request state - %s", state)
 	}
-	c.JSON(http.StatusOK, requests)
+	for param, createdAt := range map[string]*time.Time{"created_after": &filter.CreatedAfter, "created_before": &filter.CreatedBefore} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, 0, fmt.Errorf("%s must be in RFC3339 format, e.g. 2006-01-02T15:04:05Z: %w", param, err)
+		}
+		*createdAt = t
+	}
+	if !filter.CreatedAfter.IsZero() && !filter.CreatedBefore.IsZero() && !filter.CreatedAfter.Before(filter.CreatedBefore) {
+		return filter, 0, errors.New("created_after must be before created_before")
+	}
+
+	switch sortBy := c.DefaultQuery("sort_by", "created_at"); sortBy {
+	case "created_at", "state", "type":
+		filter.SortBy = sortBy
+	default:
+		return filter, 0, fmt.Errorf("invalid sort field - %s, valid values are created_at, state, type", sortBy)
+	}
+	switch order := c.DefaultQuery("order", "desc"); order {
+	case "asc":
+		filter.Descending = false
+	case "desc":
+	default:
+		return filter, 0, fmt.Errorf("invalid sort order - %s, valid values are asc, desc", order)
+	}
+
+	page, err := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		return filter, 0, errors.New("page must be a positive number")
+	}
+	filter.PerPage, err = strconv.ParseInt(c.DefaultQuery("per_page", "10"), 10, 64)
+	if err != nil || filter.PerPage < 1 || filter.PerPage > maxRequestsPerPage {
+		return filter, 0, fmt.Errorf("per_page must be a number between 1 and %d", maxRequestsPerPage)
+	}
+	filter.StartIndex = (page - 1) * filter.PerPage
+	return filter, page, nil
 }
 
 // GetRequest			godoc
@@ -96,11 +185,8 @@ func GetRequest(c *gin.Context) {
 // @Success					200
 // @Router					/api/v1/services/{name}/expiry [put]
 func UpdateServiceExpiryRequest(c *gin.Context) {
-	originator := c.Request.Context().Value("userid").(string)
 	logger := log.GetLogger()
 	var request = models.GetRequest()
-	userID := c.Request.Context().Value("userid").(string)
-
 	if err := c.BindJSON(&request); err != nil {
 		logger.Error("failed to bind request", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -108,105 +194,12 @@ func UpdateServiceExpiryRequest(c *gin.Context) {
 	}
 	logger.Debug("request body", zap.Any("request", request))
 
-	if err := validateCreateRequestParams(request); len(err) > 0 {
-		logger.Error("error in create request validation", zap.Errors("errors", err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%v", err)})
-		return
-	}
-
-	serviceName := c.Param("name")
-	// verify service with name exist
-	service, err := kubeClient.GetService(serviceName)
-	if err != nil {
-		if errors.Is(err, utils.ErrResourceNotFound) {
-			logger.Error("service not found", zap.String("service name", serviceName))
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("service with name %s not found", serviceName)})
-		}
-		logger.Error("failed to get service", zap.String("service name", serviceName), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%v", err)})
-		return
-	}
-	logger.Debug("fetched service", zap.Any("service", service))
-
-	// service shouldn't be extended it is already expired
-	now := time.Now()
-	if now.After(service.Spec.Expiry.Time) {
-		logger.Error("service expired", zap.String("service name", serviceName), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Service %s is expired, can't extend the expiry", serviceName)})
-		return
-	}
-
-	// service shouldn't be extended if catalog already retired
-	catalog, err := kubeClient.GetCatalog(service.Spec.Catalog.Name)
-	if err != nil {
-		if errors.Is(err, utils.ErrResourceNotFound) {
-			logger.Error("catalog does not exists", zap.String("catalog name", service.Spec.Catalog.Name))
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("%v", err)})
-			return
-		}
-		logger.Error("failed to get catalog", zap.String("catalog name", service.Spec.Catalog.Name), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%v", err)})
-		return
-	}
-
-	if catalog.Spec.Retired {
-		logger.Debug("catalog is retired, can't extend the expiry", zap.Any("catalog", catalog))
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("catalog %s is retired, can't extend the expiry", catalog.Name)})
-		return
+	request.RequestType = models.RequestExtendServiceExpiry
+	if request.ServiceExpiry == nil {
+		request.ServiceExpiry = &models.ServiceExpiry{}
 	}
-
-	// verify already request exist for service extension
-	req, err := dbCon.GetRequestByServiceName(serviceName)
-	if err != nil {
-		logger.Error("failed to fetch the request", zap.String("service name", serviceName), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to fetch the request from the db, err: %s", err.Error())})
-		return
-	}
-	logger.Debug("fetched request", zap.Any("request", req))
-	for _, request := range req {
-		if request.State == models.RequestStateNew {
-			logger.Error("user have already requested to extend service expiry")
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You have already requested to extend service expiry"})
-			return
-		}
-	}
-
-	// insert the request into the database
-	id, err := dbCon.NewRequest(&models.Request{
-		UserID:        userID,
-		CreatedAt:     time.Now(),
-		State:         models.RequestStateNew,
-		Justification: request.Justification,
-		RequestType:   models.RequestExtendServiceExpiry,
-		ServiceExpiry: &models.ServiceExpiry{
-			Name:   serviceName,
-			Expiry: request.ServiceExpiry.Expiry,
-		},
-	})
-	if err != nil {
-		logger.Error("failed to create request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to insert the request into the db, err: %s", err.Error())})
-		return
-	}
-
-	event, err := models.NewEvent(userID, originator, models.EventServiceExpiryRequest)
-	if err != nil {
-		logger.Error("failed to create event", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create event, err: %s", err.Error())})
-		return
-	}
-
-	defer func() {
-		if err := dbCon.NewEvent(event); err != nil {
-			log.GetLogger().Error("failed to create event", zap.Error(err))
-		}
-	}()
-
-	event.SetNotifiyBoth()
-	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("New Request submitted to change the expiry of the service, id: %s", id))
-
-	logger.Debug("successfully created request")
-	c.Status(http.StatusCreated)
+	request.ServiceExpiry.Name = c.Param("name")
+	respondRequestCreated(c, &request)
 }
 
 // NewGroupRequest			godoc
@@ -220,14 +213,8 @@ func UpdateServiceExpiryRequest(c *gin.Context) {
 // @Success				200
 // @Router				/api/v1/groups/{id}/request [post]
 func NewGroupRequest(c *gin.Context) {
-	originator := c.Request.Context().Value("userid").(string)
 	logger := log.GetLogger()
-
 	var request = models.GetRequest()
-	// get the authenticated user's username and ID
-	username := c.Request.Context().Value("username").(string)
-	userID := c.Request.Context().Value("userid").(string)
-
 	if err := c.BindJSON(&request); err != nil {
 		logger.Error("failed to bind request", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -235,84 +222,32 @@ func NewGroupRequest(c *gin.Context) {
 	}
 	logger.Debug("request body", zap.Any("request", request))
 
-	// validate request params
-	if err := validateCreateRequestParams(request); len(err) > 0 {
-		logger.Error("error in create request validation", zap.Errors("errors", err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%v", err)})
-		return
-	}
-
-	// check if the user is already a member of the group
-	groupID := c.Param("id")
-	grp, err := getGroup(c.Request.Context(), groupID)
-	if err != nil && err != client.ErrorGroupNotFound {
-		logger.Error("failed to get groups", zap.String("group id", groupID), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	} else if err == client.ErrorGroupNotFound {
-		logger.Error("group not found", zap.String("group id", groupID))
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		return
-	}
-	logger.Debug("fetched group", zap.Any("groups", grp))
-
-	if models.IsMemberOfGroup(c.Request.Context(), *grp.Name) {
-		logger.Debug("user is already member of group", zap.String("group", *grp.Name))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You are already a member of this group."})
-		return
-	}
-
-	// check if the user has already requested access to the group
-	r, err := dbCon.GetRequestByGroupIDAndUserID(groupID, userID)
-	if err != nil {
-		logger.Error("failed to fetch requests", zap.String("group id", groupID), zap.String("user id", userID), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to fetch the requested record from the db, err: %s", err.Error())})
-		return
-	}
-	for _, request := range r {
-		if request.State == models.RequestStateNew {
-			logger.Debug("user is already requested access to this group", zap.String("group", *grp.Name), zap.Any("request", r))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You have already requested access to this group."})
-			return
-		}
-	}
-
-	// insert the request into the database
-	id, err := dbCon.NewRequest(&models.Request{
-		UserID:        userID,
-		CreatedAt:     time.Now(),
-		State:         models.RequestStateNew,
-		Justification: request.Justification,
-		RequestType:   models.RequestAddToGroup,
-		GroupAdmission: &models.GroupAdmission{
-			GroupID:   groupID,
-			Group:     *grp.Name,
-			Requester: username,
-		}})
-	if err != nil {
-		logger.Error("failed to create request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to insert the request into the db, err: %s", err.Error())})
-		return
-	}
+	request.RequestType = models.RequestAddToGroup
+	request.GroupAdmission = &models.GroupAdmission{GroupID: c.Param("id")}
+	respondRequestCreated(c, &request)
+}
 
-	event, err := models.NewEvent(userID, originator, models.EventGroupJoinRequest)
-	if err != nil {
-		logger.Error("failed to create event", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create event, err: %s", err.Error())})
+// NewQuotaIncreaseRequest		godoc
+// @Summary				New quota increase request
+// @Description			Request to increase the quota of the user for a limited duration
+// @Tags				requests
+// @Accept				json
+// @Produce				json
+// @Param				Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success				200
+// @Router				/api/v1/quota/request [post]
+func NewQuotaIncreaseRequest(c *gin.Context) {
+	logger := log.GetLogger()
+	var request = models.GetRequest()
+	if err := c.BindJSON(&request); err != nil {
+		logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	logger.Debug("request body", zap.Any("request", request))
 
-	defer func() {
-		if err := dbCon.NewEvent(event); err != nil {
-			log.GetLogger().Error("failed to create event", zap.Error(err))
-		}
-	}()
-
-	event.SetNotifiyBoth()
-	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("New Request(%s) has been submitted to add to the group: %s", id, *grp.Name))
-
-	logger.Debug("successfully created request")
-	c.Status(http.StatusCreated)
+	request.RequestType = models.RequestQuotaIncrease
+	respondRequestCreated(c, &request)
 }
 
 // ExitGroup			godoc
@@ -327,12 +262,7 @@ func NewGroupRequest(c *gin.Context) {
 // @Router			/api/v1/groups/{id}/exit [post]
 func ExitGroup(c *gin.Context) {
 	logger := log.GetLogger()
-
 	var request = models.GetRequest()
-	// get the authenticated user's username and ID
-	username := c.Request.Context().Value("username").(string)
-	userID := c.Request.Context().Value("userid").(string)
-
 	if err := c.BindJSON(&request); err != nil {
 		logger.Error("failed to bind request", zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to bind request body, err: %s", err.Error())})
@@ -340,144 +270,18 @@ func ExitGroup(c *gin.Context) {
 	}
 	logger.Debug("request body", zap.Any("request", request))
 
-	// validate request params
-	if err := validateCreateRequestParams(request); len(err) > 0 {
-		logger.Error("error in exit group request validation", zap.Errors("errors", err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%v", err)})
-		return
-	}
-
-	// check if the user is already a member of the group
-	groupID := c.Param("id")
-	grp, err := getGroup(c.Request.Context(), groupID)
-	if err != nil && err != client.ErrorGroupNotFound {
-		logger.Error("failed to get groups", zap.String("group id", groupID), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	} else if err == client.ErrorGroupNotFound {
-		logger.Error("group not found", zap.String("group id", groupID))
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		return
-	}
-	logger.Debug("fetched group", zap.Any("groups", grp))
-
-	if !models.IsMemberOfGroup(c.Request.Context(), *grp.Name) {
-		logger.Debug("user is not member of group", zap.String("user", username), zap.String("group", *grp.Name))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You are already not a member of this group."})
-		return
-	}
-
-	// check if the user has already requested to exit from the group
-	r, err := dbCon.GetRequestByGroupIDAndUserID(groupID, userID)
-	if err != nil {
-		logger.Error("failed to fetch requests", zap.String("group id", groupID), zap.String("user id", userID), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to fetch the requested record from the db, err: %s", err.Error())})
-		return
-	}
-	logger.Debug("fetched request", zap.Any("request", r))
-	for _, request := range r {
-		if request.RequestType != models.RequestExitFromGroup {
-			continue
-		}
-		if request.State == models.RequestStateNew {
-			logger.Debug("user is already requested to exit form this group", zap.String("group", *grp.Name), zap.Any("request", r))
-			c.JSON(http.StatusBadRequest, gin.H{"error": "You have already requested to exit from this group."})
-			return
-		}
-	}
-
-	// insert the request into the database
-	id, err := dbCon.NewRequest(&models.Request{
-		UserID:        userID,
-		CreatedAt:     time.Now(),
-		State:         models.RequestStateNew,
-		Justification: request.Justification,
-		RequestType:   models.RequestExitFromGroup,
-		GroupAdmission: &models.GroupAdmission{
-			GroupID:   groupID,
-			Group:     *grp.Name,
-			Requester: username,
-		},
-	})
-	if err != nil {
-		logger.Error("failed to create request", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to insert the request into the db, err: %s", err.Error())})
-		return
-	}
-
-	event, err := models.NewEvent(userID, userID, models.EventGroupExitRequest)
-	if err != nil {
-		logger.Error("failed to create event", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create event, err: %s", err.Error())})
-		return
-	}
-
-	defer func() {
-		if err := dbCon.NewEvent(event); err != nil {
-			log.GetLogger().Error("failed to create event", zap.Error(err))
-		}
-	}()
-
-	event.SetNotifyAdmin()
-	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request has been submitted for exiting the group, id: %s", id))
-
-	logger.Debug("successfully created request")
-	c.Status(http.StatusCreated)
+	request.RequestType = models.RequestExitFromGroup
+	request.GroupAdmission = &models.GroupAdmission{GroupID: c.Param("id")}
+	respondRequestCreated(c, &request)
 }
 
 func deleteUserRequest(c *gin.Context) error {
-	logger := log.GetLogger()
-
 	var request = models.GetRequest()
-	userID := c.Request.Context().Value("userid").(string)
-
 	// TODO : Request details has to be send by UI
-	// if err := c.BindJSON(&request); err != nil {
-	// 	logger.Error("failed to bind request", zap.Error(err))
-	// 	return fmt.Errorf("failed to bind request body, err: %s", err.Error())
-	// }
-
 	request.Justification = "User personal information to be deleted"
-
-	logger.Debug("request body", zap.Any("request", request))
-
-	// validate request params
-	// TODO : Enable validation once information is coming from UI
-	// if err := validateCreateRequestParams(request); len(err) > 0 {
-	// 	logger.Error("error in delete user request validation", zap.Errors("errors", err))
-	// 	return fmt.Errorf("failed to validate request body, err: %s", err.Error())
-	// }
-
-	// insert the request into the database
-	id, err := dbCon.NewRequest(&models.Request{
-		UserID:        userID,
-		CreatedAt:     time.Now(),
-		State:         models.RequestStateNew,
-		Justification: request.Justification,
-		RequestType:   models.RequestDeleteUser,
-	})
-	if err != nil {
-		logger.Error("failed to create request", zap.Error(err))
-		return fmt.Errorf("failed to insert the request into the db, err: %s", err.Error())
-	}
-
-	event, err := models.NewEvent(userID, userID, models.EventDeletUserRequest)
-	if err != nil {
-		logger.Error("failed to create event", zap.Error(err))
-		return fmt.Errorf("failed to create event, err: %s", err.Error())
-	}
-
-	defer func() {
-		if err := dbCon.NewEvent(event); err != nil {
-			log.GetLogger().Error("failed to create event", zap.Error(err))
-		}
-	}()
-
-	event.SetNotifyAdmin()
-	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request has been submitted for deleting the user with request-id: %s", id))
-
-	logger.Debug("successfully created request")
-	return nil
+	request.RequestType = models.RequestDeleteUser
+	_, _, err := createRequest(c, &request)
+	return err
 }
 
 func DeleteUser(c *gin.Context) {
@@ -524,10 +328,9 @@ func DeleteUser(c *gin.Context) {
 	c.Status(http.StatusCreated)
 }
 
-func deleteUserFromPreviousGroups(c *gin.Context, request *models.Request) {
+func deleteUserFromPreviousGroups(kc client.Keycloak, request *models.Request) {
 	logger := log.GetLogger()
-	config := client.GetConfigFromContext(c.Request.Context())
-	groups, err := client.NewKeyCloakClient(config, c.Request.Context()).GetUserGroups(request.UserID)
+	groups, err := kc.GetUserGroups(request.UserID)
 	if err != nil {
 		logger.Error("failed to get groups for user", zap.String("user id", request.UserID))
 		return
@@ -537,7 +340,7 @@ func deleteUserFromPreviousGroups(c *gin.Context, request *models.Request) {
 		if *group.ID == request.GroupAdmission.GroupID {
 			continue
 		}
-		if err := client.NewKeyCloakClient(config, c.Request.Context()).DeleteUserFromGroup(request.UserID, *group.ID); err != nil {
+		if err := kc.DeleteUserFromGroup(request.UserID, *group.ID); err != nil {
 			logger.Error("failed to remove user from group", zap.String("user id", request.UserID),
 				zap.String("group id", *group.ID), zap.Error(err))
 		}
@@ -555,16 +358,7 @@ func deleteUserFromPreviousGroups(c *gin.Context, request *models.Request) {
 // @Success			200
 // @Router			/api/v1/requests/{id}/approve [post]
 func ApproveRequest(c *gin.Context) {
-	originator := c.Request.Context().Value("userid").(string)
 	logger := log.GetLogger()
-	config := client.GetConfigFromContext(c.Request.Context())
-	kc := client.NewKeyCloakClient(config, c.Request.Context())
-	if !kc.IsRole(utils.ManagerRole) {
-		logger.Error("only admin can approve the requests")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "You do not have permission to approve requests."})
-		return
-	}
-
 	id := c.Param("id")
 	request, err := dbCon.GetRequestByID(id)
 	if err != nil {
@@ -576,55 +370,126 @@ func ApproveRequest(c *gin.Context) {
 		return
 	}
 	logger.Debug("fetched request", zap.Any("request", request))
-	switch request.RequestType {
-	case models.RequestAddToGroup:
-		if err := client.NewKeyCloakClient(config, c.Request.Context()).AddUserToGroup(request.UserID, request.GroupAdmission.GroupID); err != nil {
-			logger.Error("failed to add user to group", zap.String("user id", request.UserID),
-				zap.String("group id", request.GroupAdmission.GroupID), zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-		deleteUserFromPreviousGroups(c, request)
-	case models.RequestExitFromGroup:
-		if err := client.NewKeyCloakClient(config, c.Request.Context()).DeleteUserFromGroup(request.UserID, request.GroupAdmission.GroupID); err != nil {
-			logger.Error("failed to remove user from group", zap.String("user id", request.UserID),
-				zap.String("group id", request.GroupAdmission.GroupID), zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	case models.RequestDeleteUser:
-		if err := client.NewKeyCloakClient(config, c.Request.Context()).DeleteUser(request.UserID); err != nil {
-			logger.Error("failed to delete user", zap.String("user id", request.UserID))
-			c.JSON(getKeycloakHttpStatus(err), gin.H{"error": err.Error()})
-			return
-		}
-		if err := dbCon.DeleteTermsAndConditionsByUserID(request.UserID); err != nil {
-			logger.Error("failed to delete tnc status for user", zap.String("user id", request.UserID), zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+
+	approval, err := approveRequest(c, request)
+	if err != nil {
+		c.JSON(getErrorHttpStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if approval != nil {
+		c.JSON(http.StatusAccepted, approval)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// statusError is the failure to create or decide a request along with the HTTP status the failure is reported with
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// getErrorHttpStatus returns the HTTP status the failure to create or decide a request is reported with
+func getErrorHttpStatus(err error) int {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status
+	}
+	return http.StatusInternalServerError
+}
+
+// checkRequestDecidable checks if the user making the request can decide the request, and the request is not decided yet
+func checkRequestDecidable(c *gin.Context, request *models.Request, action string) error {
+	logger := log.GetLogger()
+	// requests can be decided by the admin, or by the owners of the group targeted by the request
+	if allowed, err := canDecideRequest(c, request); err != nil {
+		logger.Error("failed to check the group owners", zap.String("id", request.ID.Hex()), zap.Error(err))
+		return &statusError{http.StatusInternalServerError, fmt.Errorf("failed to check the group owners, err: %w", err)}
+	} else if !allowed {
+		logger.Error("only admin or group owner can decide the requests", zap.String("action", action))
+		return &statusError{http.StatusUnauthorized, fmt.Errorf("You do not have permission to %s requests.", action)}
+	}
+	if request.State == models.RequestStateRejected || request.State == models.RequestStateApproved ||
+		request.State == models.RequestStateExpired || request.State == models.RequestStateDeleted {
+		logger.Debug("request is already", zap.String("state", string(request.State)), zap.String("id", request.ID.Hex()))
+		return &statusError{http.StatusBadRequest, fmt.Errorf("Request is already %s.", string(request.State))}
+	}
+	return nil
+}
+
+// approveRequest approves the request on behalf of the user making the request and raises the event of the approval.
+// The requests matching an approval policy are approved only once all the stages of the approval chain are approved,
+// until then the pending approval chain is returned.
+func approveRequest(c *gin.Context, request *models.Request) (*models.Approval, error) {
+	originator := c.Request.Context().Value("userid").(string)
+	logger := log.GetLogger()
+	id := request.ID.Hex()
+	if err := checkRequestDecidable(c, request, "approve"); err != nil {
+		return nil, err
+	}
+
+	// a satisfied chain is not approved again so that the failed side effects can be retried
+	policy := getApprovalPolicy(request)
+	if (request.Approval != nil || policy != nil) && (request.Approval == nil || !request.Approval.IsSatisfied()) {
+		if err := approveStage(c, request, policy); err != nil {
+			logger.Error("failed to record approval", zap.String("id", id), zap.Error(err))
+			switch {
+			case errors.Is(err, errApproverNotAllowed):
+				return nil, &statusError{http.StatusUnauthorized, err}
+			case errors.Is(err, utils.ErrResourceModified):
+				return nil, &statusError{http.StatusConflict, errors.New("Request was modified by another approver, please retry.")}
+			default:
+				return nil, &statusError{http.StatusInternalServerError, fmt.Errorf("failed to record the approval, err: %w", err)}
+			}
 		}
-	case models.RequestExtendServiceExpiry:
-		if err := kubeClient.UpdateServiceExpiry(request.ServiceExpiry.Name, request.ServiceExpiry.Expiry); err != nil {
-			if errors.Is(err, utils.ErrResourceNotFound) {
-				logger.Error("service does not exists", zap.String("service name", request.ServiceExpiry.Name))
-				c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("service with name %s does not exists", request.ServiceExpiry.Name)})
-				return
+		if !request.Approval.IsSatisfied() {
+			event, err := models.NewEvent(request.UserID, originator, models.EventTypeRequestPartiallyApproved)
+			if err != nil {
+				logger.Error("failed to create event", zap.Error(err))
+				return nil, &statusError{http.StatusInternalServerError, fmt.Errorf("failed to create event, err: %w", err)}
 			}
-			logger.Error("failed to update service", zap.String("service name", request.ServiceExpiry.Name), zap.Error(err))
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%v", err)})
-			return
+			defer func() {
+				if err := dbCon.NewEvent(event); err != nil {
+					log.GetLogger().Error("failed to create event", zap.Error(err))
+				}
+			}()
+			// admin is notified for the approval of the next stage
+			event.SetNotifiyBoth()
+			event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request has been approved at stage %d of %d, id: %s",
+				request.Approval.CurrentStage(), len(request.Approval.Stages), id))
+			setRequestResource(event, id, request)
+			logger.Debug("request is pending approval", zap.String("id", id), zap.Any("approval", request.Approval))
+			return request.Approval, nil
+		}
+	}
+
+	if err := applyApproval(c, request); err != nil {
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			return nil, err
 		}
+		return nil, &statusError{getKeycloakHttpStatus(err), err}
 	}
-	if err := dbCon.UpdateRequestState(id, models.RequestStateApproved); err != nil {
+	if err := dbCon.UpdateRequestState(id, models.NewRequestTransition(request, models.RequestStateApproved, originator, "")); err != nil {
 		logger.Error("failed to update request status in database", zap.String("id", id), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update the state field in the db, err: %s", err.Error())})
-		return
+		if errors.Is(err, utils.ErrResourceModified) {
+			return nil, &statusError{http.StatusConflict, errors.New("Request was modified by another approver, please retry.")}
+		}
+		return nil, &statusError{http.StatusInternalServerError, fmt.Errorf("failed to update the state field in the db, err: %w", err)}
 	}
+	request.State = models.RequestStateApproved
 	event, err := models.NewEvent(request.UserID, originator, models.EventTypeRequestApproved)
 	if err != nil {
 		logger.Error("failed to create event", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create event, err: %s", err.Error())})
-		return
+		return nil, &statusError{http.StatusInternalServerError, fmt.Errorf("failed to create event, err: %w", err)}
 	}
 
 	defer func() {
@@ -634,9 +499,28 @@ func ApproveRequest(c *gin.Context) {
 	}()
 
 	event.SetNotify()
-	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request has been successfully approved, id: %s", request.ID.Hex()))
+	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request has been successfully approved, id: %s", id))
+	setRequestResource(event, id, request)
 	logger.Debug("successfully approved request", zap.String("id", id))
-	c.Status(http.StatusNoContent)
+	return nil, nil
+}
+
+// applyApproval carries out the changes requested by the approved request by the handler of its type
+func applyApproval(c *gin.Context, request *models.Request) error {
+	handler, err := getRequestHandler(request.RequestType)
+	if err != nil {
+		return &statusError{http.StatusBadRequest, err}
+	}
+	return handler.OnApprove(c, request)
+}
+
+// applyRejection cleans up after the rejected request by the handler of its type
+func applyRejection(c *gin.Context, request *models.Request) error {
+	handler, err := getRequestHandler(request.RequestType)
+	if err != nil {
+		return err
+	}
+	return handler.OnReject(c, request)
 }
 
 // RejectRequest		godoc
@@ -650,15 +534,7 @@ func ApproveRequest(c *gin.Context) {
 // @Success			200
 // @Router			/api/v1/requests/{id}/reject [post]
 func RejectRequest(c *gin.Context) {
-	originator := c.Request.Context().Value("userid").(string)
 	logger := log.GetLogger()
-	config := client.GetConfigFromContext(c.Request.Context())
-	kc := client.NewKeyCloakClient(config, c.Request.Context())
-	if !kc.IsRole(utils.ManagerRole) {
-		logger.Error("only admin can approve the requests")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "You do not have permission to reject requests."})
-		return
-	}
 
 	var request models.Request
 	if err := c.BindJSON(&request); err != nil {
@@ -685,22 +561,39 @@ func RejectRequest(c *gin.Context) {
 	}
 	logger.Debug("fetched request", zap.Any("request", req))
 
-	if req.State == models.RequestStateRejected || req.State == models.RequestStateApproved {
-		logger.Debug("request is already", zap.String("state", string(req.State)), zap.String("id", id))
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Request is already %s.", string(req.State))})
+	if err := rejectRequest(c, req, request.Comment); err != nil {
+		c.JSON(getErrorHttpStatus(err), gin.H{"error": err.Error()})
 		return
 	}
+	c.Status(http.StatusNoContent)
+}
 
-	if err := dbCon.UpdateRequestStateWithComment(id, models.RequestStateRejected, request.Comment); err != nil {
+// rejectRequest rejects the request with the comment on behalf of the user making the request and raises the event of
+// the rejection
+func rejectRequest(c *gin.Context, request *models.Request, comment string) error {
+	originator := c.Request.Context().Value("userid").(string)
+	logger := log.GetLogger()
+	id := request.ID.Hex()
+	if err := checkRequestDecidable(c, request, "reject"); err != nil {
+		return err
+	}
+
+	if err := applyRejection(c, request); err != nil {
+		logger.Error("failed to clean up the rejected request", zap.String("id", id), zap.Error(err))
+		return &statusError{http.StatusInternalServerError, fmt.Errorf("failed to clean up the rejected request, err: %w", err)}
+	}
+	if err := dbCon.UpdateRequestState(id, models.NewRequestTransition(request, models.RequestStateRejected, originator, comment)); err != nil {
 		logger.Error("failed to update request status in database", zap.String("id", id), zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update the state field in the db, err: %s", err.Error())})
-		return
+		if errors.Is(err, utils.ErrResourceModified) {
+			return &statusError{http.StatusConflict, errors.New("Request was modified by another approver, please retry.")}
+		}
+		return &statusError{http.StatusInternalServerError, fmt.Errorf("failed to update the state field in the db, err: %w", err)}
 	}
-	event, err := models.NewEvent(req.UserID, originator, models.EventTypeRequestRejected)
+	request.State = models.RequestStateRejected
+	event, err := models.NewEvent(request.UserID, originator, models.EventTypeRequestRejected)
 	if err != nil {
 		logger.Error("failed to create event", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to create event, err: %s", err.Error())})
-		return
+		return &statusError{http.StatusInternalServerError, fmt.Errorf("failed to create event, err: %w", err)}
 	}
 
 	defer func() {
@@ -710,9 +603,10 @@ func RejectRequest(c *gin.Context) {
 	}()
 
 	event.SetNotify()
-	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request has been rejected, id: %s", request.ID.Hex()))
+	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request has been rejected, id: %s", id))
+	setRequestResource(event, id, request)
 	logger.Debug("successfully rejected request", zap.String("id", id))
-	c.Status(http.StatusNoContent)
+	return nil
 }
 
 // DeleteRequest		godoc
@@ -739,6 +633,10 @@ func DeleteRequest(c *gin.Context) {
 		return
 	}
 	logger.Debug("fetched request", zap.Any("request", request))
+	if request.State == models.RequestStateDeleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("request with id: %s not found", id)})
+		return
+	}
 	config := client.GetConfigFromContext(c.Request.Context())
 	kc := client.NewKeyCloakClient(config, c.Request.Context())
 	userID := kc.GetUserID()
@@ -750,8 +648,13 @@ func DeleteRequest(c *gin.Context) {
 		return
 	}
 
-	if err := dbCon.DeleteRequest(id); err != nil {
+	// requests are kept along with their history once deleted
+	if err := dbCon.UpdateRequestState(id, models.NewRequestTransition(request, models.RequestStateDeleted, originator, "")); err != nil {
 		logger.Error("failed to delete request in database", zap.String("id", id), zap.Error(err))
+		if errors.Is(err, utils.ErrResourceModified) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Request was modified concurrently, please retry."})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to delete the record from the db, err: %s", err.Error())})
 		return
 	}
@@ -769,6 +672,7 @@ func DeleteRequest(c *gin.Context) {
 	}()
 
 	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request has been deleted, id: %s", request.ID.Hex()))
+	setRequestResource(event, request.ID.Hex(), request)
 	logger.Debug("successfully deleted request", zap.String("id", id))
 	c.Status(http.StatusNoContent)
 }
@@ -782,17 +686,27 @@ func validateCreateRequestParams(request models.Request) []error {
 		errs = append(errs, errors.New("justification must be 500 characters or less"))
 	}
 
-	switch request.RequestType {
-	case models.RequestAddToGroup:
-	case models.RequestExitFromGroup:
-	case models.RequestExtendServiceExpiry:
-		if request.ServiceExpiry == nil || request.ServiceExpiry.Expiry.IsZero() {
-			errs = append(errs, errors.New("expiry time should be set"))
-		} else if time.Now().After(request.ServiceExpiry.Expiry) {
-			errs = append(errs, errors.New("expiry time should be in future"))
+	return errs
+}
+
+// validateExpiryExtension verifies that extending the expiry of the service till expiry is within the maximum extension
+// and the monthly core-hours budget of the quota, utils.ErrQuotaExceeded is returned if it is not.
+func validateExpiryExtension(userID string, quota models.Capacity, service pac.Service, catalog pac.Catalog, expiry time.Time) error {
+	if quota.MaxExtensionDays > 0 {
+		maxExpiry := service.CreationTimestamp.AddDate(0, 0, catalog.Spec.Expiry+quota.MaxExtensionDays)
+		if expiry.After(maxExpiry) {
+			return fmt.Errorf("%w, expiry can be extended by at most %d days i.e. till %s", utils.ErrQuotaExceeded,
+				quota.MaxExtensionDays, maxExpiry.Format(time.RFC3339))
 		}
-	default:
-		errs = append(errs, fmt.Errorf("invalid request_type: \"%s\" is set, valid values are %s %s %s", request.RequestType, models.RequestAddToGroup, models.RequestExitFromGroup, models.RequestExtendServiceExpiry))
 	}
-	return errs
+	cpu, err := utils.CastStrToFloat(catalog.Spec.Capacity.CPU)
+	if err != nil {
+		return err
+	}
+	// the service is already accounted till the current expiry
+	extendedFrom := service.Spec.Expiry.Time
+	if now := time.Now(); now.After(extendedFrom) {
+		extendedFrom = now
+	}
+	return checkCoreHoursBudget(userID, quota, cpu, extendedFrom, expiry)
 }
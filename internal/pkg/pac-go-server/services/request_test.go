@@ -3,16 +3,21 @@ package services
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Nerzal/gocloak/v13"
 	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGetAllRequest(t *testing.T) {
@@ -24,25 +29,95 @@ func TestGetAllRequest(t *testing.T) {
 		name           string
 		mockFunc       func()
 		requestContext testContext
+		query          string
 		httpStatus     int
+		totalPages     int64
 	}{
 		{
 			name: "get all requests successfully",
 			mockFunc: func() {
-				mockDBClient.EXPECT().GetRequestsByUserID(gomock.Any(), gomock.Any()).Return(getResource("get-requests-by-user-id", nil).([]models.Request), nil).Times(1)
-				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).AnyTimes()
+				mockDBClient.EXPECT().GetRequests(gomock.Any()).DoAndReturn(func(filter models.RequestFilter) ([]models.Request, int64, error) {
+					assert.Equal(t, models.RequestFilter{SortBy: "created_at", Descending: true, PerPage: 10}, filter)
+					return getResource("get-requests-by-user-id", nil).([]models.Request), 1, nil
+				}).Times(1)
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+			},
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusOK,
+			totalPages: 1,
+		},
+		{
+			name: "admin filtered requests",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequests(gomock.Any()).DoAndReturn(func(filter models.RequestFilter) ([]models.Request, int64, error) {
+					assert.Equal(t, models.RequestFilter{
+						RequesterID:   "67890",
+						Type:          models.RequestAddToGroup,
+						State:         models.RequestStateNew,
+						GroupID:       "test-group",
+						CreatedAfter:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+						CreatedBefore: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+						SortBy:        "state",
+						StartIndex:    5,
+						PerPage:       5,
+					}, filter)
+					return getResource("get-requests-by-user-id", nil).([]models.Request), 11, nil
+				}).Times(1)
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+			},
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			query: "?type=GROUP&state=NEW&group=test-group&requester=67890&created_after=2024-01-01T00:00:00Z" +
+				"&created_before=2024-02-01T00:00:00Z&sort_by=state&order=asc&page=2&per_page=5",
+			httpStatus: http.StatusOK,
+			totalPages: 3,
+		},
+		{
+			name: "group owner lists requests of the owned groups",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockKCClient.EXPECT().GetUserID().Return("12345").Times(1)
+				mockDBClient.EXPECT().GetOwnedGroups("12345").Return([]models.GroupOwnership{{GroupID: "test-group", Owners: []string{"12345"}}}, nil).Times(1)
+				mockDBClient.EXPECT().GetRequests(gomock.Any()).DoAndReturn(func(filter models.RequestFilter) ([]models.Request, int64, error) {
+					assert.Equal(t, "12345", filter.UserID)
+					assert.Equal(t, []string{"test-group"}, filter.OwnedGroupIDs)
+					return getResource("get-requests-by-user-id", nil).([]models.Request), 1, nil
+				}).Times(1)
 			},
 			requestContext: formContext(customValues{
 				"userid": "12345",
 			}),
 			httpStatus: http.StatusOK,
+			totalPages: 1,
+		},
+		{
+			name:     "invalid state",
+			mockFunc: func() {},
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			query:      "?state=PENDING",
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid created-at range",
+			mockFunc: func() {},
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			query:      "?created_after=2024-02-01T00:00:00Z&created_before=2024-01-01T00:00:00Z",
+			httpStatus: http.StatusBadRequest,
 		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mockFunc()
-			c, _ := gin.CreateTestContext(httptest.NewRecorder())
-			req, err := http.NewRequest(http.MethodGet, "/requests", nil)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			req, err := http.NewRequest(http.MethodGet, "/requests"+tc.query, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -51,6 +126,14 @@ func TestGetAllRequest(t *testing.T) {
 			dbCon = mockDBClient
 			GetAllRequests(c)
 			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+			if tc.httpStatus != http.StatusOK {
+				return
+			}
+			var response models.RequestResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.totalPages, response.TotalPages)
 		})
 	}
 }
@@ -93,7 +176,7 @@ func TestGetRequest(t *testing.T) {
 
 func TestUpdateServiceExpiryRequest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	mockClient, mockDBClient, _, tearDown := setUp(t)
+	mockClient, mockDBClient, mockKCClient, tearDown := setUp(t)
 	defer tearDown()
 
 	testcases := []struct {
@@ -109,16 +192,71 @@ func TestUpdateServiceExpiryRequest(t *testing.T) {
 			mockFunc: func() {
 				mockClient.EXPECT().GetService(gomock.Any()).Return(getResource("get-service", nil).(pac.Service), nil).Times(1)
 				mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).Times(1)
+				mockKCClient.EXPECT().GetUserID().Return("test-user").Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("test-user").Return(nil, nil).Times(1)
 				mockDBClient.EXPECT().GetRequestByServiceName(gomock.Any()).Return(getResource("get-request-by-service-name", nil).([]models.Request), nil).Times(1)
 				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
 				mockDBClient.EXPECT().NewRequest(gomock.Any()).Return("123", nil).Times(1)
+				mockDBClient.EXPECT().GetRules().Return(nil, nil).Times(1)
 			},
 			requestContext: formContext(customValues{
-				"userid": "12345",
+				"userid": "test-user",
 			}),
 			httpStatus: http.StatusCreated,
 			request:    getResource("get-request-by-id", nil).(*models.Request),
 		},
+		{
+			name: "service owned by another user",
+			mockFunc: func() {
+				mockClient.EXPECT().GetService(gomock.Any()).Return(getResource("get-service", nil).(pac.Service), nil).Times(1)
+			},
+			requestContext: formContext(customValues{
+				"userid": "67890",
+			}),
+			httpStatus: http.StatusForbidden,
+			request:    getResource("get-request-by-id", nil).(*models.Request),
+		},
+		{
+			name: "expiry extended beyond max extension days",
+			mockFunc: func() {
+				mockClient.EXPECT().GetService(gomock.Any()).Return(getResource("get-service", customValues{
+					"ObjectMeta": metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(time.Now().AddDate(0, 0, -11))},
+				}).(pac.Service), nil).Times(1)
+				mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).Times(1)
+				mockKCClient.EXPECT().GetUserID().Return("test-user").Times(1)
+				mockDBClient.EXPECT().GetGroupsQuota(gomock.Any()).Return(getResource("get-groups-quota", customValues{
+					"Capacity": models.Capacity{CPU: 10, Memory: 10, MaxExtensionDays: 1},
+				}).([]models.Quota), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas(gomock.Any()).Return(nil, nil).Times(1)
+			},
+			requestContext: formContext(customValues{
+				"userid": "test-user",
+				"groups": formGroup(customValues{"id": "122343"}),
+			}),
+			httpStatus: http.StatusBadRequest,
+			request:    getResource("get-request-by-id", nil).(*models.Request),
+		},
+		{
+			name: "core-hours budget exceeded",
+			mockFunc: func() {
+				mockClient.EXPECT().GetService(gomock.Any()).Return(getResource("get-service", nil).(pac.Service), nil).Times(1)
+				mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).Times(1)
+				mockKCClient.EXPECT().GetUserID().Return("test-user").Times(1)
+				mockDBClient.EXPECT().GetGroupsQuota(gomock.Any()).Return(getResource("get-groups-quota", customValues{
+					"Capacity": models.Capacity{CPU: 10, Memory: 10, CoreHours: 1},
+				}).([]models.Quota), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas(gomock.Any()).Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().GetServiceUsage(gomock.Any(), gomock.Any(), gomock.Any()).Return([]models.ServiceUsage{}, nil).MinTimes(1)
+			},
+			requestContext: formContext(customValues{
+				"userid": "test-user",
+				"groups": formGroup(customValues{"id": "122343"}),
+			}),
+			httpStatus: http.StatusBadRequest,
+			request: getResource("get-request-by-id", customValues{
+				"ServiceExpiry": &models.ServiceExpiry{Name: "test-service", Expiry: time.Now().Add(24 * time.Hour)},
+			}).(*models.Request),
+		},
 		{
 			name:          "justification not set",
 			mockFunc:      func() {},
@@ -149,7 +287,7 @@ func TestUpdateServiceExpiryRequest(t *testing.T) {
 			}
 			ctx := getContext(tc.requestContext)
 			c.Request = req.WithContext(ctx)
-			c.Params = gin.Params{tc.requestParams}
+			c.Params = gin.Params{{Key: "name", Value: "test-service"}, tc.requestParams}
 			kubeClient = mockClient
 			dbCon = mockDBClient
 			UpdateServiceExpiryRequest(c)
@@ -159,6 +297,106 @@ func TestUpdateServiceExpiryRequest(t *testing.T) {
 
 }
 
+func TestNewQuotaIncreaseRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, _, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name           string
+		mockFunc       func()
+		requestContext testContext
+		httpStatus     int
+		request        *models.Request
+	}{
+		{
+			name: "quota increase request created successfully",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestsByUserID("12345", string(models.RequestQuotaIncrease)).Return(getResource("get-requests-by-user-id", nil).([]models.Request), nil).Times(1)
+				mockDBClient.EXPECT().NewRequest(gomock.Any()).Return("123", nil).Times(1)
+				mockDBClient.EXPECT().GetRules().Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
+			},
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusCreated,
+			request: &models.Request{
+				Justification: "justification",
+				QuotaIncrease: &models.QuotaIncrease{Capacity: models.Capacity{CPU: 2, Memory: 4}, Duration: 7},
+			},
+		},
+		{
+			name: "quota increase already requested",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestsByUserID("12345", string(models.RequestQuotaIncrease)).Return(getResource("get-requests-by-user-id", customValues{
+					"State": models.RequestStateNew,
+				}).([]models.Request), nil).Times(1)
+			},
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusBadRequest,
+			request: &models.Request{
+				Justification: "justification",
+				QuotaIncrease: &models.QuotaIncrease{Capacity: models.Capacity{CPU: 2, Memory: 4}, Duration: 7},
+			},
+		},
+		{
+			name:     "invalid capacity requested",
+			mockFunc: func() {},
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusBadRequest,
+			request: &models.Request{
+				Justification: "justification",
+				QuotaIncrease: &models.QuotaIncrease{Capacity: models.Capacity{CPU: 0.3, Memory: 4}, Duration: 7},
+			},
+		},
+		{
+			name:     "duration not set",
+			mockFunc: func() {},
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusBadRequest,
+			request: &models.Request{
+				Justification: "justification",
+				QuotaIncrease: &models.QuotaIncrease{Capacity: models.Capacity{CPU: 2, Memory: 4}},
+			},
+		},
+		{
+			name:     "duration exceeds the max",
+			mockFunc: func() {},
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+			httpStatus: http.StatusBadRequest,
+			request: &models.Request{
+				Justification: "justification",
+				QuotaIncrease: &models.QuotaIncrease{Capacity: models.Capacity{CPU: 2, Memory: 4}, Duration: maxQuotaIncreaseDays + 1},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			marshalledRequest, _ := json.Marshal(tc.request)
+			req, err := http.NewRequest(http.MethodPost, "/quota/request", bytes.NewBuffer(marshalledRequest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := getContext(tc.requestContext)
+			c.Request = req.WithContext(ctx)
+			dbCon = mockDBClient
+			NewQuotaIncreaseRequest(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
+
 func TestNewGroupRequest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	_, mockDBClient, mockKCClient, tearDown := setUp(t)
@@ -178,12 +416,90 @@ func TestNewGroupRequest(t *testing.T) {
 				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
 				mockDBClient.EXPECT().GetRequestByGroupIDAndUserID(gomock.Any(), gomock.Any()).Return(getResource("get-requests-by-user-id", nil).([]models.Request), nil).AnyTimes()
 				mockDBClient.EXPECT().NewRequest(gomock.Any()).Return("123", nil).Times(1)
+				mockDBClient.EXPECT().GetRules().Return([]models.Rule{}, nil).Times(1)
 				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
 			},
 			request:       getResource("get-request-by-id", nil).(*models.Request),
 			httpStatus:    http.StatusCreated,
 			requestParams: gin.Param{Key: "id", Value: "test-group"},
 		},
+		{
+			name: "group request auto-approved by rule",
+			mockFunc: func() {
+				mockDBClient.EXPECT().NewRequest(gomock.Any()).Return("123", nil).Times(1)
+				mockDBClient.EXPECT().GetRules().Return([]models.Rule{
+					{ID: [12]byte{1}, Enabled: false, Action: models.RuleActionReject, Reason: "disabled",
+						Match: models.RuleMatch{RequestType: models.RequestAddToGroup}},
+					{ID: [12]byte{2}, Enabled: true, Action: models.RuleActionApprove, Reason: "open group",
+						Match: models.RuleMatch{RequestType: models.RequestAddToGroup, Groups: []string{"test-group"}}},
+				}, nil).Times(1)
+				mockKCClient.EXPECT().AddUserToGroup("12345", "test-group").Return(nil).Times(1)
+				mockKCClient.EXPECT().GetUserGroups("12345").Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestDecision("123", transitionTo(models.RequestStateApproved, models.RequestActorSystem), gomock.Any()).DoAndReturn(
+					func(_ string, _ models.RequestTransition, decision *models.RuleDecision) error {
+						assert.Equal(t, primitive.ObjectID{2}.Hex(), decision.RuleID)
+						assert.Equal(t, "open group", decision.Reason)
+						return nil
+					}).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).DoAndReturn(func(event *models.Event) error {
+					assert.Equal(t, models.EventTypeRequestApproved, event.Type)
+					return nil
+				}).Times(1)
+			},
+			request:        getResource("get-request-by-id", nil).(*models.Request),
+			httpStatus:     http.StatusCreated,
+			requestParams:  gin.Param{Key: "id", Value: "test-group"},
+			requestContext: formContext(customValues{"userid": "12345"}),
+		},
+		{
+			name: "decision of the rule reverted once the approval fails",
+			mockFunc: func() {
+				mockDBClient.EXPECT().NewRequest(gomock.Any()).Return("123", nil).Times(1)
+				mockDBClient.EXPECT().GetRules().Return([]models.Rule{
+					{ID: [12]byte{2}, Enabled: true, Action: models.RuleActionApprove, Reason: "open group",
+						Match: models.RuleMatch{RequestType: models.RequestAddToGroup, Groups: []string{"test-group"}}},
+				}, nil).Times(1)
+				// the decision is recorded before the user is added to the group
+				gomock.InOrder(
+					mockDBClient.EXPECT().UpdateRequestDecision("123", transitionTo(models.RequestStateApproved, models.RequestActorSystem), gomock.Any()).Return(nil).Times(1),
+					mockKCClient.EXPECT().AddUserToGroup("12345", "test-group").Return(errors.New("keycloak is down")).Times(1),
+					mockDBClient.EXPECT().RevertRequestDecision("123", gomock.Any()).DoAndReturn(func(_ string, transition models.RequestTransition) error {
+						assert.Equal(t, models.RequestStateApproved, transition.From)
+						assert.Equal(t, models.RequestStateNew, transition.To)
+						return nil
+					}).Times(1),
+				)
+				// the request is left to the admins
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).DoAndReturn(func(event *models.Event) error {
+					assert.Equal(t, models.EventGroupJoinRequest, event.Type)
+					return nil
+				}).Times(1)
+			},
+			request:        getResource("get-request-by-id", nil).(*models.Request),
+			httpStatus:     http.StatusCreated,
+			requestParams:  gin.Param{Key: "id", Value: "test-group"},
+			requestContext: formContext(customValues{"userid": "12345"}),
+		},
+		{
+			name: "group request auto-rejected by rule on email domain",
+			mockFunc: func() {
+				mockDBClient.EXPECT().NewRequest(gomock.Any()).Return("123", nil).Times(1)
+				mockDBClient.EXPECT().GetRules().Return([]models.Rule{
+					{ID: [12]byte{3}, Enabled: true, Action: models.RuleActionReject, Reason: "external users",
+						Match: models.RuleMatch{RequestType: models.RequestAddToGroup, EmailDomains: []string{"example.com"}}},
+				}, nil).Times(1)
+				mockKCClient.EXPECT().GetUserInfo().Return(&gocloak.UserInfo{Email: utils.Ptr("user@Example.com")}, nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestDecision("123", transitionTo(models.RequestStateRejected, models.RequestActorSystem), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).DoAndReturn(func(event *models.Event) error {
+					assert.Equal(t, models.EventTypeRequestRejected, event.Type)
+					return nil
+				}).Times(1)
+			},
+			request:        getResource("get-request-by-id", nil).(*models.Request),
+			httpStatus:     http.StatusCreated,
+			requestParams:  gin.Param{Key: "id", Value: "test-group"},
+			requestContext: formContext(customValues{"userid": "12345"}),
+		},
 		{
 			name:          "group not found",
 			mockFunc:      func() {},
@@ -217,54 +533,102 @@ func TestNewGroupRequest(t *testing.T) {
 	}
 }
 
-// func TestExitGroup(t *testing.T) {
-// 	gin.SetMode(gin.TestMode)
-// 	_, mockDBClient, mockKCClient, tearDown := setUp(t)
-// 	defer tearDown()
+func TestExitGroup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
 
-// 	testcases := []struct {
-// 		name           string
-// 		mockFunc       func()
-// 		requestContext testContext
-// 		httpStatus     int
-// 		request        *models.Request
-// 		requestParams  gin.Param
-// 	}{
-// 		{
-// 			name: "successfully created request",
-// 			mockFunc: func() {
-// 				mockKCClient.EXPECT().AddUserToGroup("test-user", "test-group").Return(nil).AnyTimes()
-// 				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).AnyTimes()
-// 				mockDBClient.EXPECT().GetRequestByGroupIDAndUserID(gomock.Any(), gomock.Any()).Return(getResource("get-requests-by-user-id", nil).([]models.Request), nil).AnyTimes()
-// 				mockDBClient.EXPECT().NewRequest(gomock.Any()).Return("123", nil).Times(1)
-// 				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
-// 			},
-// 			httpStatus:    http.StatusCreated,
-// 			request:       getResource("add-to-group-request", nil).(*models.Request),
-// 			requestParams: gin.Param{Key: "id", Value: "test-group"},
-// 			requestContext: formContext(customValues{
-// 				"username": "test-user",
-// 			}),
-// 		},
-// 	}
-// 	for _, tc := range testcases {
-// 		t.Run(tc.name, func(t *testing.T) {
-// 			tc.mockFunc()
-// 			c, _ := gin.CreateTestContext(httptest.NewRecorder())
-// 			marshalledRequest, _ := json.Marshal(tc.request)
-// 			req, err := http.NewRequest(http.MethodPost, "/requests", bytes.NewBuffer(marshalledRequest))
-// 			if err != nil {
-// 				t.Fatal(err)
-// 			}
-// 			ctx := getContext(tc.requestContext)
-// 			c.Request = req.WithContext(ctx)
-// 			c.Params = gin.Params{tc.requestParams}
-// 			dbCon = mockDBClient
-// 			ExitGroup(c)
-// 			assert.Equal(t, tc.httpStatus, c.Writer.Status())
-// 		})
-// 	}
-// }
+	testcases := []struct {
+		name           string
+		mockFunc       func()
+		requestContext testContext
+		httpStatus     int
+		request        *models.Request
+		requestParams  gin.Param
+	}{
+		{
+			name: "successfully created request",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).Times(1)
+				mockDBClient.EXPECT().GetRequestByGroupIDAndUserID("test-group", "12345").Return(getResource("get-requests-by-user-id", nil).([]models.Request), nil).Times(1)
+				mockDBClient.EXPECT().NewRequest(gomock.Any()).DoAndReturn(func(request *models.Request) (string, error) {
+					assert.Equal(t, models.RequestExitFromGroup, request.RequestType)
+					assert.Equal(t, "test-group", request.GroupAdmission.GroupID)
+					return "123", nil
+				}).Times(1)
+				mockDBClient.EXPECT().GetRules().Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
+			},
+			httpStatus:    http.StatusCreated,
+			request:       &models.Request{Justification: "justification"},
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			requestContext: formContext(customValues{
+				"userid":   "12345",
+				"username": "test-user",
+				"groups":   []models.Group{{ID: "test-group", Name: "test-group"}},
+			}),
+		},
+		{
+			name: "exit already requested",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).Times(1)
+				mockDBClient.EXPECT().GetRequestByGroupIDAndUserID("test-group", "12345").Return([]models.Request{
+					{RequestType: models.RequestExitFromGroup, State: models.RequestStateNew},
+				}, nil).Times(1)
+			},
+			httpStatus:    http.StatusBadRequest,
+			request:       &models.Request{Justification: "justification"},
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			requestContext: formContext(customValues{
+				"userid":   "12345",
+				"username": "test-user",
+				"groups":   []models.Group{{ID: "test-group", Name: "test-group"}},
+			}),
+		},
+		{
+			name: "not a member of the group",
+			mockFunc: func() {
+				mockKCClient.EXPECT().GetGroups().Return(getResource("get-group-info", nil).([]*gocloak.Group), nil).Times(1)
+			},
+			httpStatus:    http.StatusBadRequest,
+			request:       &models.Request{Justification: "justification"},
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			requestContext: formContext(customValues{
+				"userid":   "12345",
+				"username": "test-user",
+				"groups":   []models.Group{},
+			}),
+		},
+		{
+			name:          "justification not set",
+			mockFunc:      func() {},
+			httpStatus:    http.StatusBadRequest,
+			request:       &models.Request{},
+			requestParams: gin.Param{Key: "id", Value: "test-group"},
+			requestContext: formContext(customValues{
+				"userid":   "12345",
+				"username": "test-user",
+			}),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			marshalledRequest, _ := json.Marshal(tc.request)
+			req, err := http.NewRequest(http.MethodPost, "/groups/test-group/exit", bytes.NewBuffer(marshalledRequest))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := getContext(tc.requestContext)
+			c.Request = req.WithContext(ctx)
+			c.Params = gin.Params{tc.requestParams}
+			dbCon = mockDBClient
+			ExitGroup(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
 
 func TestDeleteUser(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -283,11 +647,14 @@ func TestDeleteUser(t *testing.T) {
 			mockFunc: func() {
 				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(1)
 				mockClient.EXPECT().DeleteService(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().ReleaseQuota(gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().EndServiceUsage(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
 				mockDBClient.EXPECT().GetRequestByServiceName(gomock.Any()).Return(getResource("get-request-by-service-name", nil).([]models.Request), nil).Times(1)
 				mockDBClient.EXPECT().GetKeyByUserID(gomock.Any()).Return(getResource("get-key-by-userid", nil).([]models.Key), nil).Times(1)
 				mockDBClient.EXPECT().GetKeyByID(gomock.Any()).Return(getResource("get-key-by-id", nil).(*models.Key), nil).Times(1)
 				mockDBClient.EXPECT().DeleteKey(gomock.Any()).Return(nil).AnyTimes()
 				mockDBClient.EXPECT().NewRequest(gomock.Any()).Return("123", nil).AnyTimes()
+				mockDBClient.EXPECT().GetRules().Return(nil, nil).Times(1)
 				mockDBClient.EXPECT().NewEvent(gomock.Any()).AnyTimes()
 				mockKCClient.EXPECT().GetUserID().Return("12345").Times(2)
 				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(2)
@@ -302,8 +669,13 @@ func TestDeleteUser(t *testing.T) {
 		{
 			name: "not authorized to delete key",
 			mockFunc: func() {
-				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(1)
+				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(2)
 				mockClient.EXPECT().DeleteService(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().ReleaseQuota(gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().EndServiceUsage(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().GetQuotaAlert(models.QuotaAlertUser, "12345").Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("12345").Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().UpdateQuotaAlert(gomock.Any()).Return(nil).Times(1)
 				mockDBClient.EXPECT().GetRequestByServiceName(gomock.Any()).Return(getResource("get-request-by-service-name", nil).([]models.Request), nil).Times(1)
 				mockDBClient.EXPECT().GetKeyByUserID(gomock.Any()).Return(getResource("get-key-by-userid", nil).([]models.Key), nil).Times(1)
 				mockDBClient.EXPECT().GetKeyByID(gomock.Any()).Return(getResource("get-key-by-id", nil).(*models.Key), nil).Times(1)
@@ -352,6 +724,29 @@ func TestApproveRequest(t *testing.T) {
 				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
 				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
 				mockClient.EXPECT().UpdateServiceExpiry(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().UpdateServiceUsageExpiry("test-service", gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
+			},
+			httpStatus: http.StatusNoContent,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+				"roles":  []string{"manager"},
+			}),
+		},
+		{
+			name: "quota increase request approved",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", customValues{
+					"RequestType":   models.RequestQuotaIncrease,
+					"QuotaIncrease": &models.QuotaIncrease{Capacity: models.Capacity{CPU: 2, Memory: 4}, Duration: 7},
+				}).(*models.Request), nil).Times(1)
+				mockDBClient.EXPECT().NewUserQuota(gomock.Any()).DoAndReturn(func(quota *models.UserQuota) error {
+					assert.Equal(t, models.Capacity{CPU: 2, Memory: 4}, quota.Capacity)
+					assert.Equal(t, quota.StartsAt.AddDate(0, 0, 7), quota.ExpiresAt)
+					return nil
+				}).Times(1)
 				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), gomock.Any()).Return(nil).Times(1)
 				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
 			},
@@ -361,9 +756,73 @@ func TestApproveRequest(t *testing.T) {
 				"roles":  []string{"manager"},
 			}),
 		},
+		{
+			name: "request already approved",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", customValues{
+					"State": models.RequestStateApproved,
+				}).(*models.Request), nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+				"roles":  []string{"manager"},
+			}),
+		},
+		{
+			name: "request already expired",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", customValues{
+					"State": models.RequestStateExpired,
+				}).(*models.Request), nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+				"roles":  []string{"manager"},
+			}),
+		},
+		{
+			name: "group request approved by group owner",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", customValues{
+					"RequestType":    models.RequestAddToGroup,
+					"GroupAdmission": &models.GroupAdmission{GroupID: "test-group", Group: "test-group"},
+				}).(*models.Request), nil).Times(1)
+				mockDBClient.EXPECT().GetGroupOwnership("test-group").Return(&models.GroupOwnership{GroupID: "test-group",
+					Owners: []string{"12345"}}, nil).Times(1)
+				mockKCClient.EXPECT().AddUserToGroup(gomock.Any(), "test-group").Return(nil).Times(1)
+				mockKCClient.EXPECT().GetUserGroups(gomock.Any()).Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
+			},
+			httpStatus: http.StatusNoContent,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+		},
+		{
+			name: "group request of another group",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", customValues{
+					"RequestType":    models.RequestAddToGroup,
+					"GroupAdmission": &models.GroupAdmission{GroupID: "test-group-2", Group: "test-group-2"},
+				}).(*models.Request), nil).Times(1)
+				mockDBClient.EXPECT().GetGroupOwnership("test-group-2").Return(nil, utils.ErrResourceNotFound).Times(1)
+			},
+			httpStatus: http.StatusUnauthorized,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+		},
 		{
 			name: "not authorized to approve request",
 			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
 				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
 			},
 			httpStatus: http.StatusUnauthorized,
@@ -388,6 +847,159 @@ func TestApproveRequest(t *testing.T) {
 	}
 }
 
+func TestApproveRequestWithApprovalPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+	models.ApprovalPolicies = []models.ApprovalPolicy{{
+		Name:        "gold-group",
+		RequestType: models.RequestAddToGroup,
+		Groups:      []string{"gold"},
+		Stages:      []models.ApprovalStage{{Approvals: 1}, {ApproverGroup: "security", Approvals: 1}},
+	}}
+	defer func() { models.ApprovalPolicies = nil }()
+
+	firstApproval := &models.Approval{
+		Policy:    "gold-group",
+		Stages:    models.ApprovalPolicies[0].Stages,
+		Approvals: []models.RequestApproval{{UserID: "12345", Username: "admin", Stage: 0}},
+	}
+	groupRequest := func(group string, approval *models.Approval) *models.Request {
+		return getResource("get-request-by-id", customValues{
+			"RequestType":    models.RequestAddToGroup,
+			"GroupAdmission": &models.GroupAdmission{GroupID: "test-group", Group: group, Requester: "test-user"},
+			"Approval":       approval,
+		}).(*models.Request)
+	}
+
+	testcases := []struct {
+		name           string
+		mockFunc       func()
+		requestContext testContext
+		httpStatus     int
+	}{
+		{
+			name: "first stage approved",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(2)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(groupRequest("gold", nil), nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestApproval(gomock.Any(), gomock.Any(), 0).DoAndReturn(
+					func(_ string, approval *models.Approval, _ int) error {
+						assert.Equal(t, "gold-group", approval.Policy)
+						assert.Len(t, approval.Approvals, 1)
+						return nil
+					}).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
+			},
+			httpStatus: http.StatusAccepted,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+				"roles":  []string{"manager"},
+			}),
+		},
+		{
+			name: "group owner approving the stage of the admins",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(2)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(groupRequest("gold", nil), nil).Times(1)
+				mockDBClient.EXPECT().GetGroupOwnership("test-group").Return(&models.GroupOwnership{GroupID: "test-group",
+					Owners: []string{"12345"}}, nil).Times(1)
+			},
+			httpStatus: http.StatusUnauthorized,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+		},
+		{
+			name: "approver not member of the approver group",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(groupRequest("gold", firstApproval), nil).Times(1)
+			},
+			httpStatus: http.StatusUnauthorized,
+			requestContext: formContext(customValues{
+				"userid": "67890",
+				"roles":  []string{"manager"},
+			}),
+		},
+		{
+			name: "approver already approved",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(groupRequest("gold", firstApproval), nil).Times(1)
+			},
+			httpStatus: http.StatusUnauthorized,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+				"roles":  []string{"manager"},
+				"groups": formGroup(customValues{"id": "security-id", "name": "security"}),
+			}),
+		},
+		{
+			name: "approved concurrently by another approver",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(groupRequest("gold", firstApproval), nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestApproval(gomock.Any(), gomock.Any(), 1).Return(utils.ErrResourceModified).Times(1)
+			},
+			httpStatus: http.StatusConflict,
+			requestContext: formContext(customValues{
+				"userid": "67890",
+				"roles":  []string{"manager"},
+				"groups": formGroup(customValues{"id": "security-id", "name": "security"}),
+			}),
+		},
+		{
+			name: "approval chain satisfied",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(groupRequest("gold", firstApproval), nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestApproval(gomock.Any(), gomock.Any(), 1).Return(nil).Times(1)
+				mockKCClient.EXPECT().AddUserToGroup("12345", "test-group").Return(nil).Times(1)
+				mockKCClient.EXPECT().GetUserGroups("12345").Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), transitionTo(models.RequestStateApproved, "67890")).Return(nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
+			},
+			httpStatus: http.StatusNoContent,
+			requestContext: formContext(customValues{
+				"userid": "67890",
+				"roles":  []string{"manager"},
+				"groups": formGroup(customValues{"id": "security-id", "name": "security"}),
+			}),
+		},
+		{
+			name: "request not matching any policy",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(groupRequest("silver", nil), nil).Times(1)
+				mockKCClient.EXPECT().AddUserToGroup("12345", "test-group").Return(nil).Times(1)
+				mockKCClient.EXPECT().GetUserGroups("12345").Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), transitionTo(models.RequestStateApproved, "12345")).Return(nil).Times(1)
+				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
+			},
+			httpStatus: http.StatusNoContent,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+				"roles":  []string{"manager"},
+			}),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			req, err := http.NewRequest(http.MethodPost, "/requests", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req.WithContext(getContext(tc.requestContext))
+			dbCon = mockDBClient
+			ApproveRequest(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
+
 func TestRejectRequest(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	_, mockDBClient, mockKCClient, tearDown := setUp(t)
@@ -403,9 +1015,14 @@ func TestRejectRequest(t *testing.T) {
 		{
 			name: "successfully rejected request",
 			mockFunc: func() {
-				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(2)
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
 				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
-				mockDBClient.EXPECT().UpdateRequestStateWithComment(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), gomock.Any()).DoAndReturn(func(_ string, transition models.RequestTransition) error {
+					assert.Equal(t, models.RequestStateRejected, transition.To)
+					assert.Equal(t, "12345", transition.Actor)
+					assert.NotEmpty(t, transition.Comment)
+					return nil
+				}).Times(1)
 				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
 			},
 			httpStatus: http.StatusNoContent,
@@ -425,12 +1042,29 @@ func TestRejectRequest(t *testing.T) {
 			}),
 			request: getResource("get-request-by-id", nil).(*models.Request),
 		},
+		{
+			name: "expired request not rejected",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", customValues{
+					"State": models.RequestStateExpired,
+				}).(*models.Request), nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+				"roles":  []string{"manager"},
+			}),
+			request: getResource("get-request-by-id", nil).(*models.Request),
+		},
 		{
 			name: "not authorized to approve request",
 			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
 				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(false).Times(1)
 			},
 			httpStatus: http.StatusUnauthorized,
+			request:    getResource("get-request-by-id", nil).(*models.Request),
 		},
 	}
 	for _, tc := range testcases {
@@ -472,10 +1106,25 @@ func TestDeleteRequest(t *testing.T) {
 				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", nil).(*models.Request), nil).Times(1)
 				mockKCClient.EXPECT().GetUserID().Return("12345").Times(1)
 				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
-				mockDBClient.EXPECT().DeleteRequest(gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), transitionTo(models.RequestStateDeleted, "12345")).Return(nil).Times(1)
 				mockDBClient.EXPECT().NewEvent(gomock.Any()).Times(1)
 			},
 			httpStatus: http.StatusNoContent,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
+		},
+		{
+			name: "request already deleted",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetRequestByID(gomock.Any()).Return(getResource("get-request-by-id", customValues{
+					"State": models.RequestStateDeleted,
+				}).(*models.Request), nil).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+			requestContext: formContext(customValues{
+				"userid": "12345",
+			}),
 		},
 		{
 			name: "not authorized to approve request",
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+// GetRules			godoc
+// @Summary			Get all rules
+// @Description		Get all the rules auto-deciding the requests in the order of evaluation
+// @Tags			rules
+// @Accept			json
+// @Produce			json
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			200
+// @Router			/api/v1/rules [get]
+func GetRules(c *gin.Context) {
+	logger := log.GetLogger()
+	rules, err := dbCon.GetRules()
+	if err != nil {
+		logger.Error("failed to get rules", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%v", err)})
+		return
+	}
+	logger.Debug("fetched rules", zap.Any("rules", rules))
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRule			godoc
+// @Summary			Get rule
+// @Description		Get rule
+// @Tags			rules
+// @Accept			json
+// @Produce			json
+// @Param			id path string true "rule-id for the rule to be fetched"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			200
+// @Router			/api/v1/rules/{id} [get]
+func GetRule(c *gin.Context) {
+	logger := log.GetLogger()
+	id := c.Param("id")
+	rule, err := dbCon.GetRuleByID(id)
+	if err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("rule with id: %s not found", id)})
+			return
+		}
+		logger.Error("failed to get rule", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRule			godoc
+// @Summary			Create rule
+// @Description		Create rule to auto-approve or auto-reject the requests
+// @Tags			rules
+// @Accept			json
+// @Produce			json
+// @Param			rule body models.Rule true "Create rule"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			201
+// @Router			/api/v1/rules [post]
+func CreateRule(c *gin.Context) {
+	originator := c.Request.Context().Value("userid").(string)
+	logger := log.GetLogger()
+	var rule models.Rule
+	if err := c.BindJSON(&rule); err != nil {
+		logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to bind request, Error: %v", err.Error())})
+		return
+	}
+	if err := validateRuleParams(rule); len(err) > 0 {
+		logger.Error("error in create rule validation", zap.Errors("errors", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%v", err)})
+		return
+	}
+	rule.ID = primitive.NilObjectID
+	rule.CreatedBy = originator
+	rule.CreatedAt = time.Now()
+	id, err := dbCon.NewRule(&rule)
+	if err != nil {
+		logger.Error("failed to create rule", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to insert the rule into the db, err: %s", err.Error())})
+		return
+	}
+	logger.Debug("successfully created rule", zap.String("id", id))
+	c.JSON(http.StatusCreated, gin.H{"id": id})
+}
+
+// UpdateRule			godoc
+// @Summary			Update rule
+// @Description		Update rule
+// @Tags			rules
+// @Accept			json
+// @Produce			json
+// @Param			id path string true "rule-id for the rule to be updated"
+// @Param			rule body models.Rule true "Update rule"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			204
+// @Router			/api/v1/rules/{id} [put]
+func UpdateRule(c *gin.Context) {
+	logger := log.GetLogger()
+	id := c.Param("id")
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid rule id: %s", id)})
+		return
+	}
+	var rule models.Rule
+	if err := c.BindJSON(&rule); err != nil {
+		logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to bind request, Error: %v", err.Error())})
+		return
+	}
+	if err := validateRuleParams(rule); len(err) > 0 {
+		logger.Error("error in update rule validation", zap.Errors("errors", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%v", err)})
+		return
+	}
+	rule.ID = objectID
+	if err := dbCon.UpdateRule(&rule); err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("rule with id: %s not found", id)})
+			return
+		}
+		logger.Error("failed to update rule", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%v", err)})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteRule			godoc
+// @Summary			Delete rule
+// @Description		Delete rule
+// @Tags			rules
+// @Accept			json
+// @Produce			json
+// @Param			id path string true "rule-id for the rule to be deleted"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			204
+// @Router			/api/v1/rules/{id} [delete]
+func DeleteRule(c *gin.Context) {
+	logger := log.GetLogger()
+	id := c.Param("id")
+	if err := dbCon.DeleteRule(id); err != nil {
+		if errors.Is(err, utils.ErrResourceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("rule with id: %s not found", id)})
+			return
+		}
+		logger.Error("failed to delete rule", zap.String("id", id), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("%v", err)})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DryRunRule			godoc
+// @Summary			Dry-run rule
+// @Description		Evaluate the rule against the historical requests without deciding any of them
+// @Tags			rules
+// @Accept			json
+// @Produce			json
+// @Param			rule body models.Rule true "Rule to be evaluated"
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Success			200
+// @Router			/api/v1/rules/dry-run [post]
+func DryRunRule(c *gin.Context) {
+	logger := log.GetLogger()
+	var rule models.Rule
+	if err := c.BindJSON(&rule); err != nil {
+		logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to bind request, Error: %v", err.Error())})
+		return
+	}
+	if err := validateRuleParams(rule); len(err) > 0 {
+		logger.Error("error in dry-run rule validation", zap.Errors("errors", err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%v", err)})
+		return
+	}
+
+	requests, err := dbCon.GetRequestsByUserID("", string(rule.Match.RequestType))
+	if err != nil {
+		logger.Error("failed to get requests", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	config := client.GetConfigFromContext(c.Request.Context())
+	kc := client.NewKeyCloakClient(config, c.Request.Context())
+	emails := make(map[string]string)
+	groups := make(map[string][]string)
+	dryRun := models.RuleDryRun{Requests: len(requests), Results: []models.RuleDryRunResult{}}
+	for i := range requests {
+		request := &requests[i]
+		result := models.RuleDryRunResult{RequestID: request.ID.Hex(), UserID: request.UserID, State: request.State}
+		input, err := getHistoricalRuleInput(kc, request, emails, groups)
+		if err != nil {
+			logger.Error("failed to get the facts of the request", zap.String("id", request.ID.Hex()), zap.Error(err))
+			result.Error = err.Error()
+			dryRun.Results = append(dryRun.Results, result)
+			continue
+		}
+		if result.Matched = matchRule(rule, input); result.Matched {
+			dryRun.Matched++
+			switch {
+			case rule.Action == models.RuleActionApprove && request.State != models.RequestStateApproved,
+				rule.Action == models.RuleActionReject && request.State != models.RequestStateRejected:
+				result.Differs = true
+				dryRun.Differs++
+			}
+		}
+		dryRun.Results = append(dryRun.Results, result)
+	}
+	c.JSON(http.StatusOK, dryRun)
+}
+
+// getHistoricalRuleInput collects the facts about the request as of now, the emails and the groups of the users are
+// cached across the requests.
+func getHistoricalRuleInput(kc client.Keycloak, request *models.Request, emails map[string]string, groups map[string][]string) (models.RuleInput, error) {
+	input := models.RuleInput{Request: request}
+	if _, ok := emails[request.UserID]; !ok {
+		user, err := kc.GetUser(request.UserID)
+		if err != nil {
+			return input, fmt.Errorf("failed to get user: %w", err)
+		}
+		userGroups, err := kc.GetUserGroups(request.UserID)
+		if err != nil {
+			return input, fmt.Errorf("failed to get groups of the user: %w", err)
+		}
+		emails[request.UserID] = ""
+		if user.Email != nil {
+			emails[request.UserID] = *user.Email
+		}
+		for _, group := range userGroups {
+			groups[request.UserID] = append(groups[request.UserID], *group.Name)
+		}
+	}
+	input.Email = emails[request.UserID]
+	input.Groups = groups[request.UserID]
+
+	if request.RequestType == models.RequestExtendServiceExpiry && request.ServiceExpiry != nil {
+		input.ExtensionDays = request.ServiceExpiry.Expiry.Sub(request.CreatedAt).Hours() / 24
+		// the catalog is unknown once the service is deleted, the rules on the extension do not match then
+		if service, err := kubeClient.GetService(request.ServiceExpiry.Name); err == nil {
+			if catalog, err := kubeClient.GetCatalog(service.Spec.Catalog.Name); err == nil {
+				input.CatalogExpiry = catalog.Spec.Expiry
+			}
+		}
+	}
+	return input, nil
+}
+
+// decideRequest evaluates the rules against the request created by the user, the request matching a rule is approved or
+// rejected on behalf of the admins. The decision is nil if no rule matched or the decision could not be carried out,
+// leaving the request to the admins.
+func decideRequest(c *gin.Context, id string, input models.RuleInput) *models.RuleDecision {
+	logger := log.GetLogger()
+	rules, err := dbCon.GetRules()
+	if err != nil {
+		logger.Error("failed to get rules", zap.Error(err))
+		return nil
+	}
+	var candidates []models.Rule
+	var needEmail bool
+	for _, rule := range rules {
+		if rule.Enabled && rule.Match.RequestType == input.Request.RequestType {
+			candidates = append(candidates, rule)
+			needEmail = needEmail || len(rule.Match.EmailDomains) > 0
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	if needEmail {
+		config := client.GetConfigFromContext(c.Request.Context())
+		userInfo, err := client.NewKeyCloakClient(config, c.Request.Context()).GetUserInfo()
+		if err != nil {
+			logger.Error("failed to get user info", zap.Error(err))
+			return nil
+		}
+		if userInfo.Email != nil {
+			input.Email = *userInfo.Email
+		}
+	}
+
+	var rule *models.Rule
+	for i := range candidates {
+		if matchRule(candidates[i], input) {
+			rule = &candidates[i]
+			break
+		}
+	}
+	if rule == nil {
+		return nil
+	}
+	// requests requiring an approval chain are approved only by the admins
+	if rule.Action == models.RuleActionApprove && getApprovalPolicy(input.Request) != nil {
+		logger.Debug("request requires an approval chain, not auto-approving", zap.String("id", id), zap.String("rule", rule.ID.Hex()))
+		return nil
+	}
+
+	decision := &models.RuleDecision{RuleID: rule.ID.Hex(), Action: rule.Action, Reason: rule.Reason, DecidedAt: time.Now()}
+	state := models.RequestStateRejected
+	if rule.Action == models.RuleActionApprove {
+		state = models.RequestStateApproved
+	}
+	// the decision is recorded before it is carried out, so that the request decided by an admin meanwhile is not
+	// carried out twice
+	transition := models.NewRequestTransition(input.Request, state, models.RequestActorSystem, rule.Reason)
+	if err := dbCon.UpdateRequestDecision(id, transition, decision); err != nil {
+		logger.Error("failed to record the decision of the rule", zap.String("id", id), zap.String("rule", rule.ID.Hex()), zap.Error(err))
+		return nil
+	}
+	request := *input.Request
+	request.ID, _ = primitive.ObjectIDFromHex(id)
+	request.State = state
+	if rule.Action == models.RuleActionApprove {
+		err = applyApproval(c, &request)
+	} else {
+		err = applyRejection(c, &request)
+	}
+	if err != nil {
+		logger.Error("failed to carry out the decision of the rule", zap.String("id", id), zap.String("rule", rule.ID.Hex()), zap.Error(err))
+		// the request is left to the admins
+		revert := models.NewRequestTransition(&request, models.RequestStateNew, models.RequestActorSystem,
+			fmt.Sprintf("failed to carry out the decision of the rule %s", rule.ID.Hex()))
+		if err := dbCon.RevertRequestDecision(id, revert); err != nil {
+			logger.Error("failed to revert the decision of the rule", zap.String("id", id), zap.String("rule", rule.ID.Hex()), zap.Error(err))
+		}
+		return nil
+	}
+	logger.Debug("request decided by rule", zap.String("id", id), zap.Any("decision", decision))
+	return decision
+}
+
+// setDecisionEvent notifies the requester of the decision taken by the rule on the request
+func setDecisionEvent(event *models.Event, id string, request *models.Request, decision *models.RuleDecision) {
+	decided := "rejected"
+	event.SetType(models.EventTypeRequestRejected)
+	if decision.Action == models.RuleActionApprove {
+		decided = "approved"
+		event.SetType(models.EventTypeRequestApproved)
+	}
+	event.SetNotify()
+	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request(%s) has been automatically %s by rule %s, reason: %s", id, decided,
+		decision.RuleID, decision.Reason))
+	setRequestResource(event, id, request)
+	event.SetMetadata(models.EventMetadataRuleID, decision.RuleID)
+}
+
+// getContextGroupNames returns the names of the groups of the user making the request
+func getContextGroupNames(c *gin.Context) []string {
+	var names []string
+	groups, _ := c.Request.Context().Value("groups").([]models.Group)
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names
+}
+
+// matchRule returns true if the request satisfies all the conditions of the rule
+func matchRule(rule models.Rule, input models.RuleInput) bool {
+	match := rule.Match
+	request := input.Request
+	if match.RequestType != request.RequestType {
+		return false
+	}
+	if len(match.Groups) > 0 && (request.GroupAdmission == nil || !containsFold(match.Groups, request.GroupAdmission.Group)) {
+		return false
+	}
+	if len(match.EmailDomains) > 0 {
+		_, domain, found := strings.Cut(input.Email, "@")
+		if !found || !containsFold(match.EmailDomains, domain) {
+			return false
+		}
+	}
+	if len(match.MemberOf) > 0 {
+		var member bool
+		for _, group := range input.Groups {
+			if containsFold(match.MemberOf, group) {
+				member = true
+				break
+			}
+		}
+		if !member {
+			return false
+		}
+	}
+	if match.MaxExtensionPercent > 0 || match.MinExtensionPercent > 0 {
+		if input.CatalogExpiry == 0 {
+			return false
+		}
+		percent := input.ExtensionDays / float64(input.CatalogExpiry) * 100
+		if match.MaxExtensionPercent > 0 && percent > match.MaxExtensionPercent {
+			return false
+		}
+		if match.MinExtensionPercent > 0 && percent <= match.MinExtensionPercent {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateRuleParams(rule models.Rule) []error {
+	var errs []error
+	if rule.Name == "" {
+		errs = append(errs, errors.New("name should be set"))
+	}
+	if rule.Reason == "" {
+		errs = append(errs, errors.New("reason should be set"))
+	}
+	if rule.Action != models.RuleActionApprove && rule.Action != models.RuleActionReject {
+		errs = append(errs, fmt.Errorf("invalid action: \"%s\" is set, valid values are %s %s", rule.Action,
+			models.RuleActionApprove, models.RuleActionReject))
+	}
+	match := rule.Match
+	switch match.RequestType {
+	case models.RequestAddToGroup:
+		if match.MaxExtensionPercent != 0 || match.MinExtensionPercent != 0 {
+			errs = append(errs, errors.New("extension can be matched only for the service expiry requests"))
+		}
+	case models.RequestExtendServiceExpiry:
+		if len(match.Groups) > 0 {
+			errs = append(errs, errors.New("groups can be matched only for the group requests"))
+		}
+		if match.MaxExtensionPercent < 0 || match.MinExtensionPercent < 0 {
+			errs = append(errs, errors.New("extension percent should not be negative"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("invalid request_type: \"%s\" is set, valid values are %s %s", match.RequestType,
+			models.RequestAddToGroup, models.RequestExtendServiceExpiry))
+	}
+	return errs
+}
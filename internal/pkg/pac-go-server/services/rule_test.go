@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Nerzal/gocloak/v13"
+	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, _, tearDown := setUp(t)
+	defer tearDown()
+
+	testcases := []struct {
+		name       string
+		mockFunc   func()
+		rule       models.Rule
+		httpStatus int
+	}{
+		{
+			name: "rule created successfully",
+			mockFunc: func() {
+				mockDBClient.EXPECT().NewRule(gomock.Any()).DoAndReturn(func(rule *models.Rule) (string, error) {
+					assert.Equal(t, "12345", rule.CreatedBy)
+					return "123", nil
+				}).Times(1)
+			},
+			rule: models.Rule{Name: "short extensions", Enabled: true, Action: models.RuleActionApprove, Reason: "short extension",
+				Match: models.RuleMatch{RequestType: models.RequestExtendServiceExpiry, MaxExtensionPercent: 50}},
+			httpStatus: http.StatusCreated,
+		},
+		{
+			name:     "invalid action",
+			mockFunc: func() {},
+			rule: models.Rule{Name: "invalid", Action: "IGNORE", Reason: "reason",
+				Match: models.RuleMatch{RequestType: models.RequestAddToGroup}},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "extension matched for group request",
+			mockFunc: func() {},
+			rule: models.Rule{Name: "invalid", Action: models.RuleActionApprove, Reason: "reason",
+				Match: models.RuleMatch{RequestType: models.RequestAddToGroup, MaxExtensionPercent: 50}},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "unsupported request type",
+			mockFunc: func() {},
+			rule: models.Rule{Name: "invalid", Action: models.RuleActionApprove, Reason: "reason",
+				Match: models.RuleMatch{RequestType: models.RequestDeleteUser}},
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			marshalledRule, _ := json.Marshal(tc.rule)
+			req, err := http.NewRequest(http.MethodPost, "/rules", bytes.NewBuffer(marshalledRule))
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Request = req.WithContext(getContext(formContext(customValues{"userid": "12345"})))
+			dbCon = mockDBClient
+			CreateRule(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+		})
+	}
+}
+
+func TestDryRunRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+
+	createdAt := time.Now().AddDate(0, 0, -30)
+	expiryRequest := func(id byte, userID string, state models.RequestStateType, days int) models.Request {
+		return models.Request{ID: [12]byte{id}, UserID: userID, State: state, CreatedAt: createdAt,
+			RequestType:   models.RequestExtendServiceExpiry,
+			ServiceExpiry: &models.ServiceExpiry{Name: "test-service", Expiry: createdAt.AddDate(0, 0, days)}}
+	}
+	// catalog expiry is 10 days, i.e. the rule matches the extensions up to 5 days
+	rule := models.Rule{Name: "short extensions", Enabled: true, Action: models.RuleActionApprove, Reason: "short extension",
+		Match: models.RuleMatch{RequestType: models.RequestExtendServiceExpiry, EmailDomains: []string{"example.com"}, MaxExtensionPercent: 50}}
+
+	mockDBClient.EXPECT().GetRequestsByUserID("", string(models.RequestExtendServiceExpiry)).Return([]models.Request{
+		expiryRequest(1, "user-1", models.RequestStateApproved, 2),
+		expiryRequest(2, "user-1", models.RequestStateRejected, 3),
+		expiryRequest(3, "user-1", models.RequestStateApproved, 6),
+		expiryRequest(4, "user-2", models.RequestStateNew, 1),
+	}, nil).Times(1)
+	mockKCClient.EXPECT().GetUser("user-1").Return(&gocloak.User{Email: utils.Ptr("user-1@example.com")}, nil).Times(1)
+	mockKCClient.EXPECT().GetUserGroups("user-1").Return(nil, nil).Times(1)
+	mockKCClient.EXPECT().GetUser("user-2").Return(nil, errors.New("user not found")).Times(1)
+	mockClient.EXPECT().GetService("test-service").Return(getResource("get-service", nil).(pac.Service), nil).AnyTimes()
+	mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).AnyTimes()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	marshalledRule, _ := json.Marshal(rule)
+	req, err := http.NewRequest(http.MethodPost, "/rules/dry-run", bytes.NewBuffer(marshalledRule))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Request = req.WithContext(getContext(formContext(customValues{"userid": "12345"})))
+	kubeClient = mockClient
+	dbCon = mockDBClient
+	DryRunRule(c)
+	assert.Equal(t, http.StatusOK, c.Writer.Status())
+
+	var dryRun models.RuleDryRun
+	if err := json.Unmarshal(w.Body.Bytes(), &dryRun); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 4, dryRun.Requests)
+	assert.Equal(t, 2, dryRun.Matched)
+	assert.Equal(t, 1, dryRun.Differs)
+	assert.True(t, dryRun.Results[1].Differs)
+	assert.False(t, dryRun.Results[2].Matched)
+	assert.NotEmpty(t, dryRun.Results[3].Error)
+}
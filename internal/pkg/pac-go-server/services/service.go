@@ -212,17 +212,15 @@ func CreateService(c *gin.Context) {
 	}
 	logger.Debug("user quota", zap.Any("quota", quota))
 
-	// fetch the user used quota across all provisioned services
-	usedQuota, err := getUsedQuota(userId)
-	if err != nil {
-		logger.Error("failed to get used quota", zap.String("userid", userId), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to get used quota %v", err)})
+	// reconcile the quota reserved by the user with the provisioned services
+	if err := syncQuotaLedger(userId); err != nil {
+		logger.Error("failed to sync quota ledger", zap.String("userid", userId), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get used quota %v", err)})
 		return
 	}
-	logger.Debug("user used quota", zap.Any("used quota", usedQuota))
 
-	// calculate the total user capacity need to provision service
-	neededCapacity, err := AddCapacity(usedQuota, catalog.Spec.Capacity)
+	// calculate the capacity needed to provision service
+	neededCapacity, err := AddCapacity(models.Capacity{}, catalog.Spec.Capacity)
 	if err != nil {
 		logger.Error("failed to needed capacity", zap.String("userid", userId), zap.Error(err))
 		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("failed to get needed capacity %v", err)})
@@ -230,29 +228,71 @@ func CreateService(c *gin.Context) {
 	}
 	logger.Debug("needed capacity", zap.Any("needed capacity", neededCapacity))
 
-	// calculate the remaining capacity of user if provision this service
-	remainingCapacity := models.Capacity{
-		CPU:    quota.CPU - neededCapacity.CPU,
-		Memory: quota.Memory - neededCapacity.Memory,
-	}
-	logger.Debug("remaining capacity", zap.Any("remaining capacity", remainingCapacity))
+	service.UserID = userId
+	startedAt := time.Now()
+	service.Expiry = startedAt.Add(time.Hour * 24 * time.Duration(catalog.Spec.Expiry))
+	// generate unique service name
+	serviceName := generateServiceName(service)
 
-	if remainingCapacity.CPU < 0 || remainingCapacity.Memory < 0 {
-		logger.Error("user does not have sufficient quota to provision service", zap.Any("required capacity", catalog.Spec.Capacity),
-			zap.Any("user quota", quota), zap.Any("used capacity", usedQuota))
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("user does not have quota to provision resource, Quota: %v Required: %v Used: %v",
-			quota, catalog.Spec.Capacity, usedQuota)})
+	// reserve the capacity atomically, so that the parallel requests of the user cannot overshoot the quota
+	reservation := models.QuotaReservation{
+		ServiceName: serviceName,
+		Capacity:    neededCapacity,
+		ReservedAt:  time.Now(),
+	}
+	err = dbCon.ReserveQuota(userId, reservation, quota)
+	if err == nil {
+		// the parallel requests of the members of a pooled group cannot overshoot the pool either
+		if err = reservePooledQuota(c, userId, reservation); err != nil {
+			releaseServiceCapacity(serviceName)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, utils.ErrQuotaExceeded) {
+			logger.Error("user does not have sufficient quota to provision service", zap.Any("required capacity", catalog.Spec.Capacity),
+				zap.Any("user quota", quota))
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("user does not have quota to provision resource, Quota: %v Required: %v",
+				quota, catalog.Spec.Capacity)})
+			return
+		}
+		logger.Error("failed to reserve quota", zap.String("userid", userId), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to reserve quota %v", err)})
 		return
 	}
 
-	service.UserID = userId
-	service.Expiry = time.Now().Add(time.Hour * 24 * time.Duration(catalog.Spec.Expiry))
-	// generate unique service name
-	serviceName := generateServiceName(service)
+	// the usage is recorded ahead of checking the core-hours budget, so that the parallel requests of the user account
+	// for each other and cannot overshoot the budget together
+	if err := dbCon.NewServiceUsage(&models.ServiceUsage{
+		UserID:      userId,
+		ServiceName: serviceName,
+		CatalogName: catalog.Name,
+		CPU:         neededCapacity.CPU,
+		Memory:      neededCapacity.Memory,
+		StartedAt:   startedAt,
+		ExpiresAt:   service.Expiry,
+	}); err != nil {
+		releaseServiceCapacity(serviceName)
+		logger.Error("failed to record service usage", zap.String("service name", serviceName), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to record service usage %v", err)})
+		return
+	}
+	// the core-hours of the service are committed already
+	if err := checkCoreHoursBudget(userId, quota, 0, startedAt, service.Expiry); err != nil {
+		releaseServiceCapacity(serviceName)
+		if errors.Is(err, utils.ErrQuotaExceeded) {
+			logger.Error("user does not have sufficient core-hours to provision service", zap.String("userid", userId), zap.Error(err))
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("user does not have core-hours to provision resource, %v", err)})
+			return
+		}
+		logger.Error("failed to check core-hours budget", zap.String("userid", userId), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to check core-hours budget %v", err)})
+		return
+	}
 
 	// create service
 	logger.Debug("service create params", zap.String("service name", serviceName), zap.Any("service", service), zap.Any("sshKey", sshKeys))
 	if err := kubeClient.CreateService(createServiceObject(serviceName, keys, service)); err != nil {
+		releaseServiceCapacity(serviceName)
 		if errors.Is(err, utils.ErrResourceAlreadyExists) {
 			logger.Error("service already exists", zap.String("service name", serviceName))
 			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("service with name: %s already exists", serviceName)})
@@ -263,9 +303,30 @@ func CreateService(c *gin.Context) {
 		return
 	}
 	logger.Debug("successfully created service")
+
+	recordUsage(models.UsageRecord{
+		UserID:      userId,
+		ServiceName: serviceName,
+		CatalogName: catalog.Name,
+		Transition:  models.UsageTransitionCreated,
+		Capacity:    models.Capacity{CPU: neededCapacity.CPU, Memory: neededCapacity.Memory},
+		Timestamp:   startedAt,
+	})
+	checkQuotaThresholds(c, userId)
 	c.Status(http.StatusCreated)
 }
 
+// releaseServiceCapacity releases the quota reserved and removes the usage recorded for the service not provisioned
+func releaseServiceCapacity(serviceName string) {
+	logger := log.GetLogger()
+	if err := dbCon.DeleteServiceUsage(serviceName); err != nil {
+		logger.Error("failed to delete service usage", zap.String("service name", serviceName), zap.Error(err))
+	}
+	if err := dbCon.ReleaseQuota(serviceName); err != nil {
+		logger.Error("failed to release quota", zap.String("service name", serviceName), zap.Error(err))
+	}
+}
+
 // DeleteService		godoc
 // @Summary			Delete service
 // @Description		Delete service
@@ -315,17 +376,43 @@ func deleteService(c *gin.Context, serviceName string) error {
 		event, err := models.NewEvent(userId, userId, models.EventServiceDeleteFailed)
 		if err != nil {
 			logger.Error("failed to create event", zap.Error(err))
+			return fmt.Errorf("failed to delete service : %s", serviceName)
 		}
+		event.SetNotifyAdmin()
+		event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Admin has been notified that service deletion has failed, service-name: %s", serviceName))
+		event.SetResource(models.EventResourceService, "", serviceName)
+		event.SetMetadata(models.EventMetadataServiceName, serviceName)
 		if err := dbCon.NewEvent(event); err != nil {
 			logger.Error("failed to create event in db", zap.Error(err))
 		}
-		event.SetNotifyAdmin()
-		event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Admin has been notified that service deletion has failed, service-name: %s", serviceName))
 
 		return fmt.Errorf("failed to delete service : %s", serviceName)
 	}
 	logger.Debug("successfully deleted service", zap.String("service name", serviceName))
 
+	if err := dbCon.ReleaseQuota(serviceName); err != nil {
+		logger.Error("failed to release quota", zap.String("service name", serviceName), zap.Error(err))
+	}
+	// owner of the service is known from the usage, when deleted by the admin
+	owner := userId
+	usage, err := dbCon.EndServiceUsage(serviceName, time.Now())
+	if err != nil {
+		logger.Error("failed to record service usage", zap.String("service name", serviceName), zap.Error(err))
+	} else if usage != nil {
+		owner = usage.UserID
+		recordUsage(models.UsageRecord{
+			UserID:      usage.UserID,
+			ServiceName: serviceName,
+			CatalogName: usage.CatalogName,
+			Transition:  models.UsageTransitionDeleted,
+			Capacity:    models.Capacity{CPU: usage.CPU, Memory: usage.Memory},
+			Timestamp:   *usage.EndedAt,
+		})
+	}
+	if owner != "" {
+		checkQuotaThresholds(c, owner)
+	}
+
 	// Delete associated service-expiry-extension requests if present
 	// Not generating event for request deletion as service is already deleted (To be discussed)
 	req, err := dbCon.GetRequestByServiceName(serviceName)
@@ -336,9 +423,10 @@ func deleteService(c *gin.Context, serviceName string) error {
 	}
 	logger.Debug("fetched request", zap.Any("request", req))
 	for _, request := range req {
-		if request.RequestType == models.RequestExtendServiceExpiry {
-			if err := dbCon.DeleteRequest(request.ID.String()); err != nil {
-				logger.Error("failed to delete request in database", zap.String("id", request.ID.String()), zap.Error(err))
+		if request.RequestType == models.RequestExtendServiceExpiry && request.State != models.RequestStateDeleted {
+			transition := models.NewRequestTransition(&request, models.RequestStateDeleted, kc.GetUserID(), "")
+			if err := dbCon.UpdateRequestState(request.ID.Hex(), transition); err != nil {
+				logger.Error("failed to delete request in database", zap.String("id", request.ID.Hex()), zap.Error(err))
 			}
 		}
 
@@ -414,14 +502,19 @@ func generateServiceName(service models.Service) string {
 
 // getUsedQuota calculates and returns the total capacity consumed by user provisioned service
 func getUsedQuota(userId string) (models.Capacity, error) {
-	var consumedCapacity models.Capacity
-	catalogMap := make(map[string]float64)
 	serviceList, err := kubeClient.GetServices(userId)
 	if err != nil {
-		return consumedCapacity, fmt.Errorf("failed to get user services %v", err)
+		return models.Capacity{}, fmt.Errorf("failed to get user services %v", err)
 	}
+	return getServicesCapacity(serviceList.Items)
+}
+
+// getServicesCapacity calculates and returns the total capacity consumed by the services
+func getServicesCapacity(services []pac.Service) (models.Capacity, error) {
+	var consumedCapacity models.Capacity
+	catalogMap := make(map[string]float64)
 	// fetch the catalogs associated with the service
-	for _, svc := range serviceList.Items {
+	for _, svc := range services {
 		// ignore the expired services
 		if svc.Status.State == pac.ServiceStateExpired {
 			continue
@@ -441,6 +534,7 @@ func getUsedQuota(userId string) (models.Capacity, error) {
 		}
 		consumedCapacity.CPU += count * cpu
 		consumedCapacity.Memory += int(count) * catalog.Spec.Capacity.Memory
+		consumedCapacity.Services += int(count)
 	}
 	return consumedCapacity, nil
 }
@@ -453,7 +547,8 @@ func AddCapacity(capacity models.Capacity, catalogCapacity pac.Capacity) (models
 		return models.Capacity{}, err
 	}
 	return models.Capacity{
-		CPU:    capacity.CPU + cpu,
-		Memory: capacity.Memory + catalogCapacity.Memory,
+		CPU:      capacity.CPU + cpu,
+		Memory:   capacity.Memory + catalogCapacity.Memory,
+		Services: capacity.Services + 1,
 	}, nil
 }
@@ -6,10 +6,14 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/Nerzal/gocloak/v13"
 	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
 	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -138,10 +142,18 @@ func TestCreateService(t *testing.T) {
 			mockFunc: func() {
 				mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).Times(2)
 				mockClient.EXPECT().CreateService(gomock.Any()).Return(nil).Times(1)
-				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(1)
+				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(2)
 				mockKCClient.EXPECT().GetUserID().Return("122344").Times(2)
 				mockDBClient.EXPECT().GetKeyByUserID(gomock.Any()).Return(getResource("get-key-by-userid", nil).([]models.Key), nil).Times(1)
-				mockDBClient.EXPECT().GetGroupsQuota(gomock.Any()).Return(getResource("get-groups-quota", nil).([]models.Quota), nil).Times(1)
+				mockDBClient.EXPECT().GetGroupsQuota(gomock.Any()).Return(getResource("get-groups-quota", nil).([]models.Quota), nil).Times(2)
+				mockDBClient.EXPECT().GetActiveUserQuotas(gomock.Any()).Return(nil, nil).Times(2)
+				mockDBClient.EXPECT().GetQuotaAlert(models.QuotaAlertUser, "122344").Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().UpdateQuotaAlert(gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().GetQuotaLedger(gomock.Any()).Return(&models.QuotaLedger{}, nil).Times(1)
+				mockDBClient.EXPECT().AddQuotaReservation(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().ReserveQuota(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().NewServiceUsage(gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().NewUsageRecord(gomock.Any()).Return(nil).Times(1)
 			},
 			service: getResource("create-service", nil).(models.Service),
 			requestContext: formContext(customValues{
@@ -160,6 +172,42 @@ func TestCreateService(t *testing.T) {
 			}),
 			httpStatus: http.StatusCreated,
 		},
+		{
+			name: "core-hours budget exceeded",
+			mockFunc: func() {
+				mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).Times(2)
+				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(1)
+				mockKCClient.EXPECT().GetUserID().Return("122344").Times(2)
+				mockDBClient.EXPECT().GetKeyByUserID(gomock.Any()).Return(getResource("get-key-by-userid", nil).([]models.Key), nil).Times(1)
+				mockDBClient.EXPECT().GetGroupsQuota(gomock.Any()).Return(getResource("get-groups-quota", customValues{
+					"Capacity": models.Capacity{CPU: 10, Memory: 10, CoreHours: 100},
+				}).([]models.Quota), nil).Times(2)
+				mockDBClient.EXPECT().GetActiveUserQuotas(gomock.Any()).Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().GetQuotaLedger(gomock.Any()).Return(&models.QuotaLedger{}, nil).Times(1)
+				mockDBClient.EXPECT().AddQuotaReservation(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().ReserveQuota(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				// the usage of the service is accounted in the budget check, which undoes the reservation
+				var usage models.ServiceUsage
+				mockDBClient.EXPECT().NewServiceUsage(gomock.Any()).DoAndReturn(func(u *models.ServiceUsage) error {
+					usage = *u
+					return nil
+				}).Times(1)
+				mockDBClient.EXPECT().GetServiceUsage(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ []string, _, _ time.Time) ([]models.ServiceUsage, error) {
+						return []models.ServiceUsage{usage}, nil
+					}).Times(1)
+				mockDBClient.EXPECT().DeleteServiceUsage(gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().ReleaseQuota(gomock.Any()).Return(nil).Times(1)
+			},
+			service: getResource("create-service", nil).(models.Service),
+			requestContext: formContext(customValues{
+				"groups": formGroup(customValues{
+					"id":   "122343",
+					"name": "silver",
+				}),
+			}),
+			httpStatus: http.StatusBadRequest,
+		},
 		{
 			name: "catalog is retired",
 			mockFunc: func() {
@@ -167,6 +215,10 @@ func TestCreateService(t *testing.T) {
 				mockKCClient.EXPECT().GetUserID().Return("122344").Times(2)
 				mockDBClient.EXPECT().GetKeyByUserID(gomock.Any()).Return(getResource("get-key-by-userid", nil).([]models.Key), nil).Times(1)
 				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas(gomock.Any()).Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().GetQuotaLedger(gomock.Any()).Return(&models.QuotaLedger{}, nil).Times(1)
+				mockDBClient.EXPECT().AddQuotaReservation(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().ReserveQuota(gomock.Any(), gomock.Any(), gomock.Any()).Return(utils.ErrQuotaExceeded).Times(1)
 			},
 			service:    getResource("create-service", customValues{"retired": "true"}).(models.Service),
 			httpStatus: http.StatusBadRequest,
@@ -178,10 +230,49 @@ func TestCreateService(t *testing.T) {
 				mockKCClient.EXPECT().GetUserID().Return("122344").Times(2)
 				mockDBClient.EXPECT().GetKeyByUserID(gomock.Any()).Return(getResource("get-key-by-userid", nil).([]models.Key), nil).Times(1)
 				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas(gomock.Any()).Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().GetQuotaLedger(gomock.Any()).Return(&models.QuotaLedger{}, nil).Times(1)
+				mockDBClient.EXPECT().AddQuotaReservation(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().ReserveQuota(gomock.Any(), gomock.Any(), gomock.Any()).Return(utils.ErrQuotaExceeded).Times(1)
 			},
 			service:    getResource("create-service", customValues{"ready": "false"}).(models.Service),
 			httpStatus: http.StatusBadRequest,
 		},
+		{
+			name: "pooled quota taken by the other members",
+			// the case is kept last as the calls expected any times would match the calls of the later cases
+			mockFunc: func() {
+				mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).AnyTimes()
+				mockClient.EXPECT().GetServices(gomock.Any()).Return(getResource("get-all-services", nil).(pac.ServiceList), nil).AnyTimes()
+				mockKCClient.EXPECT().GetUserID().Return("122344").Times(2)
+				mockKCClient.EXPECT().GetGroupMembers("122343").Return([]*gocloak.User{{ID: utils.Ptr("122344")}}, nil).Times(2)
+				mockDBClient.EXPECT().GetKeyByUserID(gomock.Any()).Return(getResource("get-key-by-userid", nil).([]models.Key), nil).Times(1)
+				mockDBClient.EXPECT().GetGroupsQuota(gomock.Any()).DoAndReturn(func([]string) ([]models.Quota, error) {
+					return getResource("get-groups-quota", customValues{"Type": models.QuotaTypePooled}).([]models.Quota), nil
+				}).Times(2)
+				mockDBClient.EXPECT().GetActiveUserQuotas("122344").Return([]models.UserQuota{
+					{UserID: "122344", Capacity: models.Capacity{CPU: 2, Memory: 2}},
+				}, nil).Times(2)
+				mockDBClient.EXPECT().GetQuotaLedger(gomock.Any()).Return(&models.QuotaLedger{}, nil).Times(1)
+				mockDBClient.EXPECT().AddQuotaReservation(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockDBClient.EXPECT().ReserveQuota("122344", gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().GetGroupQuotaLedger("122343").Return(&models.QuotaLedger{}, nil).Times(1)
+				mockDBClient.EXPECT().AddGroupQuotaReservation("122343", gomock.Any()).Return(nil).AnyTimes()
+				// the pool is shared with the grant of the user on top
+				mockDBClient.EXPECT().ReserveGroupQuota("122343", gomock.Any(), models.Capacity{CPU: 12, Memory: 12}).
+					Return(utils.ErrQuotaExceeded).Times(1)
+				mockDBClient.EXPECT().DeleteServiceUsage(gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().ReleaseQuota(gomock.Any()).Return(nil).Times(1)
+			},
+			service: getResource("create-service", nil).(models.Service),
+			requestContext: formContext(customValues{
+				"groups": formGroup(customValues{
+					"id":   "122343",
+					"name": "silver",
+				}),
+			}),
+			httpStatus: http.StatusBadRequest,
+		},
 	}
 	for _, tc := range testcases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -202,6 +293,110 @@ func TestCreateService(t *testing.T) {
 	}
 }
 
+func TestCreateServiceConcurrently(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockClient, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+
+	const parallelRequests = 10
+	// quota of the user is enough to provision only two services of the catalog
+	quota := getResource("get-groups-quota", customValues{"Capacity": models.Capacity{CPU: 4, Memory: 4}}).([]models.Quota)
+
+	var mu sync.Mutex
+	var services pac.ServiceList
+	ledger := models.QuotaLedger{}
+
+	mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).AnyTimes()
+	mockClient.EXPECT().GetServices(gomock.Any()).DoAndReturn(func(string) (pac.ServiceList, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return *services.DeepCopy(), nil
+	}).AnyTimes()
+	mockClient.EXPECT().CreateService(gomock.Any()).DoAndReturn(func(service pac.Service) error {
+		mu.Lock()
+		defer mu.Unlock()
+		services.Items = append(services.Items, service)
+		return nil
+	}).AnyTimes()
+	mockKCClient.EXPECT().GetUserID().Return("test-user").AnyTimes()
+	mockDBClient.EXPECT().GetKeyByUserID(gomock.Any()).Return(getResource("get-key-by-userid", nil).([]models.Key), nil).AnyTimes()
+	mockDBClient.EXPECT().GetGroupsQuota(gomock.Any()).Return(quota, nil).AnyTimes()
+	mockDBClient.EXPECT().GetActiveUserQuotas(gomock.Any()).Return(nil, nil).AnyTimes()
+	mockDBClient.EXPECT().GetQuotaLedger(gomock.Any()).DoAndReturn(func(string) (*models.QuotaLedger, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		l := ledger
+		l.Reservations = append([]models.QuotaReservation{}, ledger.Reservations...)
+		return &l, nil
+	}).AnyTimes()
+	mockDBClient.EXPECT().AddQuotaReservation(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockDBClient.EXPECT().NewServiceUsage(gomock.Any()).Return(nil).Times(2)
+	mockDBClient.EXPECT().NewUsageRecord(gomock.Any()).Return(nil).Times(2)
+	mockDBClient.EXPECT().GetQuotaAlert(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	mockDBClient.EXPECT().UpdateQuotaAlert(gomock.Any()).Return(nil).AnyTimes()
+	mockDBClient.EXPECT().NewEvent(gomock.Any()).Return(nil).AnyTimes()
+	// emulates the conditional increment done by the database
+	mockDBClient.EXPECT().ReserveQuota(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ string, reservation models.QuotaReservation, limit models.Capacity) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if ledger.CPU+reservation.Capacity.CPU > limit.CPU || ledger.Memory+reservation.Capacity.Memory > limit.Memory {
+				return utils.ErrQuotaExceeded
+			}
+			ledger.CPU += reservation.Capacity.CPU
+			ledger.Memory += reservation.Capacity.Memory
+			ledger.Reservations = append(ledger.Reservations, reservation)
+			return nil
+		}).AnyTimes()
+
+	requestContext := formContext(customValues{
+		"userid":                "test-user",
+		"keycloak_hostname":     "127.0.0.1",
+		"keycloak_access_token": "Bearer test-token",
+		"keycloak_realm":        "test-pac",
+		"groups": formGroup(customValues{
+			"id":   "122343",
+			"name": "silver",
+		}),
+	})
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(getContext(requestContext))
+		c.Next()
+	})
+	router.POST("/services", CreateService)
+	kubeClient = mockClient
+	dbCon = mockDBClient
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, parallelRequests)
+	marshalledService, _ := json.Marshal(getResource("create-service", nil).(models.Service))
+	for i := 0; i < parallelRequests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/services", bytes.NewBuffer(marshalledService))
+			router.ServeHTTP(w, req)
+			statuses <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	created := 0
+	for status := range statuses {
+		if status == http.StatusCreated {
+			created++
+			continue
+		}
+		assert.Equal(t, http.StatusBadRequest, status)
+	}
+	assert.Equal(t, 2, created)
+	assert.Len(t, services.Items, 2)
+	assert.Equal(t, models.Capacity{CPU: 4, Memory: 4}, models.Capacity{CPU: ledger.CPU, Memory: ledger.Memory})
+}
+
 func TestDeleteService(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockClient, mockDBClient, mockKCClient, tearDown := setUp(t)
@@ -219,6 +414,19 @@ func TestDeleteService(t *testing.T) {
 				mockKCClient.EXPECT().GetUserID().Return("12345").Times(1)
 				mockKCClient.EXPECT().IsRole(gomock.Any()).Return(true).Times(1)
 				mockClient.EXPECT().DeleteService(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				mockDBClient.EXPECT().ReleaseQuota("test-service").Return(nil).Times(1)
+				mockDBClient.EXPECT().EndServiceUsage("test-service", gomock.Any()).DoAndReturn(func(name string, endedAt time.Time) (*models.ServiceUsage, error) {
+					return &models.ServiceUsage{UserID: "test-user", ServiceName: name, CatalogName: "test-catalog", CPU: 2, Memory: 2, EndedAt: &endedAt}, nil
+				}).Times(1)
+				mockDBClient.EXPECT().NewUsageRecord(gomock.Any()).DoAndReturn(func(record *models.UsageRecord) error {
+					assert.Equal(t, models.UsageTransitionDeleted, record.Transition)
+					assert.Equal(t, models.Capacity{CPU: 2, Memory: 2}, record.Capacity)
+					return nil
+				}).Times(1)
+				mockClient.EXPECT().GetServices(gomock.Any()).Return(pac.ServiceList{}, nil).Times(1)
+				mockDBClient.EXPECT().GetQuotaAlert(models.QuotaAlertUser, "test-user").Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("test-user").Return(nil, nil).Times(1)
+				mockDBClient.EXPECT().UpdateQuotaAlert(gomock.Any()).Return(nil).Times(1)
 				mockDBClient.EXPECT().GetRequestByServiceName(gomock.Any()).Return(getResource("get-request-by-service-name", nil).([]models.Request), nil).Times(1)
 			},
 			requestParams: gin.Param{Key: "name", Value: "test-service"},
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+const staleRequestCheckInterval = time.Hour
+
+var (
+	staleRequestExpiredMsg  = "Request is expired since it was not decided within %s"
+	staleRequestReminderMsg = "Request %s of type %s by user %s is pending since %s, it will expire on %s if not decided"
+)
+
+// LoadStaleRequestAges parses the age after which the pending requests of every type are expired, e.g. GROUP=720h
+func LoadStaleRequestAges(ages map[string]string) error {
+	staleRequestAges := map[models.RequestType]time.Duration{}
+	for requestType, age := range ages {
+		if _, err := getRequestHandler(models.RequestType(requestType)); err != nil {
+			return err
+		}
+		duration, err := time.ParseDuration(age)
+		if err != nil {
+			return fmt.Errorf("invalid age of %s requests: %w", requestType, err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("age of %s requests must be positive", requestType)
+		}
+		staleRequestAges[models.RequestType(requestType)] = duration
+	}
+	models.StaleRequestAges = staleRequestAges
+	return nil
+}
+
+// expireStaleRequests expires the pending requests older than the age set for their type, and reminds the approvers
+// of the pending requests about to expire
+func expireStaleRequests() {
+	logger := log.GetLogger()
+	now := time.Now()
+	for requestType, age := range models.StaleRequestAges {
+		logger.Debug("checking stale requests", zap.String("type", string(requestType)), zap.Duration("age", age))
+		// the requests to be reminded include the ones to be expired
+		requests, err := dbCon.GetPendingRequestsBefore(requestType, now.Add(-age+models.StaleRequestReminder))
+		if err != nil {
+			logger.Error("failed to get pending requests", zap.String("type", string(requestType)), zap.Error(err))
+			continue
+		}
+		for i := range requests {
+			request := &requests[i]
+			if !request.CreatedAt.Add(age).After(now) {
+				expireStaleRequest(request, age)
+				continue
+			}
+			if request.RemindedAt == nil {
+				remindApprovers(request, request.CreatedAt.Add(age))
+			}
+		}
+	}
+}
+
+func expireStaleRequest(request *models.Request, age time.Duration) {
+	logger := log.GetLogger()
+	transition := models.NewRequestTransition(request, models.RequestStateExpired, models.RequestActorSystem, fmt.Sprintf(staleRequestExpiredMsg, age))
+	if err := dbCon.UpdateRequestState(request.ID.Hex(), transition); err != nil {
+		// the request is decided since it was fetched
+		if errors.Is(err, utils.ErrResourceModified) {
+			return
+		}
+		logger.Error("failed to expire request", zap.String("id", request.ID.Hex()), zap.Error(err))
+		return
+	}
+	logger.Info("expired stale request", zap.String("id", request.ID.Hex()), zap.String("type", string(request.RequestType)))
+	event, err := models.NewEvent(request.UserID, request.UserID, models.EventTypeRequestExpired)
+	if err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+		return
+	}
+	event.SetNotify()
+	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf("Request has been expired since it was not decided within %s, id: %s",
+		age, request.ID.Hex()))
+	setRequestResource(event, request.ID.Hex(), request)
+	if err := dbCon.NewEvent(event); err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+	}
+}
+
+// remindApprovers reminds the admin, and the owners of the group targeted by the request, of the request about to expire
+func remindApprovers(request *models.Request, expiry time.Time) {
+	logger := log.GetLogger()
+	message := fmt.Sprintf(staleRequestReminderMsg, request.ID.Hex(), request.RequestType, request.UserID,
+		request.CreatedAt.Format(time.RFC3339), expiry.Format(time.RFC3339))
+	event, err := models.NewEvent(request.UserID, request.UserID, models.EventRequestExpiryReminder)
+	if err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+		return
+	}
+	event.SetNotifyAdmin()
+	event.SetLog(models.EventLogLevelINFO, message)
+	setRequestResource(event, request.ID.Hex(), request)
+	if err := dbCon.NewEvent(event); err != nil {
+		logger.Error("failed to create event", zap.Error(err))
+		return
+	}
+
+	notifyGroupOwners(request, request.UserID, models.EventRequestExpiryReminder, message)
+
+	if err := dbCon.UpdateRequestReminded(request.ID.Hex(), time.Now()); err != nil {
+		logger.Error("failed to record the reminder", zap.String("id", request.ID.Hex()), zap.Error(err))
+	}
+}
+
+// StaleRequestExpiry periodically expires the pending requests not decided in time
+func StaleRequestExpiry() {
+	go func() {
+		ticker := time.NewTicker(staleRequestCheckInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			expireStaleRequests()
+		}
+	}()
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+func TestExpireStaleRequests(t *testing.T) {
+	_, mockDBClient, _, tearDown := setUp(t)
+	defer tearDown()
+	models.StaleRequestAges = map[models.RequestType]time.Duration{models.RequestAddToGroup: 30 * 24 * time.Hour}
+	models.StaleRequestReminder = 48 * time.Hour
+	defer func() { models.StaleRequestAges = nil }()
+
+	groupRequest := func(id byte, age time.Duration, remindedAt *time.Time) models.Request {
+		return models.Request{ID: [12]byte{id}, UserID: "test-user", State: models.RequestStateNew, RequestType: models.RequestAddToGroup,
+			CreatedAt: time.Now().Add(-age), GroupAdmission: &models.GroupAdmission{GroupID: "test-group", Group: "test-group"},
+			RemindedAt: remindedAt}
+	}
+	remindedAt := time.Now().Add(-time.Hour)
+
+	testcases := []struct {
+		name     string
+		mockFunc func()
+		request  models.Request
+		events   []models.Event
+	}{
+		{
+			name: "request expired",
+			mockFunc: func() {
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), transitionTo(models.RequestStateExpired, models.RequestActorSystem)).DoAndReturn(
+					func(_ string, transition models.RequestTransition) error {
+						assert.Equal(t, models.RequestStateNew, transition.From)
+						assert.Equal(t, "Request is expired since it was not decided within 720h0m0s", transition.Comment)
+						return nil
+					}).Times(1)
+			},
+			request: groupRequest(1, 31*24*time.Hour, &remindedAt),
+			events:  []models.Event{{UserID: "test-user", Type: models.EventTypeRequestExpired, Notify: true}},
+		},
+		{
+			name: "request decided concurrently",
+			mockFunc: func() {
+				mockDBClient.EXPECT().UpdateRequestState(gomock.Any(), gomock.Any()).Return(utils.ErrResourceModified).Times(1)
+			},
+			request: groupRequest(2, 31*24*time.Hour, nil),
+		},
+		{
+			name: "approvers reminded",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetGroupOwnership("test-group").Return(&models.GroupOwnership{GroupID: "test-group",
+					Owners: []string{"test-owner"}}, nil).Times(1)
+				mockDBClient.EXPECT().UpdateRequestReminded(gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			request: groupRequest(3, 29*24*time.Hour, nil),
+			events: []models.Event{
+				{UserID: "test-user", Type: models.EventRequestExpiryReminder, NotifyAdmin: true},
+				{UserID: "test-owner", Type: models.EventRequestExpiryReminder, Notify: true},
+			},
+		},
+		{
+			name:     "approvers already reminded",
+			mockFunc: func() {},
+			request:  groupRequest(4, 29*24*time.Hour, &remindedAt),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			var events []models.Event
+			mockDBClient.EXPECT().GetPendingRequestsBefore(models.RequestAddToGroup, gomock.Any()).Return([]models.Request{tc.request}, nil).Times(1)
+			mockDBClient.EXPECT().NewEvent(gomock.Any()).DoAndReturn(func(event *models.Event) error {
+				events = append(events, models.Event{UserID: event.UserID, Type: event.Type, Notify: event.Notify, NotifyAdmin: event.NotifyAdmin})
+				return nil
+			}).Times(len(tc.events))
+			dbCon = mockDBClient
+			expireStaleRequests()
+			assert.Equal(t, tc.events, events)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+)
+
+const quotaThresholdCheckInterval = time.Hour
+
+var (
+	userQuotaThresholdMsg  = "You have used %d%% of your quota, CPU: %v of %v Memory: %v of %v Services: %v"
+	groupQuotaThresholdMsg = "Members of the group %s have used %d%% of the group quota, CPU: %v of %v Memory: %v of %v"
+)
+
+// checkQuotaThresholds evaluates the quota utilization of the user and the pooled groups of the user once the services
+// of the user change, groups of the user are known only if the user is the one making the request.
+func checkQuotaThresholds(c *gin.Context, userID string) {
+	logger := log.GetLogger()
+	serviceList, err := kubeClient.GetServices(userID)
+	if err != nil {
+		logger.Error("failed to get services", zap.String("user id", userID), zap.Error(err))
+		return
+	}
+	alert, err := getQuotaAlert(models.QuotaAlertUser, userID)
+	if err != nil {
+		logger.Error("failed to get quota alert", zap.String("user id", userID), zap.Error(err))
+		return
+	}
+	if groups, ok := c.Request.Context().Value("groups").([]models.Group); ok && userID == c.Request.Context().Value("userid") {
+		alert.GroupIDs = nil
+		for _, grp := range groups {
+			alert.GroupIDs = append(alert.GroupIDs, grp.ID)
+		}
+	}
+	var groupsQuota []models.Quota
+	if len(alert.GroupIDs) > 0 {
+		if groupsQuota, err = dbCon.GetGroupsQuota(alert.GroupIDs); err != nil {
+			logger.Error("failed to get quota", zap.String("user id", userID), zap.Error(err))
+			return
+		}
+	}
+	if err := evaluateUserQuotaAlert(alert, groupsQuota, serviceList.Items); err != nil {
+		logger.Error("failed to evaluate user quota threshold", zap.String("user id", userID), zap.Error(err))
+	}
+
+	// services of the other users are listed only for the pooled groups
+	var services []pac.Service
+	var listed bool
+	for _, quota := range groupsQuota {
+		if !quota.IsPooled() {
+			continue
+		}
+		groupAlert, err := getQuotaAlert(models.QuotaAlertGroup, quota.GroupID)
+		if err != nil {
+			logger.Error("failed to get quota alert", zap.String("group id", quota.GroupID), zap.Error(err))
+			continue
+		}
+		// fall back to the members known from the earlier evaluation
+		if memberIDs, err := getGroupMemberIDs(c.Request.Context(), quota.GroupID); err != nil {
+			logger.Error("failed to get members of the group", zap.String("group id", quota.GroupID), zap.Error(err))
+		} else {
+			groupAlert.MemberIDs = memberIDs
+		}
+		if !listed {
+			allServices, err := kubeClient.GetServices("")
+			if err != nil {
+				logger.Error("failed to get services", zap.Error(err))
+				return
+			}
+			services, listed = allServices.Items, true
+		}
+		if err := evaluatePooledQuotaAlert(groupAlert, quota, services); err != nil {
+			logger.Error("failed to evaluate group quota threshold", zap.String("group id", quota.GroupID), zap.Error(err))
+		}
+	}
+}
+
+// evaluateQuotaThresholds evaluates the quota utilization of the members of all the groups with quota, of the pooled
+// groups and of the users granted quota, which catches the changes in quota without a change in the services, e.g.
+// granted quota reverting.
+func evaluateQuotaThresholds() {
+	logger := log.GetLogger()
+	logger.Debug("evaluating quota thresholds")
+	quotas, err := dbCon.GetQuotas()
+	if err != nil {
+		logger.Error("failed to get quota", zap.Error(err))
+		return
+	}
+	grants, err := dbCon.GetAllActiveUserQuotas()
+	if err != nil {
+		logger.Error("failed to get user granted quota", zap.Error(err))
+		return
+	}
+	serviceList, err := kubeClient.GetServices("")
+	if err != nil {
+		logger.Error("failed to get services", zap.Error(err))
+		return
+	}
+
+	// quota of the groups of every user to be evaluated
+	userGroupsQuota := make(map[string][]models.Quota)
+	for _, quota := range quotas {
+		memberIDs, err := getGroupMemberIDs(context.Background(), quota.GroupID)
+		if err != nil {
+			logger.Error("failed to get members of the group", zap.String("group id", quota.GroupID), zap.Error(err))
+			continue
+		}
+		for _, memberID := range memberIDs {
+			userGroupsQuota[memberID] = append(userGroupsQuota[memberID], quota)
+		}
+		if !quota.IsPooled() {
+			continue
+		}
+		alert, err := getQuotaAlert(models.QuotaAlertGroup, quota.GroupID)
+		if err != nil {
+			logger.Error("failed to get quota alert", zap.String("group id", quota.GroupID), zap.Error(err))
+			continue
+		}
+		alert.MemberIDs = memberIDs
+		if err := evaluatePooledQuotaAlert(alert, quota, serviceList.Items); err != nil {
+			logger.Error("failed to evaluate group quota threshold", zap.String("group id", quota.GroupID), zap.Error(err))
+		}
+	}
+	// users without any group quota are evaluated for the quota granted
+	for _, grant := range grants {
+		if _, ok := userGroupsQuota[grant.UserID]; !ok {
+			userGroupsQuota[grant.UserID] = nil
+		}
+	}
+
+	for userID, groupsQuota := range userGroupsQuota {
+		alert, err := getQuotaAlert(models.QuotaAlertUser, userID)
+		if err != nil {
+			logger.Error("failed to get quota alert", zap.String("user id", userID), zap.Error(err))
+			continue
+		}
+		alert.GroupIDs = nil
+		for _, quota := range groupsQuota {
+			alert.GroupIDs = append(alert.GroupIDs, quota.GroupID)
+		}
+		if err := evaluateUserQuotaAlert(alert, groupsQuota, serviceList.Items); err != nil {
+			logger.Error("failed to evaluate user quota threshold", zap.String("user id", userID), zap.Error(err))
+		}
+	}
+}
+
+// evaluateUserQuotaAlert alerts the user on crossing a threshold of the quota granted to the user individually, i.e. the
+// PER_USER group quota and the granted quota, as the POOLED group quota is evaluated for the group.
+func evaluateUserQuotaAlert(alert *models.QuotaAlert, groupsQuota []models.Quota, services []pac.Service) error {
+	var perUserQuota []models.Quota
+	for _, quota := range groupsQuota {
+		if !quota.IsPooled() {
+			perUserQuota = append(perUserQuota, quota)
+		}
+	}
+	quota := getMaxCapacity(perUserQuota)
+	userQuotas, err := dbCon.GetActiveUserQuotas(alert.SubjectID)
+	if err != nil {
+		return err
+	}
+	for _, grant := range userQuotas {
+		quota.CPU += grant.Capacity.CPU
+		quota.Memory += grant.Capacity.Memory
+	}
+	used, err := getServicesCapacity(filterServicesByUsers(services, alert.SubjectID))
+	if err != nil {
+		return err
+	}
+
+	crossed, err := updateQuotaAlert(alert, getQuotaUtilization(used, quota))
+	if err != nil || !crossed {
+		return err
+	}
+	event, err := models.NewEvent(alert.SubjectID, alert.SubjectID, models.EventQuotaThreshold)
+	if err != nil {
+		return err
+	}
+	event.SetNotify()
+	event.SetLog(models.EventLogLevelINFO, fmt.Sprintf(userQuotaThresholdMsg, alert.Threshold, used.CPU, quota.CPU, used.Memory,
+		quota.Memory, used.Services))
+	event.SetResource(models.EventResourceUser, alert.SubjectID, "")
+	event.SetMetadata(models.EventMetadataThreshold, strconv.Itoa(alert.Threshold))
+	return dbCon.NewEvent(event)
+}
+
+// evaluatePooledQuotaAlert alerts the members of the group and the admin on crossing a threshold of the POOLED quota
+func evaluatePooledQuotaAlert(alert *models.QuotaAlert, quota models.Quota, services []pac.Service) error {
+	used, err := getServicesCapacity(filterServicesByUsers(services, alert.MemberIDs...))
+	if err != nil {
+		return err
+	}
+
+	crossed, err := updateQuotaAlert(alert, getQuotaUtilization(used, quota.Capacity))
+	if err != nil || !crossed {
+		return err
+	}
+	for i, memberID := range alert.MemberIDs {
+		event, err := models.NewEvent(memberID, memberID, models.EventQuotaThreshold)
+		if err != nil {
+			return err
+		}
+		event.SetNotify()
+		// admin is notified only once per crossing
+		if i == 0 {
+			event.SetNotifyAdmin()
+		}
+		event.SetLog(models.EventLogLevelINFO, fmt.Sprintf(groupQuotaThresholdMsg, quota.GroupID, alert.Threshold, used.CPU,
+			quota.Capacity.CPU, used.Memory, quota.Capacity.Memory))
+		event.SetResource(models.EventResourceGroup, quota.GroupID, "")
+		event.SetMetadata(models.EventMetadataThreshold, strconv.Itoa(alert.Threshold))
+		if err := dbCon.NewEvent(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getQuotaAlert returns the alert of the user or the group, a new alert is returned if it was never evaluated
+func getQuotaAlert(subjectType models.QuotaAlertSubject, subjectID string) (*models.QuotaAlert, error) {
+	alert, err := dbCon.GetQuotaAlert(subjectType, subjectID)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		alert = &models.QuotaAlert{SubjectType: subjectType, SubjectID: subjectID}
+	}
+	return alert, nil
+}
+
+// updateQuotaAlert records the threshold reached by the utilization and returns true if a higher threshold is crossed
+// since the last evaluation.
+func updateQuotaAlert(alert *models.QuotaAlert, utilization float64) (bool, error) {
+	threshold := 0
+	for _, t := range models.QuotaThresholds {
+		if utilization >= float64(t) && t > threshold {
+			threshold = t
+		}
+	}
+	crossed := threshold > alert.Threshold
+	alert.Threshold = threshold
+	alert.EvaluatedAt = time.Now()
+	if err := dbCon.UpdateQuotaAlert(alert); err != nil {
+		return false, err
+	}
+	return crossed, nil
+}
+
+// getQuotaUtilization returns the utilization of the quota in percentage, i.e. the highest of the CPU, memory and
+// services utilization.
+func getQuotaUtilization(used, quota models.Capacity) float64 {
+	var utilization float64
+	for _, u := range []struct{ used, quota float64 }{
+		{used.CPU, quota.CPU},
+		{float64(used.Memory), float64(quota.Memory)},
+		{float64(used.Services), float64(quota.Services)},
+	} {
+		if u.quota > 0 {
+			utilization = math.Max(utilization, u.used/u.quota*100)
+		}
+	}
+	return utilization
+}
+
+// filterServicesByUsers returns the services owned by the users
+func filterServicesByUsers(services []pac.Service, userIDs ...string) []pac.Service {
+	users := make(map[string]bool)
+	for _, userID := range userIDs {
+		users[userID] = true
+	}
+	var filtered []pac.Service
+	for _, svc := range services {
+		if users[svc.Spec.UserID] {
+			filtered = append(filtered, svc)
+		}
+	}
+	return filtered
+}
+
+// QuotaThresholdNotification periodically raises notification for the users and the groups crossing quota thresholds
+func QuotaThresholdNotification() {
+	go func() {
+		ticker := time.NewTicker(quotaThresholdCheckInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			evaluateQuotaThresholds()
+		}
+	}()
+}
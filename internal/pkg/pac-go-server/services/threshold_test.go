@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	pac "github.com/PDeXchange/pac/apis/app/v1alpha1"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+func TestEvaluateQuotaThresholds(t *testing.T) {
+	mockClient, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+	models.QuotaThresholds = []int{80, 100}
+
+	userService := getResource("get-all-services", nil).(pac.ServiceList).Items[0]
+	memberService := *userService.DeepCopy()
+	memberService.Spec.UserID = "test-member"
+	services := pac.ServiceList{Items: []pac.Service{userService, memberService}}
+	members := func(ids ...string) []*gocloak.User {
+		var users []*gocloak.User
+		for _, id := range ids {
+			users = append(users, &gocloak.User{ID: utils.Ptr(id)})
+		}
+		return users
+	}
+
+	testcases := []struct {
+		name     string
+		mockFunc func()
+		// alerts are the alerts evaluated earlier
+		alerts     []models.QuotaAlert
+		thresholds map[string]int
+		events     []models.Event
+	}{
+		{
+			name: "member of the group crossed threshold",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetQuotas().Return(getResource("get-groups-quota", customValues{
+					"Capacity": models.Capacity{CPU: 2.5, Memory: 10},
+				}).([]models.Quota), nil).Times(1)
+				mockDBClient.EXPECT().GetAllActiveUserQuotas().Return(nil, nil).Times(1)
+				mockKCClient.EXPECT().GetGroupMembers("122343").Return(members("test-user"), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("test-user").Return(nil, nil).Times(1)
+			},
+			thresholds: map[string]int{"test-user": 80},
+			events:     []models.Event{{UserID: "test-user", Type: models.EventQuotaThreshold, Notify: true}},
+		},
+		{
+			name: "user already alerted for the threshold",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetQuotas().Return(getResource("get-groups-quota", customValues{
+					"Capacity": models.Capacity{CPU: 2.5, Memory: 10},
+				}).([]models.Quota), nil).Times(1)
+				mockDBClient.EXPECT().GetAllActiveUserQuotas().Return(nil, nil).Times(1)
+				mockKCClient.EXPECT().GetGroupMembers("122343").Return(members("test-user"), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("test-user").Return(nil, nil).Times(1)
+			},
+			alerts:     []models.QuotaAlert{{SubjectType: models.QuotaAlertUser, SubjectID: "test-user", Threshold: 80}},
+			thresholds: map[string]int{"test-user": 80},
+		},
+		{
+			name: "user utilization dropped with granted quota",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetQuotas().Return(getResource("get-groups-quota", customValues{
+					"Capacity": models.Capacity{CPU: 2, Memory: 10},
+				}).([]models.Quota), nil).Times(1)
+				grants := []models.UserQuota{{UserID: "test-user", Capacity: models.Capacity{CPU: 2, Memory: 2}}}
+				mockDBClient.EXPECT().GetAllActiveUserQuotas().Return(grants, nil).Times(1)
+				mockKCClient.EXPECT().GetGroupMembers("122343").Return(members("test-user"), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("test-user").Return(grants, nil).Times(1)
+			},
+			alerts:     []models.QuotaAlert{{SubjectType: models.QuotaAlertUser, SubjectID: "test-user", Threshold: 100}},
+			thresholds: map[string]int{"test-user": 0},
+		},
+		{
+			name: "user without any group crossed threshold of the granted quota",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetQuotas().Return(nil, nil).Times(1)
+				grants := []models.UserQuota{{UserID: "test-user", Capacity: models.Capacity{CPU: 2, Memory: 2}}}
+				mockDBClient.EXPECT().GetAllActiveUserQuotas().Return(grants, nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas("test-user").Return(grants, nil).Times(1)
+			},
+			thresholds: map[string]int{"test-user": 100},
+			events:     []models.Event{{UserID: "test-user", Type: models.EventQuotaThreshold, Notify: true}},
+		},
+		{
+			name: "pooled group crossed threshold",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetQuotas().Return(getResource("get-groups-quota", customValues{
+					"Capacity": models.Capacity{CPU: 4, Memory: 10},
+					"Type":     models.QuotaTypePooled,
+				}).([]models.Quota), nil).Times(1)
+				mockDBClient.EXPECT().GetAllActiveUserQuotas().Return(nil, nil).Times(1)
+				mockKCClient.EXPECT().GetGroupMembers("122343").Return(members("test-user", "test-member"), nil).Times(1)
+				mockDBClient.EXPECT().GetActiveUserQuotas(gomock.Any()).Return(nil, nil).Times(2)
+			},
+			alerts:     []models.QuotaAlert{{SubjectType: models.QuotaAlertGroup, SubjectID: "122343", Threshold: 80}},
+			thresholds: map[string]int{"122343": 100, "test-user": 0, "test-member": 0},
+			events: []models.Event{
+				{UserID: "test-user", Type: models.EventQuotaThreshold, Notify: true, NotifyAdmin: true},
+				{UserID: "test-member", Type: models.EventQuotaThreshold, Notify: true},
+			},
+		},
+		{
+			name: "members of the group not listed",
+			mockFunc: func() {
+				mockDBClient.EXPECT().GetQuotas().Return(getResource("get-groups-quota", nil).([]models.Quota), nil).Times(1)
+				mockDBClient.EXPECT().GetAllActiveUserQuotas().Return(nil, nil).Times(1)
+				mockKCClient.EXPECT().GetGroupMembers("122343").Return(nil, errors.New("keycloak is down")).Times(1)
+			},
+			thresholds: map[string]int{},
+		},
+	}
+	var alerts []models.QuotaAlert
+	var thresholds map[string]int
+	var events []models.Event
+	mockClient.EXPECT().GetServices("").Return(services, nil).AnyTimes()
+	mockClient.EXPECT().GetCatalog(gomock.Any()).Return(getResource("get-catalog", nil).(pac.Catalog), nil).AnyTimes()
+	mockDBClient.EXPECT().GetQuotaAlert(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(subjectType models.QuotaAlertSubject, subjectID string) (*models.QuotaAlert, error) {
+			for _, alert := range alerts {
+				if alert.SubjectType == subjectType && alert.SubjectID == subjectID {
+					return &alert, nil
+				}
+			}
+			return nil, nil
+		}).AnyTimes()
+	mockDBClient.EXPECT().UpdateQuotaAlert(gomock.Any()).DoAndReturn(func(alert *models.QuotaAlert) error {
+		thresholds[alert.SubjectID] = alert.Threshold
+		return nil
+	}).AnyTimes()
+	mockDBClient.EXPECT().NewEvent(gomock.Any()).DoAndReturn(func(event *models.Event) error {
+		events = append(events, models.Event{UserID: event.UserID, Type: event.Type, Notify: event.Notify, NotifyAdmin: event.NotifyAdmin})
+		return nil
+	}).AnyTimes()
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			alerts, thresholds, events = tc.alerts, map[string]int{}, nil
+			kubeClient = mockClient
+			dbCon = mockDBClient
+			evaluateQuotaThresholds()
+			assert.Equal(t, tc.thresholds, thresholds)
+			assert.Equal(t, tc.events, events)
+		})
+	}
+}
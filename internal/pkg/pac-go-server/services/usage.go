@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/client"
+	log "github.com/PDeXchange/pac/internal/pkg/pac-go-server/logger"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+)
+
+const (
+	usageDateLayout    = "2006-01-02"
+	aggregationDaily   = "daily"
+	aggregationMonthly = "monthly"
+)
+
+// GetUsage			godoc
+// @Summary			Get usage
+// @Description		Get the usage history of the services, admin can get the usage of any user or group
+// @Tags			usage
+// @Accept			json
+// @Produce			json
+// @Param			Authorization header string true "Insert your access token" default(Bearer <Add access token here>)
+// @Param			user_id query string false "user whose usage to be fetched, defaults to the authenticated user for non admin"
+// @Param			group_id query string false "group whose members usage to be fetched, only for admin"
+// @Param			from query string false "start date in YYYY-MM-DD format, defaults to the start of the current month"
+// @Param			to query string false "end date(inclusive) in YYYY-MM-DD format, defaults to now"
+// @Param			aggregate query string false "aggregate the usage daily or monthly, usage history is returned if not set"
+// @Success			200
+// @Router			/api/v1/usage [get]
+func GetUsage(c *gin.Context) {
+	logger := log.GetLogger()
+	config := client.GetConfigFromContext(c.Request.Context())
+	kc := client.NewKeyCloakClient(config, c.Request.Context())
+
+	userID := c.Query("user_id")
+	groupID := c.Query("group_id")
+	if userID != "" && groupID != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only one of user_id and group_id can be set."})
+		return
+	}
+
+	var userIDs []string
+	switch {
+	case !kc.IsRole(utils.ManagerRole):
+		authenticatedUserID := kc.GetUserID()
+		if groupID != "" || (userID != "" && userID != authenticatedUserID) {
+			logger.Error("only admin can get the usage of other users", zap.String("user id", authenticatedUserID))
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "You do not have permission to get the usage of other users."})
+			return
+		}
+		userIDs = []string{authenticatedUserID}
+	case userID != "":
+		userIDs = []string{userID}
+	case groupID != "":
+		members, err := kc.GetGroupMembers(groupID)
+		if err != nil {
+			logger.Error("failed to get members of the group", zap.String("group id", groupID), zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to get members of the group, err: %s", err.Error())})
+			return
+		}
+		for _, member := range members {
+			userIDs = append(userIDs, *member.ID)
+		}
+	}
+
+	from, to, err := getUsagePeriod(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	aggregation := c.Query("aggregate")
+	if aggregation != "" && aggregation != aggregationDaily && aggregation != aggregationMonthly {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid aggregate: \"%s\" is set, valid values are %s %s",
+			aggregation, aggregationDaily, aggregationMonthly)})
+		return
+	}
+	response := models.UsageResponse{From: from, To: to, Aggregation: aggregation}
+	// group without members has no usage, an empty list of users would fetch the usage of all the users
+	if groupID != "" && len(userIDs) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	records, err := dbCon.GetUsageRecords(userIDs, from, to)
+	if err != nil {
+		logger.Error("failed to get usage history", zap.Strings("user ids", userIDs), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if aggregation == "" {
+		response.Records = records
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	usage, err := dbCon.GetServiceUsage(userIDs, from, to)
+	if err != nil {
+		logger.Error("failed to get service usage", zap.Strings("user ids", userIDs), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response.Usage = aggregateUsage(aggregation, from, to, usage, records)
+	c.JSON(http.StatusOK, response)
+}
+
+// getUsagePeriod returns the period set in the query, which defaults to the current month till now
+func getUsagePeriod(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	from, _ := getMonthBounds(now)
+	to := now
+	if date := c.Query("from"); date != "" {
+		t, err := time.Parse(usageDateLayout, date)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid from date: \"%s\" is set, expected format is YYYY-MM-DD", date)
+		}
+		from = t
+	}
+	if date := c.Query("to"); date != "" {
+		t, err := time.Parse(usageDateLayout, date)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid to date: \"%s\" is set, expected format is YYYY-MM-DD", date)
+		}
+		// include the whole day
+		to = t.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		return from, to, errors.New("from date must be before to date")
+	}
+	return from, to, nil
+}
+
+// aggregateUsage sums up the usage and the transitions of the services per day or month between from and to
+func aggregateUsage(aggregation string, from, to time.Time, usage []models.ServiceUsage, records []models.UsageRecord) []models.UsageSummary {
+	var summaries []models.UsageSummary
+	now := time.Now()
+	for start := from; start.Before(to); {
+		var end time.Time
+		if aggregation == aggregationMonthly {
+			_, end = getMonthBounds(start)
+		} else {
+			end = time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, time.UTC)
+		}
+		if end.After(to) {
+			end = to
+		}
+		summary := models.UsageSummary{Period: start}
+		// running services are recorded till their expiry, hence usage is metered only till now
+		meteredTill := end
+		if meteredTill.After(now) {
+			meteredTill = now
+		}
+		for _, u := range usage {
+			summary.CoreHours += u.CoreHours(start, meteredTill)
+			summary.MemoryHours += u.MemoryHours(start, meteredTill)
+		}
+		for _, record := range records {
+			if record.Timestamp.Before(start) || !record.Timestamp.Before(end) {
+				continue
+			}
+			switch record.Transition {
+			case models.UsageTransitionCreated:
+				summary.Created++
+			case models.UsageTransitionExpired:
+				summary.Expired++
+			case models.UsageTransitionDeleted:
+				summary.Deleted++
+			}
+		}
+		summaries = append(summaries, summary)
+		start = end
+	}
+	return summaries
+}
+
+// recordUsage adds the transition of the service to the usage history, failures are only logged as the transition has
+// already taken place.
+func recordUsage(record models.UsageRecord) {
+	if err := dbCon.NewUsageRecord(&record); err != nil {
+		log.GetLogger().Error("failed to record usage history", zap.String("service name", record.ServiceName),
+			zap.String("transition", string(record.Transition)), zap.Error(err))
+	}
+}
+
+// recordServiceExpiry adds the expiry of the service to the usage history
+func recordServiceExpiry(service models.Service) {
+	logger := log.GetLogger()
+	catalog, err := kubeClient.GetCatalog(service.CatalogName)
+	if err != nil {
+		logger.Error("failed to get catalog", zap.String("catalog name", service.CatalogName), zap.Error(err))
+		return
+	}
+	cpu, err := utils.CastStrToFloat(catalog.Spec.Capacity.CPU)
+	if err != nil {
+		logger.Error("failed to get catalog capacity", zap.String("catalog name", service.CatalogName), zap.Error(err))
+		return
+	}
+	recordUsage(models.UsageRecord{
+		UserID:      service.UserID,
+		ServiceName: service.Name,
+		CatalogName: service.CatalogName,
+		Transition:  models.UsageTransitionExpired,
+		Capacity:    models.Capacity{CPU: cpu, Memory: catalog.Spec.Capacity.Memory},
+		Timestamp:   service.Expiry,
+	})
+}
+
+// getMonthBounds returns the start of the calendar month of t and the start of the next month
+func getMonthBounds(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// getCommittedCoreHours returns the core-hours consumed by the services of the user between start and end, services
+// which are still running are accounted till their expiry.
+func getCommittedCoreHours(userID string, start, end time.Time) (float64, error) {
+	usage, err := dbCon.GetServiceUsage([]string{userID}, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get service usage %v", err)
+	}
+	var coreHours float64
+	for _, u := range usage {
+		coreHours += u.CoreHours(start, end)
+	}
+	return coreHours, nil
+}
+
+// checkCoreHoursBudget verifies that running cpu cores from start till end fits in the monthly core-hours budget of
+// the quota for every month in between, utils.ErrQuotaExceeded is returned if it does not.
+func checkCoreHoursBudget(userID string, quota models.Capacity, cpu float64, start, end time.Time) error {
+	if quota.CoreHours == 0 {
+		return nil
+	}
+	usage := models.ServiceUsage{CPU: cpu, StartedAt: start, ExpiresAt: end}
+	for monthStart, monthEnd := getMonthBounds(start); monthStart.Before(end); monthStart, monthEnd = getMonthBounds(monthEnd) {
+		committed, err := getCommittedCoreHours(userID, monthStart, monthEnd)
+		if err != nil {
+			return err
+		}
+		required := usage.CoreHours(monthStart, monthEnd)
+		if committed+required > quota.CoreHours {
+			return fmt.Errorf("%w, core-hours budget for %s: %.2f committed: %.2f required: %.2f", utils.ErrQuotaExceeded,
+				monthStart.Format("2006-01"), quota.CoreHours, committed, required)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Nerzal/gocloak/v13"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/models"
+	"github.com/PDeXchange/pac/internal/pkg/pac-go-server/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	_, mockDBClient, mockKCClient, tearDown := setUp(t)
+	defer tearDown()
+
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	records := []models.UsageRecord{
+		{UserID: "12345", ServiceName: "test-service", Transition: models.UsageTransitionCreated, Timestamp: day.Add(2 * time.Hour)},
+		{UserID: "12345", ServiceName: "test-service", Transition: models.UsageTransitionDeleted, Timestamp: day.AddDate(0, 0, 1).Add(2 * time.Hour)},
+	}
+	endedAt := day.AddDate(0, 0, 1).Add(2 * time.Hour)
+	usage := []models.ServiceUsage{
+		{UserID: "12345", ServiceName: "test-service", CPU: 2, Memory: 4, StartedAt: day.Add(2 * time.Hour), ExpiresAt: day.AddDate(0, 0, 10), EndedAt: &endedAt},
+	}
+
+	testcases := []struct {
+		name           string
+		mockFunc       func()
+		query          string
+		requestContext testContext
+		httpStatus     int
+		usage          []models.UsageSummary
+	}{
+		{
+			name: "user fetched own usage history",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(utils.ManagerRole).Return(false).Times(1)
+				mockKCClient.EXPECT().GetUserID().Return("12345").Times(1)
+				mockDBClient.EXPECT().GetUsageRecords([]string{"12345"}, day, day.AddDate(0, 0, 2)).Return(records, nil).Times(1)
+			},
+			query:      "from=2024-03-01&to=2024-03-02",
+			httpStatus: http.StatusOK,
+		},
+		{
+			name: "user fetching usage of other user",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(utils.ManagerRole).Return(false).Times(1)
+				mockKCClient.EXPECT().GetUserID().Return("12345").Times(1)
+			},
+			query:      "user_id=67890",
+			httpStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "admin fetched daily usage of group",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(utils.ManagerRole).Return(true).Times(1)
+				mockKCClient.EXPECT().GetGroupMembers("test-group").Return([]*gocloak.User{{ID: utils.Ptr("12345")}}, nil).Times(1)
+				mockDBClient.EXPECT().GetUsageRecords([]string{"12345"}, day, day.AddDate(0, 0, 2)).Return(records, nil).Times(1)
+				mockDBClient.EXPECT().GetServiceUsage([]string{"12345"}, day, day.AddDate(0, 0, 2)).Return(usage, nil).Times(1)
+			},
+			query:      "group_id=test-group&from=2024-03-01&to=2024-03-02&aggregate=daily",
+			httpStatus: http.StatusOK,
+			usage: []models.UsageSummary{
+				{Period: day, CoreHours: 44, MemoryHours: 88, Created: 1},
+				{Period: day.AddDate(0, 0, 1), CoreHours: 4, MemoryHours: 8, Deleted: 1},
+			},
+		},
+		{
+			name: "invalid aggregation",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(utils.ManagerRole).Return(true).Times(1)
+			},
+			query:      "aggregate=weekly",
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid date",
+			mockFunc: func() {
+				mockKCClient.EXPECT().IsRole(utils.ManagerRole).Return(true).Times(1)
+			},
+			query:      "from=01-03-2024",
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.mockFunc()
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			req, err := http.NewRequest(http.MethodGet, "/usage?"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := getContext(tc.requestContext)
+			c.Request = req.WithContext(ctx)
+			dbCon = mockDBClient
+			GetUsage(c)
+			assert.Equal(t, tc.httpStatus, c.Writer.Status())
+			if tc.usage != nil {
+				var response models.UsageResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tc.usage, response.Usage)
+			}
+		})
+	}
+}
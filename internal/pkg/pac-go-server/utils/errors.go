@@ -8,4 +8,6 @@ var (
 	ErrResourceNotFound      = errors.New("requested resource not found")
 	ErrResourceAlreadyExists = errors.New("requested resource already exists")
 	ErrNotAuthorized         = errors.New("user does not have permission to delete this key")
+	ErrQuotaExceeded         = errors.New("requested capacity exceeds the available quota")
+	ErrResourceModified      = errors.New("requested resource was modified concurrently")
 )